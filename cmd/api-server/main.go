@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/cache"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/client/emailclient"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/client/failover"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/client/openmeteo"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/client/weatherapi"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/handler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/circuitbreaker"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/logger/cronlogger"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/managelink"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/ratelimit"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/retry"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/repository/posgresql"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/service"
 	"github.com/denyshuzovskyi/nimbus-notify/migrations"
@@ -22,12 +32,18 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	_ "time/tzdata"
 )
 
 func main() {
 	cfg := config.ReadConfig("./config/config.yaml")
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	validate := validator.New()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	db, err := sql.Open("pgx", cfg.Datasource.Url)
 	if err != nil {
@@ -75,61 +91,193 @@ func main() {
 	confirmEmailData, confOk := emailDataMap["confirmation"]
 	confirmSuccessEmailData, confSuccessOk := emailDataMap["confirmation-successful"]
 	weatherEmailData, weatherOk := emailDataMap["weather"]
+	dailyWeatherEmailData, dailyWeatherOk := emailDataMap["daily-weather"]
 	unsubEmailData, unsubOk := emailDataMap["unsubscribe"]
-	if !confOk || !confSuccessOk || !weatherOk || !unsubOk {
+	airQualityAlertEmailData, airQualityAlertOk := emailDataMap["air-quality-alert"]
+	weatherAlertEmailData, weatherAlertOk := emailDataMap["weather-alert"]
+	changeEmailData, changeOk := emailDataMap["subscription-changed"]
+	if !confOk || !confSuccessOk || !weatherOk || !dailyWeatherOk || !unsubOk || !airQualityAlertOk || !weatherAlertOk || !changeOk {
 		log.Error("cannot prepare email data")
 		os.Exit(1)
 	}
 
-	weatherApiClient := weatherapi.NewClient(cfg.WeatherProvider.Url, cfg.WeatherProvider.Key, &http.Client{}, log)
-	emailClient := emailclient.NewEmailClient(mailgun.NewMailgun(cfg.EmailService.Domain, cfg.EmailService.Key))
+	providerRegistry := failover.NewRegistry()
+	providerRegistry.Register(failover.Entry{
+		Name:     weatherapi.ProviderName,
+		Priority: cfg.WeatherProvider.Priority,
+		Timeout:  cfg.WeatherProvider.Timeout,
+		Provider: weatherapi.NewClient(cfg.WeatherProvider.Url, cfg.WeatherProvider.Key, &http.Client{}, weatherapi.Options{
+			RequestTimeout: cfg.WeatherProvider.Retry.RequestTimeout,
+			MaxRetries:     cfg.WeatherProvider.Retry.MaxRetries,
+			Backoff: retry.Backoff{
+				BaseDelay: cfg.WeatherProvider.Retry.BaseDelay,
+				MaxDelay:  cfg.WeatherProvider.Retry.MaxDelay,
+			},
+			Breaker: circuitbreaker.New(cfg.WeatherProvider.Breaker.FailureThreshold, cfg.WeatherProvider.Breaker.OpenTimeout),
+		}, log),
+	})
+	if cfg.OpenMeteo.Enabled {
+		providerRegistry.Register(failover.Entry{
+			Name:     openmeteo.ProviderName,
+			Priority: cfg.OpenMeteo.Priority,
+			Timeout:  cfg.OpenMeteo.Timeout,
+			Provider: openmeteo.NewClient(cfg.OpenMeteo.Url, cfg.OpenMeteo.GeocodingUrl, cfg.OpenMeteo.AirQualityUrl, &http.Client{}, log),
+		})
+	}
+	weatherCache := cache.NewWeatherCache(failover.NewProvider(providerRegistry, log), cfg.WeatherCache.TTL, log)
+	tokenSigner, err := signedtoken.New(cfg.Tokens.Keys)
+	if err != nil {
+		log.Error("failed to configure token signing", "error", err)
+		os.Exit(1)
+	}
+	manageLinker, err := managelink.New(cfg.Management.Keys, cfg.Management.Url, cfg.Management.LinkTTL)
+	if err != nil {
+		log.Error("failed to configure management links", "error", err)
+		os.Exit(1)
+	}
+	if !strings.HasPrefix(cfg.Unsubscribe.Url, "https://") {
+		log.Warn("unsubscribe url is not https, mail clients ignore one-click unsubscribe", "url", cfg.Unsubscribe.Url)
+	}
+	emailClient := emailclient.NewEmailClient(mailgun.NewMailgun(cfg.EmailService.Domain, cfg.EmailService.Key), ratelimit.New(cfg.Notifications.SendRate, cfg.Notifications.SendBurst))
 	locationRepository := posgresql.NewLocationRepository()
 	weatherRepository := posgresql.NewWeatherRepository()
+	airQualityRepository := posgresql.NewAirQualityRepository()
+	weatherAlertRepository := posgresql.NewWeatherAlertRepository()
 	subscriberRepository := posgresql.NewSubscriberRepository()
 	subscriptionRepository := posgresql.NewSubscriptionRepository()
+	subscriptionConditionRepository := posgresql.NewSubscriptionConditionRepository()
 	tokenRepository := posgresql.NewTokenRepository()
-	weatherService := service.NewWeatherService(db, weatherApiClient, locationRepository, weatherRepository, log)
-	subscriptionService := service.NewSubscriptionService(db, weatherApiClient, locationRepository, subscriberRepository, subscriptionRepository, tokenRepository, emailClient, confirmEmailData, confirmSuccessEmailData, unsubEmailData, log)
-	notificationService := service.NewNotificationService(db, weatherApiClient, locationRepository, weatherRepository, subscriberRepository, subscriptionRepository, tokenRepository, emailClient, log)
-	weatherHandler := handler.NewWeatherHandler(weatherService, log)
+	emailOutboxRepository := posgresql.NewEmailOutboxRepository()
+	notificationDeliveryRepository := posgresql.NewNotificationDeliveryRepository()
+	weatherService := service.NewWeatherService(db, weatherCache, locationRepository, weatherRepository, airQualityRepository, log)
+	subscriptionService := service.NewSubscriptionService(db, weatherCache, locationRepository, subscriberRepository, subscriptionRepository, subscriptionConditionRepository, tokenRepository, emailOutboxRepository, manageLinker, tokenSigner, cfg.Tokens, cfg.Confirmation, confirmEmailData, confirmSuccessEmailData, unsubEmailData, changeEmailData, log)
+	notificationService := service.NewNotificationService(db, weatherCache, locationRepository, weatherRepository, airQualityRepository, weatherAlertRepository, notificationDeliveryRepository, subscriberRepository, subscriptionRepository, subscriptionConditionRepository, tokenRepository, emailClient, manageLinker, tokenSigner, cfg.Unsubscribe.Url, cfg.Notifications, log)
+	janitorService := service.NewJanitorService(db, tokenRepository, subscriptionRepository, subscriberRepository, emailOutboxRepository, notificationDeliveryRepository, cfg.Janitor, log)
+	if err := cfg.Tokens.Validate(); err != nil {
+		log.Error("invalid tokens config", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.EmailOutbox.Validate(); err != nil {
+		log.Error("invalid email outbox config", "error", err)
+		os.Exit(1)
+	}
+	emailDispatcher := service.NewEmailDispatcher(db, emailOutboxRepository, emailClient, cfg.EmailOutbox, log)
+	locationService := service.NewLocationService(weatherCache, log)
+	weatherHandler := handler.NewWeatherHandler(weatherService, validate, log)
+	locationHandler := handler.NewLocationHandler(locationService, log)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService, validate, log)
+	managementHandler := handler.NewManagementHandler(subscriptionService, validate, log)
 
-	c := cron.New()
-	// daily 09:00
-	_, err = c.AddFunc("0 9 * * *", func() {
-		notificationService.SendDailyNotifications(weatherEmailData)
+	// run that outlasts its interval makes the next one skip, so the same subscriptions are never processed twice at once
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cronlogger.NewCronLogger(log))))
+	// daily emails are due at the local hour of each subscription, checking every 15 minutes covers half and quarter hour offsets
+	_, err = c.AddFunc("*/15 * * * *", func() {
+		notificationService.SendDailyNotifications(ctx, dailyWeatherEmailData)
+	})
+	if err != nil {
+		log.Error("failed to schedule notification service", "error", err)
+		os.Exit(1)
+	}
+	// hourly emails go out on the first run of each hour, the other runs retry failed deliveries
+	_, err = c.AddFunc("*/15 * * * *", func() {
+		notificationService.SendHourlyNotifications(ctx, weatherEmailData)
+	})
+	if err != nil {
+		log.Error("failed to schedule notification service", "error", err)
+		os.Exit(1)
+	}
+	_, err = c.AddFunc("*/30 * * * *", func() {
+		notificationService.SendAirQualityAlerts(ctx, airQualityAlertEmailData)
+	})
+	if err != nil {
+		log.Error("failed to schedule notification service", "error", err)
+		os.Exit(1)
+	}
+	_, err = c.AddFunc("*/15 * * * *", func() {
+		notificationService.SendScheduledNotifications(ctx, weatherEmailData)
 	})
 	if err != nil {
 		log.Error("failed to schedule notification service", "error", err)
 		os.Exit(1)
 	}
-	// hourly
-	_, err = c.AddFunc("0 * * * *", func() {
-		notificationService.SendHourlyNotifications(weatherEmailData)
+	// alerts are polled on their own interval so that a new alert does not wait for the hourly run
+	_, err = c.AddFunc("@every "+cfg.WeatherAlerts.PollInterval.String(), func() {
+		notificationService.SendWeatherAlerts(ctx, weatherAlertEmailData)
 	})
 	if err != nil {
 		log.Error("failed to schedule notification service", "error", err)
 		os.Exit(1)
 	}
+	// emails of subscription flows are written to outbox within their transaction and delivered here
+	_, err = c.AddFunc("@every "+cfg.EmailOutbox.PollInterval.String(), func() {
+		emailDispatcher.Dispatch(ctx)
+	})
+	if err != nil {
+		log.Error("failed to schedule email dispatcher", "error", err)
+		os.Exit(1)
+	}
+	_, err = c.AddFunc("@every "+cfg.Janitor.Interval.String(), func() {
+		janitorService.Cleanup(ctx)
+	})
+	if err != nil {
+		log.Error("failed to schedule janitor", "error", err)
+		os.Exit(1)
+	}
+	_, err = c.AddFunc("*/15 * * * *", func() {
+		stats := weatherCache.Stats()
+		log.Info("weather cache stats", "hits", stats.Hits, "misses", stats.Misses)
+	})
+	if err != nil {
+		log.Error("failed to schedule weather cache stats", "error", err)
+		os.Exit(1)
+	}
 	c.Start()
 
 	router := http.NewServeMux()
 	router.HandleFunc("GET /weather", weatherHandler.GetCurrentWeather)
+	router.HandleFunc("GET /forecast", weatherHandler.GetForecast)
+	router.HandleFunc("GET /locations/search", locationHandler.Search)
 	router.HandleFunc("POST /subscribe", subscriptionHandler.Subscribe)
 	router.HandleFunc("GET /confirm/{token}", subscriptionHandler.Confirm)
-	router.HandleFunc("GET /unsubscribe/{token}", subscriptionHandler.Unsubscribe)
+	router.HandleFunc("POST /confirm/resend", subscriptionHandler.ResendConfirmation)
+	router.HandleFunc("GET /manage/{token}", managementHandler.Get)
+	router.HandleFunc("POST /manage/{token}/subscriptions", managementHandler.AddSubscription)
+	router.HandleFunc("POST /manage/{token}/subscriptions/{id}/frequency", managementHandler.ChangeFrequency)
+	router.HandleFunc("POST /manage/{token}/subscriptions/{id}/delete", managementHandler.DeleteSubscription)
+	router.HandleFunc("DELETE /manage/{token}/subscriptions/{id}", managementHandler.DeleteSubscription)
+	router.HandleFunc("GET /unsubscribe/{token}", subscriptionHandler.UnsubscribePage)
+	router.HandleFunc("POST /unsubscribe/{token}", subscriptionHandler.Unsubscribe)
+	router.HandleFunc("PATCH /subscriptions/{id}", managementHandler.UpdateSubscription)
 
 	server := http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.HTTPServer.Host, cfg.HTTPServer.Port),
 		Handler: router,
 	}
 
-	log.Info("starting server", "host", cfg.HTTPServer.Host, "port", cfg.HTTPServer.Port)
+	go func() {
+		log.Info("starting server", "host", cfg.HTTPServer.Host, "port", cfg.HTTPServer.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("failed to start server", "error", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info("shutting down")
 
-	err = server.ListenAndServe()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
 	if err != nil {
-		log.Error("failed to start server", "error", err)
-		return
+		log.Error("failed to shut down server", "error", err)
+	}
+	// cancelled ctx stops notification runs from taking new work, emails already being sent are waited for
+	select {
+	case <-c.Stop().Done():
+		log.Info("scheduled jobs finished")
+	case <-shutdownCtx.Done():
+		log.Warn("scheduled jobs did not finish in time")
 	}
 }
 
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"golang.org/x/sync/singleflight"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type WeatherProvider interface {
+	GetCurrentWeather(context.Context, string, string) (*model.WeatherWithLocation, error)
+	GetForecast(context.Context, string, int, string) (*model.Forecast, error)
+	GetAirQuality(context.Context, string) (*model.AirQualityWithLocation, error)
+	GetAlerts(context.Context, string) ([]model.WeatherAlert, error)
+	SearchLocations(context.Context, string) ([]model.Location, error)
+}
+
+type Stats struct {
+	Hits   int64
+	Misses int64
+}
+
+type entry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// WeatherCache is a WeatherProvider decorator that keeps provider responses in memory for ttl
+// and collapses concurrent lookups of the same location into a single provider call
+type WeatherCache struct {
+	provider  WeatherProvider
+	ttl       time.Duration
+	mu        sync.RWMutex
+	entries   map[string]entry
+	lastSweep time.Time
+	group     singleflight.Group
+	hits      atomic.Int64
+	misses    atomic.Int64
+	log       *slog.Logger
+}
+
+func NewWeatherCache(provider WeatherProvider, ttl time.Duration, log *slog.Logger) *WeatherCache {
+	return &WeatherCache{
+		provider:  provider,
+		ttl:       ttl,
+		entries:   make(map[string]entry),
+		lastSweep: time.Now(),
+		log:       log,
+	}
+}
+
+func (c *WeatherCache) GetCurrentWeather(ctx context.Context, location string, lang string) (*model.WeatherWithLocation, error) {
+	key := "current:" + lang + ":" + NormalizeLocation(location)
+
+	v, err := c.get(ctx, key, func(ctx context.Context) (any, error) {
+		return c.provider.GetCurrentWeather(ctx, location, lang)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	weather := *v.(*model.WeatherWithLocation)
+	return &weather, nil
+}
+
+func (c *WeatherCache) GetForecast(ctx context.Context, location string, days int, lang string) (*model.Forecast, error) {
+	key := "forecast:" + strconv.Itoa(days) + ":" + lang + ":" + NormalizeLocation(location)
+
+	v, err := c.get(ctx, key, func(ctx context.Context) (any, error) {
+		return c.provider.GetForecast(ctx, location, days, lang)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := *v.(*model.Forecast)
+	return &forecast, nil
+}
+
+func (c *WeatherCache) GetAirQuality(ctx context.Context, location string) (*model.AirQualityWithLocation, error) {
+	key := "aqi:" + NormalizeLocation(location)
+
+	v, err := c.get(ctx, key, func(ctx context.Context) (any, error) {
+		return c.provider.GetAirQuality(ctx, location)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	airQuality := *v.(*model.AirQualityWithLocation)
+	return &airQuality, nil
+}
+
+// GetAlerts is not cached, a new alert has to reach subscribers on the next poll
+func (c *WeatherCache) GetAlerts(ctx context.Context, location string) ([]model.WeatherAlert, error) {
+	return c.provider.GetAlerts(ctx, location)
+}
+
+func (c *WeatherCache) SearchLocations(ctx context.Context, query string) ([]model.Location, error) {
+	key := "search:" + NormalizeLocation(query)
+
+	v, err := c.get(ctx, key, func(ctx context.Context) (any, error) {
+		return c.provider.SearchLocations(ctx, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cached := v.([]model.Location)
+	locations := make([]model.Location, len(cached))
+	copy(locations, cached)
+	return locations, nil
+}
+
+func (c *WeatherCache) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+func (c *WeatherCache) get(ctx context.Context, key string, fetch func(context.Context) (any, error)) (any, error) {
+	now := time.Now()
+
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && now.Before(e.expiresAt) {
+		c.hits.Add(1)
+		return e.value, nil
+	}
+	c.misses.Add(1)
+
+	// the fetch is shared by every caller waiting on the key, so one caller giving up must not cancel it for the rest
+	ch := c.group.DoChan(key, func() (any, error) {
+		v, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, v)
+		return v, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Shared {
+			c.log.Debug("weather lookup shared with concurrent request", "key", key)
+		}
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *WeatherCache) set(key string, v any) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry{value: v, expiresAt: now.Add(c.ttl)}
+
+	if now.Sub(c.lastSweep) > c.ttl {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+}
+
+func NormalizeLocation(location string) string {
+	return strings.ToLower(strings.Join(strings.Fields(location), " "))
+}
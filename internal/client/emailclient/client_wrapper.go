@@ -3,28 +3,40 @@ package emailclient
 import (
 	"context"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/ratelimit"
 	"github.com/mailgun/mailgun-go/v4"
 )
 
 type EmailClientWrapper struct {
-	client *mailgun.MailgunImpl
+	client  *mailgun.MailgunImpl
+	limiter *ratelimit.Limiter
 }
 
-func NewEmailClient(client *mailgun.MailgunImpl) *EmailClientWrapper {
+// NewEmailClient shares limiter between all senders so that together they stay within mailgun rate limit
+func NewEmailClient(client *mailgun.MailgunImpl, limiter *ratelimit.Limiter) *EmailClientWrapper {
 	return &EmailClientWrapper{
-		client: client,
+		client:  client,
+		limiter: limiter,
 	}
 }
 
-func (w *EmailClientWrapper) Send(ctx context.Context, email dto.SimpleEmail) error {
+// Send returns message id assigned by mailgun
+func (w *EmailClientWrapper) Send(ctx context.Context, email dto.SimpleEmail) (string, error) {
+	if err := w.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+
 	m := mailgun.NewMessage(
 		email.From,
 		email.Subject,
 		email.Text,
 		email.To,
 	)
+	for name, value := range email.Headers {
+		m.AddHeader(name, value)
+	}
 
-	_, _, err := w.client.Send(ctx, m)
+	_, id, err := w.client.Send(ctx, m)
 
-	return err
+	return id, err
 }
@@ -0,0 +1,134 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"log/slog"
+)
+
+var ErrNoProviders = errors.New("no weather providers registered")
+
+type Provider struct {
+	registry *Registry
+	log      *slog.Logger
+}
+
+func NewProvider(registry *Registry, log *slog.Logger) *Provider {
+	return &Provider{
+		registry: registry,
+		log:      log,
+	}
+}
+
+func (p *Provider) GetCurrentWeather(ctx context.Context, location string, lang string) (*model.WeatherWithLocation, error) {
+	return try(ctx, p, "GetCurrentWeather", func(ctx context.Context, e Entry) (*model.WeatherWithLocation, error) {
+		weather, err := e.Provider.GetCurrentWeather(ctx, location, lang)
+		if err != nil {
+			return nil, err
+		}
+		weather.Weather.Provider = e.Name
+		return weather, nil
+	})
+}
+
+func (p *Provider) GetForecast(ctx context.Context, location string, days int, lang string) (*model.Forecast, error) {
+	return try(ctx, p, "GetForecast", func(ctx context.Context, e Entry) (*model.Forecast, error) {
+		return e.Provider.GetForecast(ctx, location, days, lang)
+	})
+}
+
+func (p *Provider) GetAirQuality(ctx context.Context, location string) (*model.AirQualityWithLocation, error) {
+	return try(ctx, p, "GetAirQuality", func(ctx context.Context, e Entry) (*model.AirQualityWithLocation, error) {
+		airQuality, err := e.Provider.GetAirQuality(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		airQuality.AirQuality.Provider = e.Name
+		return airQuality, nil
+	})
+}
+
+func (p *Provider) GetAlerts(ctx context.Context, location string) ([]model.WeatherAlert, error) {
+	alerts, err := try(ctx, p, "GetAlerts", func(ctx context.Context, e Entry) (*[]model.WeatherAlert, error) {
+		alerts, err := e.Provider.GetAlerts(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		return &alerts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return *alerts, nil
+}
+
+func (p *Provider) SearchLocations(ctx context.Context, query string) ([]model.Location, error) {
+	locations, err := try(ctx, p, "SearchLocations", func(ctx context.Context, e Entry) (*[]model.Location, error) {
+		locations, err := e.Provider.SearchLocations(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return &locations, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return *locations, nil
+}
+
+// try calls providers in priority order until one succeeds. ErrLocationNotFound is an answer, not an outage,
+// so it is returned right away instead of failing over
+func try[T any](ctx context.Context, p *Provider, op string, fn func(context.Context, Entry) (*T, error)) (*T, error) {
+	entries := p.registry.Entries()
+	if len(entries) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var errs []error
+	for _, e := range entries {
+		res, err := callWithTimeout(ctx, e, fn)
+		if err == nil {
+			return res, nil
+		}
+		if errors.Is(err, commonerrors.ErrLocationNotFound) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		p.log.Warn("weather provider failed, trying next one", "op", op, "provider", e.Name, "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", e.Name, err))
+	}
+
+	return nil, fmt.Errorf("all weather providers failed: %w", errors.Join(errs...))
+}
+
+func callWithTimeout[T any](ctx context.Context, e Entry, fn func(context.Context, Entry) (*T, error)) (*T, error) {
+	if e.Timeout <= 0 {
+		return fn(ctx, e)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	type result struct {
+		res *T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, err := fn(ctx, e)
+		done <- result{res, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("provider timed out after %s: %w", e.Timeout, ctx.Err())
+	}
+}
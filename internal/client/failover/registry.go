@@ -0,0 +1,45 @@
+package failover
+
+import (
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"sort"
+	"time"
+)
+
+type WeatherProvider interface {
+	GetCurrentWeather(context.Context, string, string) (*model.WeatherWithLocation, error)
+	GetForecast(context.Context, string, int, string) (*model.Forecast, error)
+	GetAirQuality(context.Context, string) (*model.AirQualityWithLocation, error)
+	GetAlerts(context.Context, string) ([]model.WeatherAlert, error)
+	SearchLocations(context.Context, string) ([]model.Location, error)
+}
+
+type Entry struct {
+	Name     string
+	Priority int
+	Timeout  time.Duration
+	Provider WeatherProvider
+}
+
+// Registry keeps weather providers ordered by priority, the lower value is tried first
+type Registry struct {
+	entries []Entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(entry Entry) {
+	r.entries = append(r.entries, entry)
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return r.entries[i].Priority < r.entries[j].Priority
+	})
+}
+
+func (r *Registry) Entries() []Entry {
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
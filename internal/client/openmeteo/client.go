@@ -0,0 +1,216 @@
+package openmeteo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const ProviderName = "open-meteo"
+
+const (
+	currentVariables    = "temperature_2m,relative_humidity_2m,apparent_temperature,precipitation,weather_code,cloud_cover,pressure_msl,wind_speed_10m,wind_direction_10m,uv_index,visibility"
+	hourlyVariables     = "temperature_2m,relative_humidity_2m,precipitation_probability,weather_code"
+	airQualityVariables = "pm2_5,pm10,ozone,nitrogen_dioxide,us_aqi"
+	dailyVariables      = "temperature_2m_max,temperature_2m_min,temperature_2m_mean,relative_humidity_2m_mean,precipitation_probability_max,weather_code"
+)
+
+type Client struct {
+	baseURL       string
+	geocodingURL  string
+	airQualityURL string
+	client        *http.Client
+	log           *slog.Logger
+}
+
+func NewClient(baseURL, geocodingURL, airQualityURL string, client *http.Client, log *slog.Logger) *Client {
+	return &Client{
+		baseURL:       baseURL,
+		geocodingURL:  geocodingURL,
+		airQualityURL: airQualityURL,
+		client:        client,
+		log:           log,
+	}
+}
+
+func (c *Client) GetCurrentWeather(ctx context.Context, location string, _ string) (*model.WeatherWithLocation, error) {
+	geo, err := c.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("current", currentVariables)
+	// resolves timezone of coordinates, current values are unix times and do not depend on it
+	q.Set("timezone", "auto")
+
+	var forecast ForecastResponse
+	if err := c.getForecast(ctx, geo, q, &forecast); err != nil {
+		return nil, err
+	}
+	if geo.Timezone == "" {
+		geo.Timezone = forecast.Timezone
+	}
+	weatherWithLocation := CurrentToWeatherWithLocation(forecast.Current, *geo)
+
+	return &weatherWithLocation, nil
+}
+
+func (c *Client) GetForecast(ctx context.Context, location string, days int, _ string) (*model.Forecast, error) {
+	geo, err := c.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("hourly", hourlyVariables)
+	q.Set("daily", dailyVariables)
+	q.Set("forecast_days", strconv.Itoa(days))
+	// days follow local calendar of the location, otherwise daily high and low would cover the UTC day
+	q.Set("timezone", "auto")
+
+	var forecastResp ForecastResponse
+	if err := c.getForecast(ctx, geo, q, &forecastResp); err != nil {
+		return nil, err
+	}
+	if geo.Timezone == "" {
+		geo.Timezone = forecastResp.Timezone
+	}
+	forecast := ForecastResponseToForecast(forecastResp, *geo)
+
+	return &forecast, nil
+}
+
+func (c *Client) GetAirQuality(ctx context.Context, location string) (*model.AirQualityWithLocation, error) {
+	geo, err := c.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("current", airQualityVariables)
+	q.Set("latitude", strconv.FormatFloat(geo.Latitude, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(geo.Longitude, 'f', -1, 64))
+	q.Set("timeformat", "unixtime")
+
+	var resp AirQualityResponse
+	if err := c.getJSON(ctx, c.airQualityURL+"/air-quality", q, &resp); err != nil {
+		return nil, err
+	}
+	airQuality := CurrentAirQualityToAirQualityWithLocation(resp.Current, *geo)
+
+	return &airQuality, nil
+}
+
+func (c *Client) GetAlerts(_ context.Context, _ string) ([]model.WeatherAlert, error) {
+	return nil, commonerrors.ErrNotSupported
+}
+
+func (c *Client) SearchLocations(ctx context.Context, query string) ([]model.Location, error) {
+	q := url.Values{}
+	q.Set("name", query)
+	q.Set("count", "10")
+	q.Set("format", "json")
+
+	var geo GeocodingResponse
+	if err := c.getJSON(ctx, c.geocodingURL+"/search", q, &geo); err != nil {
+		return nil, err
+	}
+
+	locations := make([]model.Location, 0, len(geo.Results))
+	for _, r := range geo.Results {
+		locations = append(locations, GeocodingResultToLocation(r))
+	}
+
+	return locations, nil
+}
+
+// geocode looks up a name, coordinates are used as they are since open-meteo has no reverse geocoding.
+// Their result has no timezone until a forecast response tells it
+func (c *Client) geocode(ctx context.Context, location string) (*GeocodingResult, error) {
+	if lat, lon, ok := parseCoordinates(location); ok {
+		return &GeocodingResult{Name: location, Latitude: lat, Longitude: lon}, nil
+	}
+
+	q := url.Values{}
+	q.Set("name", location)
+	q.Set("count", "1")
+	q.Set("format", "json")
+
+	var geo GeocodingResponse
+	if err := c.getJSON(ctx, c.geocodingURL+"/search", q, &geo); err != nil {
+		return nil, err
+	}
+	if len(geo.Results) == 0 {
+		return nil, commonerrors.ErrLocationNotFound
+	}
+
+	return &geo.Results[0], nil
+}
+
+func (c *Client) getForecast(ctx context.Context, geo *GeocodingResult, q url.Values, v any) error {
+	q.Set("latitude", strconv.FormatFloat(geo.Latitude, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(geo.Longitude, 'f', -1, 64))
+	q.Set("timeformat", "unixtime")
+
+	return c.getJSON(ctx, c.baseURL+"/forecast", q, v)
+}
+
+func (c *Client) getJSON(ctx context.Context, rawURL string, q url.Values, v any) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse url %w", err)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform get request %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			c.log.Error("failed to close body", "error", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed decode response %w", err)
+	}
+
+	return nil
+}
+
+// parseCoordinates recognizes "lat,lon" queries which don't need geocoding
+func parseCoordinates(location string) (float64, float64, bool) {
+	latStr, lonStr, found := strings.Cut(location, ",")
+	if !found {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
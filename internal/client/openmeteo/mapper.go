@@ -0,0 +1,177 @@
+package openmeteo
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"math"
+	"time"
+)
+
+// WMO weather interpretation codes, see https://open-meteo.com/en/docs
+var weatherCodeDescriptions = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	56: "Light freezing drizzle",
+	57: "Dense freezing drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Heavy freezing rain",
+	71: "Slight snow fall",
+	73: "Moderate snow fall",
+	75: "Heavy snow fall",
+	77: "Snow grains",
+	80: "Slight rain showers",
+	81: "Moderate rain showers",
+	82: "Violent rain showers",
+	85: "Slight snow showers",
+	86: "Heavy snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with slight hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+func WeatherCodeToDescription(code int) string {
+	if desc, ok := weatherCodeDescriptions[code]; ok {
+		return desc
+	}
+	return "Unknown"
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+func DegreeToCompass(degree float32) string {
+	i := int(math.Round(float64(degree)/22.5)) % len(compassPoints)
+	if i < 0 {
+		i += len(compassPoints)
+	}
+	return compassPoints[i]
+}
+
+func GeocodingResultToLocation(result GeocodingResult) model.Location {
+	return model.Location{
+		Id:        0,
+		Name:      result.Name,
+		Region:    result.Admin1,
+		Country:   result.Country,
+		Latitude:  result.Latitude,
+		Longitude: result.Longitude,
+		TzId:      result.Timezone,
+		Key:       model.LocationKey(result.Name, result.Latitude, result.Longitude),
+	}
+}
+
+func CurrentToWeatherWithLocation(current Current, location GeocodingResult) model.WeatherWithLocation {
+	return model.WeatherWithLocation{
+		Weather: model.Weather{
+			LocationId:    0,
+			LastUpdated:   time.Unix(current.Time, 0).UTC(),
+			FetchedAt:     time.Unix(0, 0),
+			Temperature:   current.Temperature2m,
+			Humidity:      current.RelativeHumidity2m,
+			Description:   WeatherCodeToDescription(current.WeatherCode),
+			FeelsLike:     current.ApparentTemperature,
+			WindSpeed:     current.WindSpeed10m,
+			WindDegree:    int32(current.WindDirection10m),
+			WindDirection: DegreeToCompass(current.WindDirection10m),
+			Pressure:      current.PressureMsl,
+			UVIndex:       current.UVIndex,
+			Precipitation: current.Precipitation,
+			CloudCover:    current.CloudCover,
+			Visibility:    current.Visibility / 1000,
+			Provider:      ProviderName,
+		},
+		Location: GeocodingResultToLocation(location),
+	}
+}
+
+func ForecastResponseToForecast(resp ForecastResponse, location GeocodingResult) model.Forecast {
+	zone := time.FixedZone("", resp.UtcOffsetSeconds)
+	days := make([]model.DailyForecast, 0, len(resp.Daily.Time))
+	for i, dayTime := range resp.Daily.Time {
+		dayStart := time.Unix(dayTime, 0).In(zone)
+		dayEnd := dayStart.AddDate(0, 0, 1)
+
+		var hours []model.HourlyForecast
+		for j, hourTime := range resp.Hourly.Time {
+			t := time.Unix(hourTime, 0).In(zone)
+			if t.Before(dayStart) || !t.Before(dayEnd) {
+				continue
+			}
+			hours = append(hours, model.HourlyForecast{
+				Time:         t,
+				Temperature:  at(resp.Hourly.Temperature2m, j),
+				Humidity:     at(resp.Hourly.RelativeHumidity2m, j),
+				ChanceOfRain: at(resp.Hourly.PrecipitationProbability, j),
+				Description:  WeatherCodeToDescription(at(resp.Hourly.WeatherCode, j)),
+			})
+		}
+
+		days = append(days, model.DailyForecast{
+			Date:           dayStart,
+			MaxTemperature: at(resp.Daily.Temperature2mMax, i),
+			MinTemperature: at(resp.Daily.Temperature2mMin, i),
+			AvgTemperature: at(resp.Daily.Temperature2mMean, i),
+			AvgHumidity:    at(resp.Daily.RelativeHumidity2mMean, i),
+			ChanceOfRain:   at(resp.Daily.PrecipitationProbabilityMax, i),
+			Description:    WeatherCodeToDescription(at(resp.Daily.WeatherCode, i)),
+			Hours:          hours,
+		})
+	}
+
+	return model.Forecast{
+		Location: GeocodingResultToLocation(location),
+		Days:     days,
+	}
+}
+
+// at tolerates variables missing from the response, open-meteo omits arrays it cannot compute
+func at[T any](values []T, i int) T {
+	var zero T
+	if i < len(values) {
+		return values[i]
+	}
+	return zero
+}
+
+// USAQIToEPAIndex converts US AQI value into US EPA index category (1 good .. 6 hazardous)
+func USAQIToEPAIndex(aqi int) int32 {
+	switch {
+	case aqi <= 50:
+		return 1
+	case aqi <= 100:
+		return 2
+	case aqi <= 150:
+		return 3
+	case aqi <= 200:
+		return 4
+	case aqi <= 300:
+		return 5
+	default:
+		return 6
+	}
+}
+
+func CurrentAirQualityToAirQualityWithLocation(current CurrentAirQuality, location GeocodingResult) model.AirQualityWithLocation {
+	return model.AirQualityWithLocation{
+		AirQuality: model.AirQuality{
+			LocationId: 0,
+			MeasuredAt: time.Unix(current.Time, 0).UTC(),
+			FetchedAt:  time.Unix(0, 0),
+			PM25:       current.PM25,
+			PM10:       current.PM10,
+			O3:         current.Ozone,
+			NO2:        current.NitrogenDioxide,
+			USEPAIndex: USAQIToEPAIndex(current.USAQI),
+			Provider:   ProviderName,
+		},
+		Location: GeocodingResultToLocation(location),
+	}
+}
@@ -0,0 +1,68 @@
+package openmeteo
+
+type GeocodingResult struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Country   string  `json:"country"`
+	Admin1    string  `json:"admin1"`
+	Timezone  string  `json:"timezone"`
+}
+
+type GeocodingResponse struct {
+	Results []GeocodingResult `json:"results"`
+}
+
+type Current struct {
+	Time                int64   `json:"time"`
+	Temperature2m       float32 `json:"temperature_2m"`
+	RelativeHumidity2m  float32 `json:"relative_humidity_2m"`
+	ApparentTemperature float32 `json:"apparent_temperature"`
+	Precipitation       float32 `json:"precipitation"`
+	WeatherCode         int     `json:"weather_code"`
+	CloudCover          float32 `json:"cloud_cover"`
+	PressureMsl         float32 `json:"pressure_msl"`
+	WindSpeed10m        float32 `json:"wind_speed_10m"`
+	WindDirection10m    float32 `json:"wind_direction_10m"`
+	UVIndex             float32 `json:"uv_index"`
+	Visibility          float32 `json:"visibility"`
+}
+
+type Hourly struct {
+	Time                     []int64   `json:"time"`
+	Temperature2m            []float32 `json:"temperature_2m"`
+	RelativeHumidity2m       []float32 `json:"relative_humidity_2m"`
+	PrecipitationProbability []int     `json:"precipitation_probability"`
+	WeatherCode              []int     `json:"weather_code"`
+}
+
+type Daily struct {
+	Time                        []int64   `json:"time"`
+	Temperature2mMax            []float32 `json:"temperature_2m_max"`
+	Temperature2mMin            []float32 `json:"temperature_2m_min"`
+	Temperature2mMean           []float32 `json:"temperature_2m_mean"`
+	RelativeHumidity2mMean      []float32 `json:"relative_humidity_2m_mean"`
+	PrecipitationProbabilityMax []int     `json:"precipitation_probability_max"`
+	WeatherCode                 []int     `json:"weather_code"`
+}
+
+type CurrentAirQuality struct {
+	Time            int64   `json:"time"`
+	PM25            float32 `json:"pm2_5"`
+	PM10            float32 `json:"pm10"`
+	Ozone           float32 `json:"ozone"`
+	NitrogenDioxide float32 `json:"nitrogen_dioxide"`
+	USAQI           int     `json:"us_aqi"`
+}
+
+type AirQualityResponse struct {
+	Current CurrentAirQuality `json:"current"`
+}
+
+type ForecastResponse struct {
+	Timezone         string  `json:"timezone"`
+	UtcOffsetSeconds int     `json:"utc_offset_seconds"`
+	Current          Current `json:"current"`
+	Hourly           Hourly  `json:"hourly"`
+	Daily            Daily   `json:"daily"`
+}
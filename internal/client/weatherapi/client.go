@@ -1,47 +1,180 @@
 package weatherapi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/circuitbreaker"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/retry"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
+const ProviderName = "weatherapi"
+
+// Options control resilience of the client, zero value means single attempt without timeout and circuit breaker
+type Options struct {
+	RequestTimeout time.Duration
+	MaxRetries     int
+	Backoff        retry.Backoff
+	Breaker        *circuitbreaker.CircuitBreaker
+}
+
 type Client struct {
 	baseURL string
 	apiKey  string
 	client  *http.Client
+	opts    Options
 	log     *slog.Logger
 }
 
-func NewClient(baseURL, apiKey string, client *http.Client, log *slog.Logger) *Client {
+func NewClient(baseURL, apiKey string, client *http.Client, opts Options, log *slog.Logger) *Client {
 	return &Client{
 		baseURL: baseURL,
 		apiKey:  apiKey,
 		client:  client,
+		opts:    opts,
 		log:     log,
 	}
 }
 
-func (c *Client) GetCurrentWeather(location string) (*model.WeatherWithLocation, error) {
-	u, err := url.Parse(c.baseURL + "/current.json")
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse url %w", err)
+func (c *Client) GetCurrentWeather(ctx context.Context, location string, lang string) (*model.WeatherWithLocation, error) {
+	q := url.Values{}
+	q.Set("q", location)
+	q.Set("aqi", "no")
+	setLang(q, lang)
+
+	var weather CurrentWeather
+	if err := c.get(ctx, "/current.json", q, &weather); err != nil {
+		return nil, err
 	}
+	weatherWithLocation := CurrentWeatherToWeatherWithLocation(weather)
 
-	q := u.Query()
-	q.Set("key", c.apiKey)
+	return &weatherWithLocation, nil
+}
+
+func (c *Client) GetForecast(ctx context.Context, location string, days int, lang string) (*model.Forecast, error) {
+	q := url.Values{}
 	q.Set("q", location)
+	q.Set("days", strconv.Itoa(days))
 	q.Set("aqi", "no")
+	q.Set("alerts", "no")
+	setLang(q, lang)
+
+	var forecastResp Forecast
+	if err := c.get(ctx, "/forecast.json", q, &forecastResp); err != nil {
+		return nil, err
+	}
+	forecast := ForecastToForecast(forecastResp)
+
+	return &forecast, nil
+}
+
+func (c *Client) GetAirQuality(ctx context.Context, location string) (*model.AirQualityWithLocation, error) {
+	q := url.Values{}
+	q.Set("q", location)
+	q.Set("aqi", "yes")
+
+	var resp CurrentAirQualityResponse
+	if err := c.get(ctx, "/current.json", q, &resp); err != nil {
+		return nil, err
+	}
+	airQuality := CurrentAirQualityToAirQualityWithLocation(resp)
+
+	return &airQuality, nil
+}
+
+func (c *Client) GetAlerts(ctx context.Context, location string) ([]model.WeatherAlert, error) {
+	q := url.Values{}
+	q.Set("q", location)
+
+	var resp AlertsResponse
+	if err := c.get(ctx, "/alerts.json", q, &resp); err != nil {
+		return nil, err
+	}
+
+	return AlertsToWeatherAlerts(resp.Alerts.Alert), nil
+}
+
+func (c *Client) SearchLocations(ctx context.Context, query string) ([]model.Location, error) {
+	q := url.Values{}
+	q.Set("q", query)
+
+	var results []SearchResult
+	if err := c.get(ctx, "/search.json", q, &results); err != nil {
+		return nil, err
+	}
+
+	return SearchResultsToLocations(results), nil
+}
+
+func (c *Client) get(ctx context.Context, path string, q url.Values, v any) error {
+	u, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return fmt.Errorf("failed to parse url %w", err)
+	}
+	q.Set("key", c.apiKey)
 	u.RawQuery = q.Encode()
 
-	resp, err := c.client.Get(u.String())
+	for attempt := 0; ; attempt++ {
+		if c.opts.Breaker != nil {
+			if err := c.opts.Breaker.Allow(); err != nil {
+				return err
+			}
+		}
+
+		err = c.doAttempt(ctx, u.String(), v)
+		c.record(err)
+		if err == nil || attempt >= c.opts.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		var delay time.Duration
+		var providerErr *commonerrors.ProviderError
+		if errors.As(err, &providerErr) {
+			if !providerErr.Retryable() {
+				return err
+			}
+			delay = providerErr.RetryAfter
+			if delay > c.opts.Backoff.MaxDelay {
+				return err
+			}
+		} else if !isTransient(err) {
+			return err
+		}
+		if delay == 0 {
+			delay = c.opts.Backoff.Delay(attempt)
+		}
+
+		c.log.Warn("retrying weatherapi request", "path", path, "attempt", attempt+1, "delay", delay, "error", err)
+		if err := retry.Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) doAttempt(ctx context.Context, rawURL string, v any) error {
+	if c.opts.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.RequestTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform get request %w", err)
+		return fmt.Errorf("failed to create request %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform get request %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -50,15 +183,46 @@ func (c *Client) GetCurrentWeather(location string) (*model.WeatherWithLocation,
 		}
 	}(resp.Body)
 
-	if resp.StatusCode == http.StatusBadRequest {
-		return nil, commonerrors.ErrLocationNotFound
+	switch {
+	case resp.StatusCode == http.StatusBadRequest:
+		return commonerrors.ErrLocationNotFound
+	case resp.StatusCode != http.StatusOK:
+		return commonerrors.NewProviderError(resp.StatusCode, retry.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
 	}
 
-	var weather CurrentWeather
-	if err := json.NewDecoder(resp.Body).Decode(&weather); err != nil {
-		return nil, fmt.Errorf("failed decode response %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed decode response %w", err)
 	}
-	weatherWithLocation := CurrentWeatherToWeatherWithLocation(weather)
 
-	return &weatherWithLocation, nil
+	return nil
+}
+
+// record reports the outcome to the circuit breaker, only failures that say something about upstream health count
+func (c *Client) record(err error) {
+	if c.opts.Breaker == nil {
+		return
+	}
+
+	var providerErr *commonerrors.ProviderError
+	switch {
+	case err == nil, errors.Is(err, commonerrors.ErrLocationNotFound):
+		c.opts.Breaker.Success()
+	case errors.As(err, &providerErr) && !providerErr.Retryable():
+		c.opts.Breaker.Success()
+	case errors.Is(err, context.Canceled):
+		c.opts.Breaker.Release()
+	default:
+		c.opts.Breaker.Failure()
+	}
+}
+
+// setLang requests condition text in the given language, english is what weatherapi returns without it
+func setLang(q url.Values, lang string) {
+	if lang != "" && lang != model.DefaultLanguage {
+		q.Set("lang", lang)
+	}
+}
+
+func isTransient(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, commonerrors.ErrLocationNotFound)
 }
@@ -1,6 +1,8 @@
 package weatherapi
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
 	"time"
 )
@@ -8,16 +10,133 @@ import (
 func CurrentWeatherToWeatherWithLocation(currentWeather CurrentWeather) model.WeatherWithLocation {
 	return model.WeatherWithLocation{
 		Weather: model.Weather{
-			LocationId:  0,
-			LastUpdated: time.Unix(currentWeather.Current.LastUpdated, 0).UTC(),
-			FetchedAt:   time.Unix(0, 0),
-			Temperature: currentWeather.Current.TempC,
-			Humidity:    float32(currentWeather.Current.Humidity),
-			Description: currentWeather.Current.Condition.Text,
+			LocationId:    0,
+			LastUpdated:   time.Unix(currentWeather.Current.LastUpdated, 0).UTC(),
+			FetchedAt:     time.Unix(0, 0),
+			Temperature:   currentWeather.Current.TempC,
+			Humidity:      float32(currentWeather.Current.Humidity),
+			Description:   currentWeather.Current.Condition.Text,
+			FeelsLike:     currentWeather.Current.FeelsLikeC,
+			WindSpeed:     currentWeather.Current.WindKph,
+			WindDegree:    int32(currentWeather.Current.WindDegree),
+			WindDirection: currentWeather.Current.WindDir,
+			Pressure:      currentWeather.Current.PressureMb,
+			UVIndex:       currentWeather.Current.UV,
+			Precipitation: currentWeather.Current.PrecipMm,
+			CloudCover:    float32(currentWeather.Current.Cloud),
+			Visibility:    currentWeather.Current.VisKm,
+			Provider:      ProviderName,
 		},
-		Location: model.Location{
-			Id:   0,
-			Name: currentWeather.Location.Name,
+		Location: LocationToLocation(currentWeather.Location),
+	}
+}
+
+func ForecastToForecast(forecast Forecast) model.Forecast {
+	days := make([]model.DailyForecast, 0, len(forecast.Forecast.ForecastDay))
+	for _, fd := range forecast.Forecast.ForecastDay {
+		hours := make([]model.HourlyForecast, 0, len(fd.Hour))
+		for _, h := range fd.Hour {
+			hours = append(hours, model.HourlyForecast{
+				Time:         time.Unix(h.TimeEpoch, 0).UTC(),
+				Temperature:  h.TempC,
+				Humidity:     float32(h.Humidity),
+				ChanceOfRain: h.ChanceOfRain,
+				Description:  h.Condition.Text,
+			})
+		}
+
+		days = append(days, model.DailyForecast{
+			Date:           time.Unix(fd.DateEpoch, 0).UTC(),
+			MaxTemperature: fd.Day.MaxTempC,
+			MinTemperature: fd.Day.MinTempC,
+			AvgTemperature: fd.Day.AvgTempC,
+			AvgHumidity:    fd.Day.AvgHumidity,
+			ChanceOfRain:   fd.Day.DailyChanceOfRain,
+			Description:    fd.Day.Condition.Text,
+			Hours:          hours,
+		})
+	}
+
+	return model.Forecast{
+		Location: LocationToLocation(forecast.Location),
+		Days:     days,
+	}
+}
+
+func LocationToLocation(location Location) model.Location {
+	return model.Location{
+		Id:        0,
+		Name:      location.Name,
+		Region:    location.Region,
+		Country:   location.Country,
+		Latitude:  location.Lat,
+		Longitude: location.Lon,
+		TzId:      location.TzId,
+		Key:       model.LocationKey(location.Name, location.Lat, location.Lon),
+	}
+}
+
+func SearchResultsToLocations(results []SearchResult) []model.Location {
+	locations := make([]model.Location, 0, len(results))
+	for _, r := range results {
+		locations = append(locations, model.Location{
+			Id:        0,
+			Name:      r.Name,
+			Region:    r.Region,
+			Country:   r.Country,
+			Latitude:  r.Lat,
+			Longitude: r.Lon,
+			Key:       model.LocationKey(r.Name, r.Lat, r.Lon),
+		})
+	}
+	return locations
+}
+
+func CurrentAirQualityToAirQualityWithLocation(resp CurrentAirQualityResponse) model.AirQualityWithLocation {
+	return model.AirQualityWithLocation{
+		AirQuality: model.AirQuality{
+			LocationId: 0,
+			MeasuredAt: time.Unix(resp.Current.LastUpdated, 0).UTC(),
+			FetchedAt:  time.Unix(0, 0),
+			PM25:       resp.Current.AirQuality.PM25,
+			PM10:       resp.Current.AirQuality.PM10,
+			O3:         resp.Current.AirQuality.O3,
+			NO2:        resp.Current.AirQuality.NO2,
+			USEPAIndex: int32(resp.Current.AirQuality.USEPAIndex),
+			Provider:   ProviderName,
 		},
+		Location: LocationToLocation(resp.Location),
+	}
+}
+
+func AlertsToWeatherAlerts(alerts []Alert) []model.WeatherAlert {
+	weatherAlerts := make([]model.WeatherAlert, 0, len(alerts))
+	for _, a := range alerts {
+		weatherAlerts = append(weatherAlerts, model.WeatherAlert{
+			ExternalId:  alertId(a),
+			Event:       a.Event,
+			Headline:    a.Headline,
+			Severity:    a.Severity,
+			Areas:       a.Areas,
+			Description: a.Desc,
+			Instruction: a.Instruction,
+			Effective:   parseAlertTime(a.Effective),
+			Expires:     parseAlertTime(a.Expires),
+		})
+	}
+	return weatherAlerts
+}
+
+// alertId derives a stable id, weatherapi does not expose the id of the originating government alert
+func alertId(a Alert) string {
+	sum := sha256.Sum256([]byte(a.Event + "|" + a.Headline + "|" + a.Areas + "|" + a.Effective))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseAlertTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
 	}
+	return t.UTC()
 }
@@ -1,7 +1,12 @@
 package weatherapi
 
 type Location struct {
-	Name string `json:"name"`
+	Name    string  `json:"name"`
+	Region  string  `json:"region"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	TzId    string  `json:"tz_id"`
 }
 
 type Condition struct {
@@ -12,10 +17,98 @@ type Current struct {
 	LastUpdated int64     `json:"last_updated_epoch"`
 	TempC       float32   `json:"temp_c"`
 	Condition   Condition `json:"condition"`
+	WindKph     float32   `json:"wind_kph"`
+	WindDegree  int       `json:"wind_degree"`
+	WindDir     string    `json:"wind_dir"`
+	PressureMb  float32   `json:"pressure_mb"`
+	PrecipMm    float32   `json:"precip_mm"`
 	Humidity    int       `json:"humidity"`
+	Cloud       int       `json:"cloud"`
+	FeelsLikeC  float32   `json:"feelslike_c"`
+	VisKm       float32   `json:"vis_km"`
+	UV          float32   `json:"uv"`
+}
+
+type AirQuality struct {
+	PM25       float32 `json:"pm2_5"`
+	PM10       float32 `json:"pm10"`
+	O3         float32 `json:"o3"`
+	NO2        float32 `json:"no2"`
+	USEPAIndex int     `json:"us-epa-index"`
+}
+
+type Alert struct {
+	Headline    string `json:"headline"`
+	Severity    string `json:"severity"`
+	Areas       string `json:"areas"`
+	Event       string `json:"event"`
+	Effective   string `json:"effective"`
+	Expires     string `json:"expires"`
+	Desc        string `json:"desc"`
+	Instruction string `json:"instruction"`
+}
+
+type Alerts struct {
+	Alert []Alert `json:"alert"`
+}
+
+type AlertsResponse struct {
+	Location Location `json:"location"`
+	Alerts   Alerts   `json:"alerts"`
+}
+
+type CurrentAirQuality struct {
+	LastUpdated int64      `json:"last_updated_epoch"`
+	AirQuality  AirQuality `json:"air_quality"`
+}
+
+type CurrentAirQualityResponse struct {
+	Location Location          `json:"location"`
+	Current  CurrentAirQuality `json:"current"`
 }
 
 type CurrentWeather struct {
 	Location Location `json:"location"`
 	Current  Current  `json:"current"`
 }
+
+type Day struct {
+	MaxTempC          float32   `json:"maxtemp_c"`
+	MinTempC          float32   `json:"mintemp_c"`
+	AvgTempC          float32   `json:"avgtemp_c"`
+	AvgHumidity       float32   `json:"avghumidity"`
+	DailyChanceOfRain int       `json:"daily_chance_of_rain"`
+	Condition         Condition `json:"condition"`
+}
+
+type Hour struct {
+	TimeEpoch    int64     `json:"time_epoch"`
+	TempC        float32   `json:"temp_c"`
+	Humidity     int       `json:"humidity"`
+	ChanceOfRain int       `json:"chance_of_rain"`
+	Condition    Condition `json:"condition"`
+}
+
+type ForecastDay struct {
+	DateEpoch int64  `json:"date_epoch"`
+	Day       Day    `json:"day"`
+	Hour      []Hour `json:"hour"`
+}
+
+type ForecastDays struct {
+	ForecastDay []ForecastDay `json:"forecastday"`
+}
+
+type Forecast struct {
+	Location Location     `json:"location"`
+	Forecast ForecastDays `json:"forecast"`
+}
+
+type SearchResult struct {
+	Id      int64   `json:"id"`
+	Name    string  `json:"name"`
+	Region  string  `json:"region"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
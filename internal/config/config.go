@@ -1,22 +1,36 @@
 package config
 
 import (
+	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"os"
+	"time"
 )
 
 type Config struct {
 	HTTPServer      `yaml:"server"`
 	Datasource      `yaml:"datasource"`
 	WeatherProvider `yaml:"weather-provider"`
+	OpenMeteo       `yaml:"open-meteo"`
+	WeatherCache    `yaml:"weather-cache"`
+	WeatherAlerts   `yaml:"weather-alerts"`
 	EmailService    `yaml:"email-service"`
+	Management      `yaml:"management"`
+	Unsubscribe     `yaml:"unsubscribe"`
+	Tokens          `yaml:"tokens"`
+	Confirmation    `yaml:"confirmation"`
+	Janitor         `yaml:"janitor"`
+	EmailOutbox     `yaml:"email-outbox"`
+	Notifications   `yaml:"notifications"`
 	Emails          []EmailData `yaml:"emails"`
 }
 
 type HTTPServer struct {
 	Host string `yaml:"host" env:"SERVER_HOST" env-default:"0.0.0.0"`
 	Port string `yaml:"port" env:"SERVER_PORT" env-default:"8080"`
+	// ShutdownTimeout bounds both draining of http requests and waiting for running jobs
+	ShutdownTimeout time.Duration `yaml:"shutdown-timeout" env:"SERVER_SHUTDOWN_TIMEOUT" env-default:"30s"`
 }
 
 type Datasource struct {
@@ -24,8 +38,41 @@ type Datasource struct {
 }
 
 type WeatherProvider struct {
-	Url string `yaml:"url" env:"WEATHER_PROVIDER_URL"`
-	Key string `yaml:"key" env:"WEATHER_PROVIDER_KEY"`
+	Url      string        `yaml:"url" env:"WEATHER_PROVIDER_URL"`
+	Key      string        `yaml:"key" env:"WEATHER_PROVIDER_KEY"`
+	Priority int           `yaml:"priority" env:"WEATHER_PROVIDER_PRIORITY" env-default:"1"`
+	Timeout  time.Duration `yaml:"timeout" env:"WEATHER_PROVIDER_TIMEOUT" env-default:"5s"`
+	Retry    `yaml:"retry"`
+	Breaker  `yaml:"circuit-breaker"`
+}
+
+type Retry struct {
+	RequestTimeout time.Duration `yaml:"request-timeout" env:"WEATHER_PROVIDER_REQUEST_TIMEOUT" env-default:"2s"`
+	MaxRetries     int           `yaml:"max-retries" env:"WEATHER_PROVIDER_MAX_RETRIES" env-default:"2"`
+	BaseDelay      time.Duration `yaml:"base-delay" env:"WEATHER_PROVIDER_RETRY_BASE_DELAY" env-default:"200ms"`
+	MaxDelay       time.Duration `yaml:"max-delay" env:"WEATHER_PROVIDER_RETRY_MAX_DELAY" env-default:"2s"`
+}
+
+type Breaker struct {
+	FailureThreshold int           `yaml:"failure-threshold" env:"WEATHER_PROVIDER_BREAKER_FAILURE_THRESHOLD" env-default:"5"`
+	OpenTimeout      time.Duration `yaml:"open-timeout" env:"WEATHER_PROVIDER_BREAKER_OPEN_TIMEOUT" env-default:"30s"`
+}
+
+type OpenMeteo struct {
+	Enabled       bool          `yaml:"enabled" env:"OPEN_METEO_ENABLED" env-default:"false"`
+	Url           string        `yaml:"url" env:"OPEN_METEO_URL" env-default:"https://api.open-meteo.com/v1"`
+	GeocodingUrl  string        `yaml:"geocoding-url" env:"OPEN_METEO_GEOCODING_URL" env-default:"https://geocoding-api.open-meteo.com/v1"`
+	AirQualityUrl string        `yaml:"air-quality-url" env:"OPEN_METEO_AIR_QUALITY_URL" env-default:"https://air-quality-api.open-meteo.com/v1"`
+	Priority      int           `yaml:"priority" env:"OPEN_METEO_PRIORITY" env-default:"2"`
+	Timeout       time.Duration `yaml:"timeout" env:"OPEN_METEO_TIMEOUT" env-default:"5s"`
+}
+
+type WeatherCache struct {
+	TTL time.Duration `yaml:"ttl" env:"WEATHER_CACHE_TTL" env-default:"5m"`
+}
+
+type WeatherAlerts struct {
+	PollInterval time.Duration `yaml:"poll-interval" env:"WEATHER_ALERTS_POLL_INTERVAL" env-default:"5m"`
 }
 
 type EmailService struct {
@@ -34,6 +81,87 @@ type EmailService struct {
 	Sender string `yaml:"sender"`
 }
 
+// Management keys are "id:secret" pairs rotated the same way as Tokens keys, but kept apart from them
+type Management struct {
+	Url     string        `yaml:"url" env:"MANAGEMENT_URL"`
+	Keys    []string      `yaml:"keys" env:"MANAGEMENT_KEYS" env-separator:","`
+	LinkTTL time.Duration `yaml:"link-ttl" env:"MANAGEMENT_LINK_TTL" env-default:"720h"`
+}
+
+// Unsubscribe Url is advertised in List-Unsubscribe header, RFC 8058 one-click unsubscribe requires it to be https
+type Unsubscribe struct {
+	Url string `yaml:"url" env:"UNSUBSCRIBE_URL" env-default:"https://db35m6zjaamdj.cloudfront.net/api/unsubscribe"`
+}
+
+// Tokens keys are "id:secret" pairs, the first one signs new tokens, all of them verify.
+// AcceptLegacy keeps unsigned tokens issued before signing working until LegacyUntil, emails containing them
+// are no longer relevant by then
+type Tokens struct {
+	Keys         []string  `yaml:"keys" env:"TOKEN_KEYS" env-separator:","`
+	AcceptLegacy bool      `yaml:"accept-legacy" env:"TOKEN_ACCEPT_LEGACY" env-default:"false"`
+	LegacyUntil  time.Time `yaml:"legacy-until" env:"TOKEN_LEGACY_UNTIL" env-layout:"2006-01-02"`
+}
+
+// Validate rejects accepting legacy tokens without a date to stop accepting them
+func (t Tokens) Validate() error {
+	if t.AcceptLegacy && t.LegacyUntil.IsZero() {
+		return fmt.Errorf("legacy tokens are accepted without legacy-until date")
+	}
+	return nil
+}
+
+// Confirmation limits how often confirmation email of one subscription can be sent, ResendLimit counts all sends within ResendWindow
+type Confirmation struct {
+	TokenTTL       time.Duration `yaml:"token-ttl" env:"CONFIRMATION_TOKEN_TTL" env-default:"15m"`
+	ResendInterval time.Duration `yaml:"resend-interval" env:"CONFIRMATION_RESEND_INTERVAL" env-default:"1m"`
+	ResendLimit    int           `yaml:"resend-limit" env:"CONFIRMATION_RESEND_LIMIT" env-default:"5"`
+	ResendWindow   time.Duration `yaml:"resend-window" env:"CONFIRMATION_RESEND_WINDOW" env-default:"24h"`
+}
+
+// Janitor retention windows are counted from token expiry, from confirmation expiry of pending subscription and from subscriber creation
+type Janitor struct {
+	Interval            time.Duration `yaml:"interval" env:"JANITOR_INTERVAL" env-default:"1h"`
+	TokenRetention      time.Duration `yaml:"token-retention" env:"JANITOR_TOKEN_RETENTION" env-default:"168h"`
+	PendingRetention    time.Duration `yaml:"pending-retention" env:"JANITOR_PENDING_RETENTION" env-default:"72h"`
+	SubscriberRetention time.Duration `yaml:"subscriber-retention" env:"JANITOR_SUBSCRIBER_RETENTION" env-default:"168h"`
+	OutboxRetention     time.Duration `yaml:"outbox-retention" env:"JANITOR_OUTBOX_RETENTION" env-default:"168h"`
+	DeliveryRetention   time.Duration `yaml:"delivery-retention" env:"JANITOR_DELIVERY_RETENTION" env-default:"720h"`
+	BatchSize           int           `yaml:"batch-size" env:"JANITOR_BATCH_SIZE" env-default:"500"`
+}
+
+// EmailOutbox retries failed deliveries with exponential backoff, after MaxAttempts the email is marked dead.
+// ClaimTimeout is how long a claimed batch is kept from other dispatchers, it has to cover sending the whole batch,
+// every email of which may take up to SendTimeout
+type EmailOutbox struct {
+	PollInterval time.Duration `yaml:"poll-interval" env:"EMAIL_OUTBOX_POLL_INTERVAL" env-default:"10s"`
+	BatchSize    int           `yaml:"batch-size" env:"EMAIL_OUTBOX_BATCH_SIZE" env-default:"50"`
+	ClaimTimeout time.Duration `yaml:"claim-timeout" env:"EMAIL_OUTBOX_CLAIM_TIMEOUT" env-default:"15m"`
+	SendTimeout  time.Duration `yaml:"send-timeout" env:"EMAIL_OUTBOX_SEND_TIMEOUT" env-default:"10s"`
+	MaxAttempts  int32         `yaml:"max-attempts" env:"EMAIL_OUTBOX_MAX_ATTEMPTS" env-default:"8"`
+	BaseDelay    time.Duration `yaml:"base-delay" env:"EMAIL_OUTBOX_BASE_DELAY" env-default:"30s"`
+	MaxDelay     time.Duration `yaml:"max-delay" env:"EMAIL_OUTBOX_MAX_DELAY" env-default:"1h"`
+}
+
+// Validate rejects a ClaimTimeout that may expire while the batch is still being sent,
+// other dispatchers would claim and send the rest of it again
+func (o EmailOutbox) Validate() error {
+	if batchTimeout := time.Duration(o.BatchSize) * o.SendTimeout; o.ClaimTimeout <= batchTimeout {
+		return fmt.Errorf("email outbox claim timeout %s must exceed batch size %d times send timeout %s = %s",
+			o.ClaimTimeout, o.BatchSize, o.SendTimeout, batchTimeout)
+	}
+	return nil
+}
+
+// Notifications sizes stages of notification pipeline, PageSize is number of subscriptions read at once, SendRate is emails per second shared by all senders of mailgun
+type Notifications struct {
+	PageSize       int           `yaml:"page-size" env:"NOTIFICATIONS_PAGE_SIZE" env-default:"500"`
+	WeatherWorkers int           `yaml:"weather-workers" env:"NOTIFICATIONS_WEATHER_WORKERS" env-default:"4"`
+	SendWorkers    int           `yaml:"send-workers" env:"NOTIFICATIONS_SEND_WORKERS" env-default:"8"`
+	SendRate       float64       `yaml:"send-rate" env:"NOTIFICATIONS_SEND_RATE" env-default:"10"`
+	SendBurst      int           `yaml:"send-burst" env:"NOTIFICATIONS_SEND_BURST" env-default:"10"`
+	SendTimeout    time.Duration `yaml:"send-timeout" env:"NOTIFICATIONS_SEND_TIMEOUT" env-default:"10s"`
+}
+
 type EmailData struct {
 	Name    string `yaml:"name"`
 	Subject string `yaml:"subject"`
@@ -5,4 +5,5 @@ type SimpleEmail struct {
 	To      string
 	Subject string
 	Text    string
+	Headers map[string]string
 }
@@ -0,0 +1,25 @@
+package dto
+
+type HourlyForecastDTO struct {
+	Time         string  `json:"time"`
+	Temperature  float32 `json:"temperature"`
+	Humidity     float32 `json:"humidity"`
+	ChanceOfRain int     `json:"chanceOfRain"`
+	Description  string  `json:"description"`
+}
+
+type DailyForecastDTO struct {
+	Date           string              `json:"date"`
+	MaxTemperature float32             `json:"maxTemperature"`
+	MinTemperature float32             `json:"minTemperature"`
+	AvgTemperature float32             `json:"avgTemperature"`
+	AvgHumidity    float32             `json:"avgHumidity"`
+	ChanceOfRain   int                 `json:"chanceOfRain"`
+	Description    string              `json:"description"`
+	Hours          []HourlyForecastDTO `json:"hours"`
+}
+
+type ForecastDTO struct {
+	City string             `json:"city"`
+	Days []DailyForecastDTO `json:"days"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+type LocationDTO struct {
+	Id        int32   `json:"id,omitempty"`
+	Key       string  `json:"key"`
+	Name      string  `json:"name"`
+	Region    string  `json:"region"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+type ManagementDTO struct {
+	Email         string                   `json:"email"`
+	Subscriptions []ManagedSubscriptionDTO `json:"subscriptions"`
+}
+
+type ManagedSubscriptionDTO struct {
+	Id           int32       `json:"id"`
+	Location     LocationDTO `json:"location"`
+	Frequency    string      `json:"frequency"`
+	Status       string      `json:"status"`
+	DeliveryHour int32       `json:"deliveryHour"`
+	Timezone     string      `json:"timezone"`
+	Schedule     string      `json:"schedule,omitempty"`
+}
+
+type ManagedSubscriptionRequest struct {
+	City           string   `validate:"required_without=LocationKey"`
+	LocationKey    string   `validate:"required_without=City,max=300"`
+	Frequency      string   `validate:"required,oneof=hourly daily alerts custom"`
+	Schedule       string   `validate:"required_if=Frequency custom,excluded_unless=Frequency custom,max=100"`
+	AqiThreshold   int32    `validate:"omitempty,min=1,max=6"`
+	Conditions     []string `validate:"max=5,dive,required,max=60"`
+	ConditionLogic string   `validate:"omitempty,oneof=and or"`
+}
+
+// UpdateSubscriptionRequest leaves empty fields unchanged
+type UpdateSubscriptionRequest struct {
+	City         string `validate:"excluded_with=LocationKey,max=100"`
+	LocationKey  string `validate:"omitempty,max=300"`
+	Frequency    string `validate:"omitempty,oneof=hourly daily alerts custom"`
+	Schedule     string `validate:"required_if=Frequency custom,max=100"`
+	DeliveryHour *int32 `validate:"omitempty,min=0,max=23"`
+	Timezone     string `validate:"omitempty,timezone"`
+}
+
+func (r UpdateSubscriptionRequest) IsEmpty() bool {
+	return r.City == "" && r.LocationKey == "" && r.Frequency == "" && r.Schedule == "" && r.DeliveryHour == nil && r.Timezone == ""
+}
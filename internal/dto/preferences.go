@@ -0,0 +1,7 @@
+package dto
+
+type PreferencesRequest struct {
+	TemperatureUnit string `validate:"omitempty,oneof=C F"`
+	WindUnit        string `validate:"omitempty,oneof=kph mph mps"`
+	Language        string `validate:"omitempty,oneof=en ar bn bg zh zh_tw cs da nl fi fr de el hi hu it ja jv ko zh_cmn mr pl pt pa ro ru sr si sk es sv ta te tr uk ur vi zh_wuu zh_hsiang zh_yue zu"`
+}
@@ -1,7 +1,15 @@
 package dto
 
 type SubscriptionRequest struct {
-	Email     string `validate:"required,email"`
-	City      string `validate:"required"`
-	Frequency string `validate:"required,oneof=hourly daily"`
+	Email          string   `validate:"required,email"`
+	City           string   `validate:"required_without=LocationKey"`
+	LocationKey    string   `validate:"required_without=City,max=300"`
+	Frequency      string   `validate:"required,oneof=hourly daily alerts custom"`
+	Schedule       string   `validate:"required_if=Frequency custom,excluded_unless=Frequency custom,max=100"`
+	AqiThreshold   int32    `validate:"omitempty,min=1,max=6"`
+	DeliveryHour   *int32   `validate:"omitempty,min=0,max=23"`
+	Timezone       string   `validate:"omitempty,timezone"`
+	Conditions     []string `validate:"max=5,dive,required,max=60"`
+	ConditionLogic string   `validate:"omitempty,oneof=and or"`
+	Preferences    PreferencesRequest
 }
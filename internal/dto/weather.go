@@ -1,7 +1,27 @@
 package dto
 
 type WeatherDTO struct {
-	Temperature float32 `json:"temperature"`
-	Humidity    float32 `json:"humidity"`
-	Description string  `json:"description"`
+	Temperature     float32        `json:"temperature"`
+	TemperatureUnit string         `json:"temperatureUnit"`
+	WindUnit        string         `json:"windUnit"`
+	Humidity        float32        `json:"humidity"`
+	Description     string         `json:"description"`
+	FeelsLike       float32        `json:"feelsLike"`
+	WindSpeed       float32        `json:"windSpeed"`
+	WindDegree      int32          `json:"windDegree"`
+	WindDirection   string         `json:"windDirection"`
+	Pressure        float32        `json:"pressure"`
+	UVIndex         float32        `json:"uvIndex"`
+	Precipitation   float32        `json:"precipitation"`
+	CloudCover      float32        `json:"cloudCover"`
+	Visibility      float32        `json:"visibility"`
+	AirQuality      *AirQualityDTO `json:"airQuality,omitempty"`
+}
+
+type AirQualityDTO struct {
+	PM25       float32 `json:"pm2_5"`
+	PM10       float32 `json:"pm10"`
+	O3         float32 `json:"o3"`
+	NO2        float32 `json:"no2"`
+	USEPAIndex int32   `json:"usEpaIndex"`
 }
@@ -7,5 +7,16 @@ var (
 	ErrSubscriptionAlreadyExists = errors.New("subscription already exists")
 	ErrInvalidToken              = errors.New("invalid token")
 	ErrTokenNotFound             = errors.New("token not found")
+	ErrTokenAlreadyUsed          = errors.New("token already used")
 	ErrUnexpectedState           = errors.New("unexpected state")
+	ErrProviderUnauthorized      = errors.New("weather provider rejected api key")
+	ErrProviderForbidden         = errors.New("weather provider denied access")
+	ErrProviderRateLimited       = errors.New("weather provider rate limit exceeded")
+	ErrProviderUnavailable       = errors.New("weather provider unavailable")
+	ErrCircuitOpen               = errors.New("circuit breaker is open")
+	ErrNotSupported              = errors.New("operation not supported by weather provider")
+	ErrInvalidSchedule           = errors.New("invalid schedule")
+	ErrInvalidCondition          = errors.New("invalid condition")
+	ErrSubscriptionNotFound      = errors.New("subscription not found")
+	ErrTooManyRequests           = errors.New("too many requests")
 )
@@ -0,0 +1,46 @@
+package error
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type ProviderError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s (status %d)", e.Err, e.StatusCode)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether repeating the request may succeed
+func (e *ProviderError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+func NewProviderError(statusCode int, retryAfter time.Duration) *ProviderError {
+	var err error
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		err = ErrProviderUnauthorized
+	case statusCode == http.StatusForbidden:
+		err = ErrProviderForbidden
+	case statusCode == http.StatusTooManyRequests:
+		err = ErrProviderRateLimited
+	default:
+		err = ErrProviderUnavailable
+	}
+
+	return &ProviderError{
+		StatusCode: statusCode,
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/httputil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const minSearchQueryLength = 2
+
+type LocationService interface {
+	SearchLocations(context.Context, string) ([]dto.LocationDTO, error)
+}
+
+type LocationHandler struct {
+	locationService LocationService
+	log             *slog.Logger
+}
+
+func NewLocationHandler(locationService LocationService, log *slog.Logger) *LocationHandler {
+	return &LocationHandler{
+		locationService: locationService,
+		log:             log,
+	}
+}
+
+func (h *LocationHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if utf8.RuneCountInString(query) < minSearchQueryLength {
+		http.Error(w, "Invalid q parameter", http.StatusBadRequest)
+		h.log.Info("query parameter 'q' is missing or too short")
+		return
+	}
+
+	locationDtos, err := h.locationService.SearchLocations(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, commonerrors.ErrLocationNotFound) {
+			locationDtos = []dto.LocationDTO{}
+		} else if isProviderUnavailable(err) {
+			http.Error(w, "Weather provider unavailable", http.StatusServiceUnavailable)
+			h.log.Error("weather provider unavailable", "error", err)
+			return
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+			h.log.Error("error searching locations", "error", err)
+			return
+		}
+	}
+
+	err = httputil.WriteJSON(w, locationDtos)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		h.log.Error("error writing response", "error", err)
+		return
+	}
+}
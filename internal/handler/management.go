@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/httputil"
+	"github.com/go-playground/validator/v10"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ManagementService interface {
+	ManageURL(string) string
+	GetManagedSubscriptions(context.Context, string) (*dto.ManagementDTO, error)
+	AddManagedSubscription(context.Context, string, dto.ManagedSubscriptionRequest) error
+	DeleteManagedSubscription(context.Context, string, int32) error
+	UpdateManagedSubscription(context.Context, string, int32, dto.UpdateSubscriptionRequest) (*dto.ManagedSubscriptionDTO, error)
+}
+
+type ManagementHandler struct {
+	managementService ManagementService
+	validator         *validator.Validate
+	log               *slog.Logger
+}
+
+func NewManagementHandler(managementService ManagementService, validator *validator.Validate, log *slog.Logger) *ManagementHandler {
+	return &ManagementHandler{
+		managementService: managementService,
+		validator:         validator,
+		log:               log,
+	}
+}
+
+// form actions are relative to the page, so the page works behind a path prefix
+var managementPage = template.Must(template.New("management").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Manage subscriptions</title></head>
+<body>
+<h1>Subscriptions of {{.Management.Email}}</h1>
+<table>
+<tr><th>Location</th><th>Frequency</th><th>Status</th><th></th><th></th></tr>
+{{range .Management.Subscriptions}}
+<tr>
+<td>{{.Location.Name}}{{if .Location.Country}}, {{.Location.Country}}{{end}}</td>
+<td>{{.Frequency}}{{if .Schedule}} ({{.Schedule}}){{end}}</td>
+<td>{{.Status}}</td>
+<td>
+<form method="post" action="{{$.Token}}/subscriptions/{{.Id}}/frequency">
+<select name="frequency">
+<option value="hourly">hourly</option>
+<option value="daily">daily</option>
+<option value="alerts">alerts</option>
+</select>
+<button type="submit">Change</button>
+</form>
+</td>
+<td>
+<form method="post" action="{{$.Token}}/subscriptions/{{.Id}}/delete">
+<button type="submit">Delete</button>
+</form>
+</td>
+</tr>
+{{end}}
+</table>
+<h2>Add city</h2>
+<form method="post" action="{{.Token}}/subscriptions">
+<input name="city" placeholder="City" required>
+<select name="frequency">
+<option value="hourly">hourly</option>
+<option value="daily">daily</option>
+<option value="alerts">alerts</option>
+</select>
+<input name="aqiThreshold" type="number" min="1" max="6" placeholder="Air quality alert at (1-6)">
+<input name="condition" placeholder="Condition, e.g. temperature<0">
+<input name="condition" placeholder="Condition, e.g. description~rain">
+<select name="conditionLogic">
+<option value="and">all conditions</option>
+<option value="or">any condition</option>
+</select>
+<button type="submit">Subscribe</button>
+</form>
+</body>
+</html>
+`))
+
+func (h *ManagementHandler) Get(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	managementDto, err := h.managementService.GetManagedSubscriptions(r.Context(), token)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if wantsJSON(r) {
+		err = httputil.WriteJSON(w, managementDto)
+		if err != nil {
+			http.Error(w, "", http.StatusInternalServerError)
+			h.log.Error("error writing response", "error", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = managementPage.Execute(w, struct {
+		Token      string
+		Management *dto.ManagementDTO
+	}{token, managementDto})
+	if err != nil {
+		h.log.Error("error rendering management page", "error", err)
+	}
+}
+
+func (h *ManagementHandler) AddSubscription(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error parsing form", "error", err)
+		return
+	}
+
+	var req dto.ManagedSubscriptionRequest
+	req.City = r.FormValue("city")
+	req.Frequency = r.FormValue("frequency")
+	req.Schedule = r.FormValue("schedule")
+	req.LocationKey = r.FormValue("locationKey")
+	// the page submits its condition inputs even when left empty
+	for _, condition := range r.Form["condition"] {
+		if condition != "" {
+			req.Conditions = append(req.Conditions, condition)
+		}
+	}
+	req.ConditionLogic = r.FormValue("conditionLogic")
+	if aqiThreshold := r.FormValue("aqiThreshold"); aqiThreshold != "" {
+		threshold, err := strconv.ParseInt(aqiThreshold, 10, 32)
+		if err != nil {
+			http.Error(w, "invalid input", http.StatusBadRequest)
+			h.log.Error("error parsing aqi threshold", "aqiThreshold", aqiThreshold)
+			return
+		}
+		req.AqiThreshold = int32(threshold)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error validating data", "error", err)
+		return
+	}
+
+	if err := h.managementService.AddManagedSubscription(r.Context(), token, req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeDone(w, r, token)
+}
+
+func (h *ManagementHandler) ChangeFrequency(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	subscriptionId, ok := h.parseSubscriptionId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error parsing form", "error", err)
+		return
+	}
+
+	req := dto.UpdateSubscriptionRequest{
+		Frequency: r.FormValue("frequency"),
+		Schedule:  r.FormValue("schedule"),
+	}
+	if err := h.validator.Struct(req); err != nil || req.Frequency == "" {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error validating data", "error", err)
+		return
+	}
+
+	if _, err := h.managementService.UpdateManagedSubscription(r.Context(), token, subscriptionId, req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeDone(w, r, token)
+}
+
+func (h *ManagementHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	subscriptionId, ok := h.parseSubscriptionId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.managementService.DeleteManagedSubscription(r.Context(), token, subscriptionId); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeDone(w, r, token)
+}
+
+// UpdateSubscription is the api counterpart of the page, management token is passed as bearer token
+func (h *ManagementHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		http.Error(w, "management token required", http.StatusUnauthorized)
+		h.log.Info("missing management token")
+		return
+	}
+	subscriptionId, ok := h.parseSubscriptionId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error parsing form", "error", err)
+		return
+	}
+
+	var req dto.UpdateSubscriptionRequest
+	req.City = r.FormValue("city")
+	req.Frequency = r.FormValue("frequency")
+	req.Schedule = r.FormValue("schedule")
+	req.Timezone = r.FormValue("timezone")
+	req.LocationKey = r.FormValue("locationKey")
+	if deliveryHour := r.FormValue("deliveryHour"); deliveryHour != "" {
+		hour, err := strconv.ParseInt(deliveryHour, 10, 32)
+		if err != nil {
+			http.Error(w, "invalid input", http.StatusBadRequest)
+			h.log.Error("error parsing delivery hour", "deliveryHour", deliveryHour)
+			return
+		}
+		hour32 := int32(hour)
+		req.DeliveryHour = &hour32
+	}
+
+	if req.IsEmpty() {
+		http.Error(w, "nothing to update", http.StatusBadRequest)
+		h.log.Info("empty subscription update")
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error validating data", "error", err)
+		return
+	}
+
+	updated, err := h.managementService.UpdateManagedSubscription(r.Context(), token, subscriptionId, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if err = httputil.WriteJSON(w, updated); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		h.log.Error("error writing response", "error", err)
+	}
+}
+
+func (h *ManagementHandler) parseSubscriptionId(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid subscription id", http.StatusBadRequest)
+		h.log.Info("invalid subscription id", "id", idStr)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// writeDone sends html forms back to the page and answers api clients with no content
+func (h *ManagementHandler) writeDone(w http.ResponseWriter, r *http.Request, token string) {
+	if wantsJSON(r) || r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, h.managementService.ManageURL(token), http.StatusSeeOther)
+}
+
+func (h *ManagementHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, commonerrors.ErrInvalidToken):
+		http.Error(w, "invalid or expired link", http.StatusForbidden)
+		h.log.Info("invalid management token", "error", err)
+	case errors.Is(err, commonerrors.ErrSubscriptionNotFound):
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		h.log.Info("subscription not found", "error", err)
+	case errors.Is(err, commonerrors.ErrSubscriptionAlreadyExists):
+		http.Error(w, "already subscribed to this location", http.StatusConflict)
+		h.log.Info("subscription already exists", "error", err)
+	case errors.Is(err, commonerrors.ErrLocationNotFound),
+		errors.Is(err, commonerrors.ErrInvalidSchedule),
+		errors.Is(err, commonerrors.ErrInvalidCondition):
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Info("invalid input", "error", err)
+	default:
+		http.Error(w, "", http.StatusInternalServerError)
+		h.log.Error("error managing subscriptions", "error", err)
+	}
+}
+
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
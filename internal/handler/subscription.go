@@ -6,13 +6,16 @@ import (
 	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
 	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
 	"github.com/go-playground/validator/v10"
+	"html/template"
 	"log/slog"
 	"net/http"
+	"strconv"
 )
 
 type SubscriptionService interface {
 	Subscribe(context.Context, dto.SubscriptionRequest) error
 	Confirm(context.Context, string) error
+	ResendConfirmation(context.Context, string) error
 	Unsubscribe(context.Context, string) error
 }
 
@@ -42,6 +45,35 @@ func (h *SubscriptionHandler) Subscribe(w http.ResponseWriter, r *http.Request)
 	subscriptionReq.Email = r.FormValue("email")
 	subscriptionReq.City = r.FormValue("city")
 	subscriptionReq.Frequency = r.FormValue("frequency")
+	subscriptionReq.Schedule = r.FormValue("schedule")
+	if deliveryHour := r.FormValue("deliveryHour"); deliveryHour != "" {
+		hour, err := strconv.ParseInt(deliveryHour, 10, 32)
+		if err != nil {
+			http.Error(w, "invalid input", http.StatusBadRequest)
+			h.log.Error("error parsing delivery hour", "deliveryHour", deliveryHour)
+			return
+		}
+		hour32 := int32(hour)
+		subscriptionReq.DeliveryHour = &hour32
+	}
+	subscriptionReq.Timezone = r.FormValue("timezone")
+	subscriptionReq.Conditions = r.Form["condition"]
+	subscriptionReq.ConditionLogic = r.FormValue("conditionLogic")
+	subscriptionReq.Preferences = dto.PreferencesRequest{
+		TemperatureUnit: r.FormValue("temperatureUnit"),
+		WindUnit:        r.FormValue("windUnit"),
+		Language:        r.FormValue("lang"),
+	}
+	subscriptionReq.LocationKey = r.FormValue("locationKey")
+	if aqiThreshold := r.FormValue("aqiThreshold"); aqiThreshold != "" {
+		threshold, err := strconv.ParseInt(aqiThreshold, 10, 32)
+		if err != nil {
+			http.Error(w, "invalid input", http.StatusBadRequest)
+			h.log.Error("error parsing aqi threshold", "aqiThreshold", aqiThreshold)
+			return
+		}
+		subscriptionReq.AqiThreshold = int32(threshold)
+	}
 
 	if err = h.validator.Struct(subscriptionReq); err != nil {
 		http.Error(w, "invalid input", http.StatusBadRequest)
@@ -54,10 +86,22 @@ func (h *SubscriptionHandler) Subscribe(w http.ResponseWriter, r *http.Request)
 			http.Error(w, "invalid input", http.StatusBadRequest)
 			h.log.Error("couldn't validate city", "error", err)
 			return
+		} else if errors.Is(err, commonerrors.ErrInvalidSchedule) {
+			http.Error(w, "invalid schedule", http.StatusBadRequest)
+			h.log.Error("couldn't validate schedule", "error", err)
+			return
+		} else if errors.Is(err, commonerrors.ErrInvalidCondition) {
+			http.Error(w, "invalid condition", http.StatusBadRequest)
+			h.log.Error("couldn't validate condition", "error", err)
+			return
 		} else if errors.Is(err, commonerrors.ErrSubscriptionAlreadyExists) {
 			http.Error(w, "email already subscribed", http.StatusConflict)
 			h.log.Error("subscription already exists", "error", err)
 			return
+		} else if errors.Is(err, commonerrors.ErrTooManyRequests) {
+			http.Error(w, "confirmation email was sent recently", http.StatusTooManyRequests)
+			h.log.Info("confirmation resend is rate limited", "error", err)
+			return
 		}
 
 		http.Error(w, "", http.StatusInternalServerError)
@@ -78,6 +122,10 @@ func (h *SubscriptionHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "token not found", http.StatusNotFound)
 			h.log.Error("token not found", "error", err)
 			return
+		} else if errors.Is(err, commonerrors.ErrTokenAlreadyUsed) {
+			http.Error(w, "token already used", http.StatusGone)
+			h.log.Info("token already used", "error", err)
+			return
 		}
 
 		http.Error(w, "", http.StatusInternalServerError)
@@ -86,10 +134,72 @@ func (h *SubscriptionHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ResendConfirmation accepts any valid email, the response does not depend on whether it is subscribed
+func (h *SubscriptionHandler) ResendConfirmation(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error parsing form", "error", err)
+		return
+	}
+
+	email := r.FormValue("email")
+	if err = h.validator.Var(email, "required,email"); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("error validating data", "error", err)
+		return
+	}
+
+	if err = h.subscriptionService.ResendConfirmation(r.Context(), email); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		h.log.Error("error resending confirmation", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// empty form action posts back to the page url, so the page works behind a path prefix
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<h1>Unsubscribe from weather updates?</h1>
+<form method="post" action="">
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// UnsubscribePage only asks for confirmation, link scanners and prefetching mail clients follow GET links from emails
+func (h *SubscriptionHandler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := unsubscribePage.Execute(w, nil); err != nil {
+		h.log.Error("error rendering unsubscribe page", "error", err)
+	}
+}
+
+// oneClickMaxMemory bounds multipart one-click body, it carries a single short field
+const oneClickMaxMemory = 1 << 10
+
+// Unsubscribe accepts RFC 8058 one-click requests, which the confirmation page sends as well
 func (h *SubscriptionHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
 	token := r.PathValue("token")
 
-	if err := h.subscriptionService.Unsubscribe(r.Context(), token); err != nil {
+	// RFC 8058 allows the one-click body to be sent url-encoded as well as multipart
+	err := r.ParseMultipartForm(oneClickMaxMemory)
+	if errors.Is(err, http.ErrNotMultipart) {
+		err = nil
+	}
+	if err != nil || r.PostFormValue("List-Unsubscribe") != "One-Click" {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		h.log.Error("unsubscribe request is not one-click", "error", err)
+		return
+	}
+
+	if err = h.subscriptionService.Unsubscribe(r.Context(), token); err != nil {
 		if errors.Is(err, commonerrors.ErrInvalidToken) {
 			http.Error(w, "invalid token", http.StatusBadRequest)
 			h.log.Error("invalid token", "error", err)
@@ -98,10 +208,14 @@ func (h *SubscriptionHandler) Unsubscribe(w http.ResponseWriter, r *http.Request
 			http.Error(w, "token not found", http.StatusNotFound)
 			h.log.Error("token not found", "error", err)
 			return
+		} else if errors.Is(err, commonerrors.ErrTokenAlreadyUsed) {
+			http.Error(w, "token already used", http.StatusGone)
+			h.log.Info("token already used", "error", err)
+			return
 		}
 
 		http.Error(w, "", http.StatusInternalServerError)
-		h.log.Error("error confirming subscription", "error", err)
+		h.log.Error("error unsubscribing", "error", err)
 		return
 	}
 }
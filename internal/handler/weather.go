@@ -6,22 +6,32 @@ import (
 	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
 	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/httputil"
+	"github.com/go-playground/validator/v10"
 	"log/slog"
 	"net/http"
+	"strconv"
+)
+
+const (
+	defaultForecastDays = 3
+	maxForecastDays     = 14
 )
 
 type WeatherService interface {
-	GetCurrentWeatherForLocation(context.Context, string) (*dto.WeatherDTO, error)
+	GetCurrentWeatherForLocation(context.Context, string, bool, dto.PreferencesRequest) (*dto.WeatherDTO, error)
+	GetForecastForLocation(context.Context, string, int) (*dto.ForecastDTO, error)
 }
 
 type WeatherHandler struct {
 	weatherService WeatherService
+	validator      *validator.Validate
 	log            *slog.Logger
 }
 
-func NewWeatherHandler(weatherService WeatherService, log *slog.Logger) *WeatherHandler {
+func NewWeatherHandler(weatherService WeatherService, validator *validator.Validate, log *slog.Logger) *WeatherHandler {
 	return &WeatherHandler{
 		weatherService: weatherService,
+		validator:      validator,
 		log:            log,
 	}
 }
@@ -36,12 +46,38 @@ func (h *WeatherHandler) GetCurrentWeather(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	weatherDto, err := h.weatherService.GetCurrentWeatherForLocation(r.Context(), location)
+	withAirQuality := false
+	if aqiStr := query.Get("aqi"); aqiStr != "" {
+		var err error
+		withAirQuality, err = strconv.ParseBool(aqiStr)
+		if err != nil {
+			http.Error(w, "Invalid aqi parameter", http.StatusBadRequest)
+			h.log.Info("invalid query parameter 'aqi'", "aqi", aqiStr)
+			return
+		}
+	}
+
+	prefsReq := dto.PreferencesRequest{
+		TemperatureUnit: query.Get("temperatureUnit"),
+		WindUnit:        query.Get("windUnit"),
+		Language:        query.Get("lang"),
+	}
+	if err := h.validator.Struct(prefsReq); err != nil {
+		http.Error(w, "Invalid preferences", http.StatusBadRequest)
+		h.log.Info("invalid preferences", "error", err)
+		return
+	}
+
+	weatherDto, err := h.weatherService.GetCurrentWeatherForLocation(r.Context(), location, withAirQuality, prefsReq)
 	if err != nil {
 		if errors.Is(err, commonerrors.ErrLocationNotFound) {
 			http.Error(w, "City not found", http.StatusNotFound)
 			h.log.Info("couldn't get weatherDto for provided location", "location", location)
 			return
+		} else if isProviderUnavailable(err) {
+			http.Error(w, "Weather provider unavailable", http.StatusServiceUnavailable)
+			h.log.Error("weather provider unavailable", "error", err)
+			return
 		}
 		http.Error(w, "", http.StatusInternalServerError)
 		h.log.Error("error getting weatherDto", "error", err)
@@ -55,3 +91,55 @@ func (h *WeatherHandler) GetCurrentWeather(w http.ResponseWriter, r *http.Reques
 		return
 	}
 }
+
+func (h *WeatherHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var location = query.Get("city")
+	if location == "" {
+		http.Error(w, "Missing city parameter", http.StatusBadRequest)
+		h.log.Info("no query parameter 'city' found")
+		return
+	}
+
+	days := defaultForecastDays
+	if daysStr := query.Get("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > maxForecastDays {
+			http.Error(w, "Invalid days parameter", http.StatusBadRequest)
+			h.log.Info("invalid query parameter 'days'", "days", daysStr)
+			return
+		}
+	}
+
+	forecastDto, err := h.weatherService.GetForecastForLocation(r.Context(), location, days)
+	if err != nil {
+		if errors.Is(err, commonerrors.ErrLocationNotFound) {
+			http.Error(w, "City not found", http.StatusNotFound)
+			h.log.Info("couldn't get forecastDto for provided location", "location", location)
+			return
+		} else if isProviderUnavailable(err) {
+			http.Error(w, "Weather provider unavailable", http.StatusServiceUnavailable)
+			h.log.Error("weather provider unavailable", "error", err)
+			return
+		}
+		http.Error(w, "", http.StatusInternalServerError)
+		h.log.Error("error getting forecastDto", "error", err)
+		return
+	}
+
+	err = httputil.WriteJSON(w, forecastDto)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		h.log.Error("error writing response", "error", err)
+		return
+	}
+}
+
+func isProviderUnavailable(err error) bool {
+	return errors.Is(err, commonerrors.ErrProviderUnavailable) ||
+		errors.Is(err, commonerrors.ErrProviderRateLimited) ||
+		errors.Is(err, commonerrors.ErrCircuitOpen) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
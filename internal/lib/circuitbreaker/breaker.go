@@ -0,0 +1,96 @@
+package circuitbreaker
+
+import (
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	State_Closed State = iota
+	State_Open
+	State_HalfOpen
+)
+
+// CircuitBreaker opens after failureThreshold consecutive failures and rejects calls for openTimeout,
+// after that a single probe call is let through to decide whether to close again
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	openTimeout      time.Duration
+	state            State
+	failures         int
+	openedAt         time.Time
+	probing          bool
+	now              func() time.Time
+}
+
+func New(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		state:            State_Closed,
+		now:              time.Now,
+	}
+}
+
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case State_Open:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return commonerrors.ErrCircuitOpen
+		}
+		b.state = State_HalfOpen
+		b.probing = true
+		return nil
+	case State_HalfOpen:
+		if b.probing {
+			return commonerrors.ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = State_Closed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == State_HalfOpen || b.failures >= b.failureThreshold {
+		b.state = State_Open
+		b.openedAt = b.now()
+	}
+}
+
+// Release gives back a probe whose outcome is unknown, e.g. the caller canceled the request
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
@@ -0,0 +1,22 @@
+package cronlogger
+
+import (
+	"log/slog"
+)
+
+// CronLogger adapts slog.Logger to logger of cron jobs and job wrappers
+type CronLogger struct {
+	log *slog.Logger
+}
+
+func (l *CronLogger) Info(msg string, keysAndValues ...any) {
+	l.log.Info("cron: "+msg, keysAndValues...)
+}
+
+func (l *CronLogger) Error(err error, msg string, keysAndValues ...any) {
+	l.log.Error("cron: "+msg, append(keysAndValues, "error", err)...)
+}
+
+func NewCronLogger(log *slog.Logger) *CronLogger {
+	return &CronLogger{log: log}
+}
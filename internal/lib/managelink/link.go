@@ -0,0 +1,58 @@
+package managelink
+
+import (
+	"fmt"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
+	"strings"
+	"time"
+)
+
+const purpose = "manage"
+
+// Linker issues stateless management links, the token carries subscriber id and expiry signed with rotatable keys
+type Linker struct {
+	signer  *signedtoken.Signer
+	baseURL string
+	ttl     time.Duration
+}
+
+// New accepts keys in "id:secret" form, they are kept apart from keys of confirmation and unsubscribe tokens
+func New(keys []string, baseURL string, ttl time.Duration) (*Linker, error) {
+	signer, err := signedtoken.New(keys)
+	if err != nil {
+		return nil, fmt.Errorf("management keys: %w", err)
+	}
+	return &Linker{
+		signer:  signer,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		ttl:     ttl,
+	}, nil
+}
+
+func (l *Linker) Link(subscriberId int32) string {
+	return l.URL(l.Token(subscriberId, time.Now()))
+}
+
+func (l *Linker) URL(token string) string {
+	return l.baseURL + "/" + token
+}
+
+func (l *Linker) Token(subscriberId int32, now time.Time) string {
+	return l.signer.Sign(signedtoken.Claims{
+		Subject:   subscriberId,
+		Purpose:   purpose,
+		ExpiresAt: now.Add(l.ttl),
+	})
+}
+
+func (l *Linker) Verify(token string, now time.Time) (int32, error) {
+	claims, err := l.signer.Verify(token, now)
+	if err != nil {
+		return 0, err
+	}
+	if claims.Purpose != purpose {
+		return 0, commonerrors.ErrInvalidToken
+	}
+	return claims.Subject, nil
+}
@@ -0,0 +1,47 @@
+package schedule
+
+import (
+	"fmt"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/robfig/cron/v3"
+	"strings"
+)
+
+// presets are expanded with the delivery hour of the subscription
+var presets = map[string]string{
+	"weekdays":              "0 %d * * 1-5",
+	"weekends":              "0 %d * * 0,6",
+	"mondays":               "0 %d * * 1",
+	"every-3-hours":         "0 */3 * * *",
+	"daytime-every-3-hours": "0 9-21/3 * * *",
+}
+
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// Parse accepts a preset name or a five field cron expression. Minutes are limited to a single quarter of an hour,
+// so a schedule fires at most hourly and lines up with the notification job that runs every 15 minutes
+func Parse(expr string, deliveryHour int32) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if preset, ok := presets[expr]; ok {
+		if strings.Contains(preset, "%d") {
+			preset = fmt.Sprintf(preset, deliveryHour)
+		}
+		expr = preset
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected preset or 5 cron fields", commonerrors.ErrInvalidSchedule)
+	}
+	switch fields[0] {
+	case "0", "15", "30", "45":
+	default:
+		return nil, fmt.Errorf("%w: minute must be one of 0, 15, 30 or 45", commonerrors.ErrInvalidSchedule)
+	}
+
+	s, err := parser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", commonerrors.ErrInvalidSchedule, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,112 @@
+package signedtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Claims are carried by the token itself, database keeps only Id which is useless without signing key.
+// Subject is id of what Purpose refers to, subscription for confirmation and unsubscribe, subscriber for management
+type Claims struct {
+	Id        string
+	Subject   int32
+	Purpose   string
+	ExpiresAt time.Time
+}
+
+// MinSecretLength keeps secrets from being short enough to guess, unsubscribe and management tokens live for weeks
+const MinSecretLength = 32
+
+// Signer signs with the first key and verifies with any key, so a new key is rotated in by putting it first
+// and an old one is retired by removing it once tokens signed with it are no longer needed
+type Signer struct {
+	keys     map[string][]byte
+	activeId string
+}
+
+// New accepts keys in "id:secret" form
+func New(keys []string) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys configured")
+	}
+
+	s := &Signer{keys: make(map[string][]byte, len(keys))}
+	for i, key := range keys {
+		id, secret, ok := strings.Cut(key, ":")
+		if !ok || id == "" || secret == "" || strings.Contains(id, ".") {
+			return nil, fmt.Errorf("signing key %d must be in id:secret form", i)
+		}
+		if len(secret) < MinSecretLength {
+			return nil, fmt.Errorf("signing key %q must be at least %d bytes long", id, MinSecretLength)
+		}
+		if _, exists := s.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate signing key id %q", id)
+		}
+		s.keys[id] = []byte(secret)
+		if i == 0 {
+			s.activeId = id
+		}
+	}
+	return s, nil
+}
+
+func (s *Signer) Sign(claims Claims) string {
+	payload := strings.Join([]string{
+		s.activeId,
+		claims.Id,
+		strconv.FormatInt(int64(claims.Subject), 10),
+		claims.Purpose,
+		strconv.FormatInt(claims.ExpiresAt.Unix(), 10),
+	}, ".")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + sign(s.keys[s.activeId], payload)
+}
+
+func (s *Signer) Verify(token string, now time.Time) (Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, commonerrors.ErrInvalidToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, commonerrors.ErrInvalidToken
+	}
+	payload := string(raw)
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 5 {
+		return Claims{}, commonerrors.ErrInvalidToken
+	}
+	key, ok := s.keys[parts[0]]
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(key, payload))) {
+		return Claims{}, commonerrors.ErrInvalidToken
+	}
+
+	subject, err := strconv.ParseInt(parts[2], 10, 32)
+	if err != nil {
+		return Claims{}, commonerrors.ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(parts[4], 10, 64)
+	if err != nil || now.After(time.Unix(exp, 0)) {
+		return Claims{}, commonerrors.ErrInvalidToken
+	}
+
+	return Claims{
+		Id:        parts[1],
+		Subject:   int32(subject),
+		Purpose:   parts[3],
+		ExpiresAt: time.Unix(exp, 0).UTC(),
+	}, nil
+}
+
+func sign(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("token." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,39 @@
+package mapper
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
+)
+
+func ForecastToForecastDTO(forecast model.Forecast) dto.ForecastDTO {
+	days := make([]dto.DailyForecastDTO, 0, len(forecast.Days))
+	for _, d := range forecast.Days {
+		hours := make([]dto.HourlyForecastDTO, 0, len(d.Hours))
+		for _, h := range d.Hours {
+			hours = append(hours, dto.HourlyForecastDTO{
+				Time:         h.Time.Format(time.RFC3339),
+				Temperature:  h.Temperature,
+				Humidity:     h.Humidity,
+				ChanceOfRain: h.ChanceOfRain,
+				Description:  h.Description,
+			})
+		}
+
+		days = append(days, dto.DailyForecastDTO{
+			Date:           d.Date.Format(time.DateOnly),
+			MaxTemperature: d.MaxTemperature,
+			MinTemperature: d.MinTemperature,
+			AvgTemperature: d.AvgTemperature,
+			AvgHumidity:    d.AvgHumidity,
+			ChanceOfRain:   d.ChanceOfRain,
+			Description:    d.Description,
+			Hours:          hours,
+		})
+	}
+
+	return dto.ForecastDTO{
+		City: forecast.Location.Name,
+		Days: days,
+	}
+}
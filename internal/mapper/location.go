@@ -0,0 +1,18 @@
+package mapper
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+)
+
+func LocationToLocationDTO(location model.Location) dto.LocationDTO {
+	return dto.LocationDTO{
+		Id:        location.Id,
+		Key:       location.Key,
+		Name:      location.Name,
+		Region:    location.Region,
+		Country:   location.Country,
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+	}
+}
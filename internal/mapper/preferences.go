@@ -0,0 +1,24 @@
+package mapper
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+)
+
+func PreferencesRequestToPreferences(req dto.PreferencesRequest) model.Preferences {
+	return ApplyPreferencesRequest(model.DefaultPreferences(), req)
+}
+
+// ApplyPreferencesRequest overrides given preferences with every field set in request
+func ApplyPreferencesRequest(prefs model.Preferences, req dto.PreferencesRequest) model.Preferences {
+	if req.TemperatureUnit != "" {
+		prefs.TemperatureUnit = model.TemperatureUnit(req.TemperatureUnit)
+	}
+	if req.WindUnit != "" {
+		prefs.WindUnit = model.WindUnit(req.WindUnit)
+	}
+	if req.Language != "" {
+		prefs.Language = req.Language
+	}
+	return prefs
+}
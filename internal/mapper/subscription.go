@@ -0,0 +1,18 @@
+package mapper
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+)
+
+func SubscriptionToManagedSubscriptionDTO(subscription model.Subscription, location model.Location) dto.ManagedSubscriptionDTO {
+	return dto.ManagedSubscriptionDTO{
+		Id:           subscription.Id,
+		Location:     LocationToLocationDTO(location),
+		Frequency:    string(subscription.Frequency),
+		Status:       string(subscription.Status),
+		DeliveryHour: subscription.DeliveryHour,
+		Timezone:     subscription.Timezone,
+		Schedule:     subscription.Schedule,
+	}
+}
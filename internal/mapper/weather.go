@@ -5,10 +5,31 @@ import (
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
 )
 
-func WeatherToWeatherDTO(weather model.Weather) dto.WeatherDTO {
+func WeatherToWeatherDTO(weather model.Weather, prefs model.Preferences) dto.WeatherDTO {
 	return dto.WeatherDTO{
-		Temperature: weather.Temperature,
-		Humidity:    weather.Humidity,
-		Description: weather.Description,
+		Temperature:     prefs.TemperatureUnit.FromCelsius(weather.Temperature),
+		TemperatureUnit: string(prefs.TemperatureUnit),
+		WindUnit:        string(prefs.WindUnit),
+		Humidity:        weather.Humidity,
+		Description:     weather.Description,
+		FeelsLike:       prefs.TemperatureUnit.FromCelsius(weather.FeelsLike),
+		WindSpeed:       prefs.WindUnit.FromKph(weather.WindSpeed),
+		WindDegree:      weather.WindDegree,
+		WindDirection:   weather.WindDirection,
+		Pressure:        weather.Pressure,
+		UVIndex:         weather.UVIndex,
+		Precipitation:   weather.Precipitation,
+		CloudCover:      weather.CloudCover,
+		Visibility:      weather.Visibility,
+	}
+}
+
+func AirQualityToAirQualityDTO(airQuality model.AirQuality) dto.AirQualityDTO {
+	return dto.AirQualityDTO{
+		PM25:       airQuality.PM25,
+		PM10:       airQuality.PM10,
+		O3:         airQuality.O3,
+		NO2:        airQuality.NO2,
+		USEPAIndex: airQuality.USEPAIndex,
 	}
 }
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+type AirQuality struct {
+	LocationId int32
+	MeasuredAt time.Time
+	FetchedAt  time.Time
+	PM25       float32
+	PM10       float32
+	O3         float32
+	NO2        float32
+	USEPAIndex int32
+	Provider   string
+}
+
+type AirQualityWithLocation struct {
+	AirQuality
+	Location
+}
@@ -0,0 +1,222 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+type ConditionField string
+
+const (
+	ConditionField_Temperature   ConditionField = "temperature"
+	ConditionField_FeelsLike     ConditionField = "feels_like"
+	ConditionField_Humidity      ConditionField = "humidity"
+	ConditionField_WindSpeed     ConditionField = "wind_speed"
+	ConditionField_Precipitation ConditionField = "precipitation"
+	ConditionField_UVIndex       ConditionField = "uv_index"
+	ConditionField_CloudCover    ConditionField = "cloud_cover"
+	ConditionField_ChanceOfRain  ConditionField = "chance_of_rain"
+	ConditionField_Description   ConditionField = "description"
+)
+
+type ConditionOperator string
+
+const (
+	ConditionOperator_Below    ConditionOperator = "<"
+	ConditionOperator_Above    ConditionOperator = ">"
+	ConditionOperator_Contains ConditionOperator = "~"
+)
+
+type ConditionLogic string
+
+const (
+	ConditionLogic_And ConditionLogic = "and"
+	ConditionLogic_Or  ConditionLogic = "or"
+)
+
+// hysteresis is how far a numeric value has to move back past the threshold before a matched condition is released
+var hysteresis = map[ConditionField]float32{
+	ConditionField_Temperature:   1,
+	ConditionField_FeelsLike:     1,
+	ConditionField_Humidity:      5,
+	ConditionField_WindSpeed:     5,
+	ConditionField_Precipitation: 0.5,
+	ConditionField_UVIndex:       1,
+	ConditionField_CloudCover:    10,
+	ConditionField_ChanceOfRain:  10,
+}
+
+// currentFields are provided by current weather that hourly and custom notifications are sent with
+var currentFields = map[ConditionField]bool{
+	ConditionField_Temperature:   true,
+	ConditionField_FeelsLike:     true,
+	ConditionField_Humidity:      true,
+	ConditionField_WindSpeed:     true,
+	ConditionField_Precipitation: true,
+	ConditionField_UVIndex:       true,
+	ConditionField_CloudCover:    true,
+	ConditionField_Description:   true,
+}
+
+// forecastFields are provided by daily forecast that daily notifications are sent with
+var forecastFields = map[ConditionField]bool{
+	ConditionField_Temperature:  true,
+	ConditionField_Humidity:     true,
+	ConditionField_ChanceOfRain: true,
+	ConditionField_Description:  true,
+}
+
+type Condition struct {
+	Id             int32
+	SubscriptionId int32
+	Field          ConditionField
+	Operator       ConditionOperator
+	Threshold      float32
+	Text           string
+}
+
+// ParseCondition parses expressions like "temperature<0", "humidity>80" or "description~rain"
+func ParseCondition(expr string) (Condition, bool) {
+	idx := strings.IndexAny(expr, "<>~")
+	if idx <= 0 || idx == len(expr)-1 {
+		return Condition{}, false
+	}
+
+	field := ConditionField(strings.ToLower(strings.TrimSpace(expr[:idx])))
+	operator := ConditionOperator(expr[idx : idx+1])
+	value := strings.TrimSpace(expr[idx+1:])
+
+	if operator == ConditionOperator_Contains {
+		if field != ConditionField_Description || value == "" {
+			return Condition{}, false
+		}
+		return Condition{Field: field, Operator: operator, Text: strings.ToLower(value)}, true
+	}
+
+	if _, ok := hysteresis[field]; !ok {
+		return Condition{}, false
+	}
+	threshold, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return Condition{}, false
+	}
+	return Condition{Field: field, Operator: operator, Threshold: float32(threshold)}, true
+}
+
+// SupportedBy reports whether notifications of the frequency carry the field, alerts are sent without conditions
+func (c Condition) SupportedBy(frequency Frequency) bool {
+	switch frequency {
+	case Frequency_Hourly, Frequency_Custom:
+		return currentFields[c.Field]
+	case Frequency_Daily:
+		return forecastFields[c.Field]
+	default:
+		return false
+	}
+}
+
+// ToMetric converts threshold given in subscriber units, weather is stored and compared in metric
+func (c Condition) ToMetric(prefs Preferences) Condition {
+	switch c.Field {
+	case ConditionField_Temperature, ConditionField_FeelsLike:
+		c.Threshold = prefs.TemperatureUnit.ToCelsius(c.Threshold)
+	case ConditionField_WindSpeed:
+		c.Threshold = prefs.WindUnit.ToKph(c.Threshold)
+	}
+	return c
+}
+
+type valueRange struct {
+	min float32
+	max float32
+}
+
+// WeatherSnapshot holds values conditions are checked against, for a forecast a field spans the whole day,
+// so "below" looks at the lowest and "above" at the highest value
+type WeatherSnapshot struct {
+	values      map[ConditionField]valueRange
+	description string
+}
+
+func SnapshotFromWeather(w Weather) WeatherSnapshot {
+	single := func(v float32) valueRange { return valueRange{v, v} }
+	return WeatherSnapshot{
+		values: map[ConditionField]valueRange{
+			ConditionField_Temperature:   single(w.Temperature),
+			ConditionField_FeelsLike:     single(w.FeelsLike),
+			ConditionField_Humidity:      single(w.Humidity),
+			ConditionField_WindSpeed:     single(w.WindSpeed),
+			ConditionField_Precipitation: single(w.Precipitation),
+			ConditionField_UVIndex:       single(w.UVIndex),
+			ConditionField_CloudCover:    single(w.CloudCover),
+		},
+		description: strings.ToLower(w.Description),
+	}
+}
+
+func SnapshotFromDailyForecast(d DailyForecast) WeatherSnapshot {
+	humidity := valueRange{d.AvgHumidity, d.AvgHumidity}
+	chanceOfRain := float32(d.ChanceOfRain)
+	descriptions := []string{d.Description}
+	for i, h := range d.Hours {
+		if i == 0 {
+			humidity = valueRange{h.Humidity, h.Humidity}
+		}
+		humidity.min = min(humidity.min, h.Humidity)
+		humidity.max = max(humidity.max, h.Humidity)
+		chanceOfRain = max(chanceOfRain, float32(h.ChanceOfRain))
+		descriptions = append(descriptions, h.Description)
+	}
+
+	return WeatherSnapshot{
+		values: map[ConditionField]valueRange{
+			ConditionField_Temperature:  {d.MinTemperature, d.MaxTemperature},
+			ConditionField_Humidity:     humidity,
+			ConditionField_ChanceOfRain: {chanceOfRain, chanceOfRain},
+		},
+		description: strings.ToLower(strings.Join(descriptions, " ")),
+	}
+}
+
+// EvaluateConditions combines conditions with the given logic. While the conditions are active thresholds are
+// relaxed by the hysteresis of the field, so a value hovering around the threshold does not flip the result back and forth
+func EvaluateConditions(conditions []Condition, logic ConditionLogic, snapshot WeatherSnapshot, active bool) bool {
+	if len(conditions) == 0 {
+		return true
+	}
+
+	for _, c := range conditions {
+		matched := c.matches(snapshot, active)
+		if logic == ConditionLogic_Or && matched {
+			return true
+		}
+		if logic != ConditionLogic_Or && !matched {
+			return false
+		}
+	}
+	return logic != ConditionLogic_Or
+}
+
+func (c Condition) matches(snapshot WeatherSnapshot, active bool) bool {
+	if c.Operator == ConditionOperator_Contains {
+		return strings.Contains(snapshot.description, c.Text)
+	}
+
+	v, ok := snapshot.values[c.Field]
+	if !ok {
+		return false
+	}
+	var margin float32
+	if active {
+		margin = hysteresis[c.Field]
+	}
+
+	switch c.Operator {
+	case ConditionOperator_Below:
+		return v.min < c.Threshold+margin
+	case ConditionOperator_Above:
+		return v.max > c.Threshold-margin
+	default:
+		return false
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxStatus_Pending OutboxStatus = "pending"
+	OutboxStatus_Sent    OutboxStatus = "sent"
+	OutboxStatus_Dead    OutboxStatus = "dead"
+)
+
+type OutboxEmail struct {
+	Id            int64
+	From          string
+	To            string
+	Subject       string
+	Text          string
+	Headers       map[string]string
+	Status        OutboxStatus
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type HourlyForecast struct {
+	Time         time.Time
+	Temperature  float32
+	Humidity     float32
+	ChanceOfRain int
+	Description  string
+}
+
+type DailyForecast struct {
+	Date           time.Time
+	MaxTemperature float32
+	MinTemperature float32
+	AvgTemperature float32
+	AvgHumidity    float32
+	ChanceOfRain   int
+	Description    string
+	Hours          []HourlyForecast
+}
+
+type Forecast struct {
+	Location Location
+	Days     []DailyForecast
+}
@@ -1,6 +1,51 @@
 package model
 
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
 type Location struct {
-	Id   int32
-	Name string
+	Id        int32
+	Name      string
+	Region    string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	TzId      string
+	Key       string
+}
+
+// Query returns the most precise lookup string for a weather provider, coordinates are preferred over the name
+// because the name alone is ambiguous
+func (l *Location) Query() string {
+	if l.Latitude == 0 && l.Longitude == 0 {
+		return l.Name
+	}
+	return strconv.FormatFloat(l.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+}
+
+// SamePlaceTolerance is how far apart in degrees providers may place the same city, about 10 km
+const SamePlaceTolerance = 0.1
+
+// LocationKey identifies a place the same way for every provider. Providers spell regions and countries differently,
+// so the key is built from the name and coordinates rounded to 2 decimals. Providers still place a city up to a few
+// kilometres apart, a stored location of the same name within SamePlaceTolerance is reused instead of a new one.
+// Migration 19 backfills existing rows with the same normalisation
+func LocationKey(name string, latitude, longitude float64) string {
+	normalized := strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+	return normalized + "|" + formatKeyCoordinate(latitude) + "|" + formatKeyCoordinate(longitude)
+}
+
+func formatKeyCoordinate(c float64) string {
+	rounded := math.Round(c*100) / 100
+	if rounded == 0 {
+		// drops the sign of negative zero, postgres numeric rounds it to plain 0.00
+		rounded = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', 2, 64)
 }
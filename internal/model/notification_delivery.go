@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type NotificationKind string
+
+const (
+	NotificationKind_Hourly       NotificationKind = "hourly"
+	NotificationKind_Daily        NotificationKind = "daily"
+	NotificationKind_Custom       NotificationKind = "custom"
+	NotificationKind_AirQuality   NotificationKind = "air_quality"
+	NotificationKind_WeatherAlert NotificationKind = "weather_alert"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatus_Sent   DeliveryStatus = "sent"
+	DeliveryStatus_Failed DeliveryStatus = "failed"
+)
+
+// NotificationDelivery is one attempt to notify a subscription, Reference identifies the notified item when kind has many, e.g. weather alert
+type NotificationDelivery struct {
+	Id                int64
+	SubscriptionId    int32
+	Kind              NotificationKind
+	Reference         string
+	Status            DeliveryStatus
+	Error             string
+	ProviderMessageId string
+	AttemptedAt       time.Time
+}
@@ -0,0 +1,83 @@
+package model
+
+type TemperatureUnit string
+
+const (
+	TemperatureUnit_Celsius    TemperatureUnit = "C"
+	TemperatureUnit_Fahrenheit TemperatureUnit = "F"
+)
+
+type WindUnit string
+
+const (
+	WindUnit_Kph WindUnit = "kph"
+	WindUnit_Mph WindUnit = "mph"
+	WindUnit_Mps WindUnit = "mps"
+)
+
+const DefaultLanguage = "en"
+
+// Preferences control how weather is presented, values stored in the database are always metric and in english
+type Preferences struct {
+	TemperatureUnit TemperatureUnit
+	WindUnit        WindUnit
+	Language        string
+}
+
+func DefaultPreferences() Preferences {
+	return Preferences{
+		TemperatureUnit: TemperatureUnit_Celsius,
+		WindUnit:        WindUnit_Kph,
+		Language:        DefaultLanguage,
+	}
+}
+
+func (u TemperatureUnit) FromCelsius(c float32) float32 {
+	if u == TemperatureUnit_Fahrenheit {
+		return c*9/5 + 32
+	}
+	return c
+}
+
+func (u TemperatureUnit) ToCelsius(t float32) float32 {
+	if u == TemperatureUnit_Fahrenheit {
+		return (t - 32) * 5 / 9
+	}
+	return t
+}
+
+func (u TemperatureUnit) Symbol() string {
+	if u == TemperatureUnit_Fahrenheit {
+		return "°F"
+	}
+	return "°C"
+}
+
+func (u WindUnit) FromKph(kph float32) float32 {
+	switch u {
+	case WindUnit_Mph:
+		return kph / 1.609344
+	case WindUnit_Mps:
+		return kph / 3.6
+	default:
+		return kph
+	}
+}
+
+func (u WindUnit) ToKph(v float32) float32 {
+	switch u {
+	case WindUnit_Mph:
+		return v * 1.609344
+	case WindUnit_Mps:
+		return v * 3.6
+	default:
+		return v
+	}
+}
+
+func (u WindUnit) Symbol() string {
+	if u == WindUnit_Mps {
+		return "m/s"
+	}
+	return string(u)
+}
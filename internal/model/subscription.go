@@ -7,6 +7,8 @@ type Frequency string
 const (
 	Frequency_Hourly Frequency = "hourly"
 	Frequency_Daily  Frequency = "daily"
+	Frequency_Alerts Frequency = "alerts"
+	Frequency_Custom Frequency = "custom"
 )
 
 type SubscriptionStatus string
@@ -17,17 +19,87 @@ const (
 )
 
 type Subscriber struct {
-	Id        int32
-	Email     string
+	Id    int32
+	Email string
+	Preferences
 	CreatedAt time.Time
 }
 
 type Subscription struct {
-	Id           int32
-	SubscriberId int32
-	LocationId   int32
-	Frequency    Frequency
-	Status       SubscriptionStatus
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	Id              int32
+	SubscriberId    int32
+	LocationId      int32
+	Frequency       Frequency
+	Status          SubscriptionStatus
+	AqiThreshold    int32
+	AqiAlertActive  bool
+	DeliveryHour    int32
+	Timezone        string
+	Schedule        string
+	ConditionLogic  ConditionLogic
+	ConditionActive bool
+	LastDeliveredAt time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// NotificationTarget is confirmed subscription together with everything needed to send it a notification
+type NotificationTarget struct {
+	Subscription Subscription
+	Subscriber   Subscriber
+	Location     Location
+	Token        Token
+}
+
+const (
+	DefaultDeliveryHour = 9
+	DefaultTimezone     = "UTC"
+)
+
+// DailyDeliveryDue reports whether the daily email for the current local day of the subscription is due and not sent yet.
+// Due time is built from the local calendar day, so it stays at the same wall clock hour across DST changes
+func (s *Subscription) DailyDeliveryDue(now time.Time) (bool, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	local := now.In(loc)
+	due := time.Date(local.Year(), local.Month(), local.Day(), int(s.DeliveryHour), 0, 0, 0, loc)
+
+	since := s.LastDeliveredAt
+	if s.CreatedAt.After(since) {
+		since = s.CreatedAt
+	}
+
+	return !due.After(now) && since.Before(due), nil
+}
+
+// HourlyDeliveryDue reports whether nothing was sent since the start of the current hour
+func (s *Subscription) HourlyDeliveryDue(now time.Time) bool {
+	since := s.LastDeliveredAt
+	if s.CreatedAt.After(since) {
+		since = s.CreatedAt
+	}
+
+	return since.Before(now.Truncate(time.Hour))
+}
+
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// ScheduleDue reports whether the schedule had a run in the subscription timezone since the last delivery
+func (s *Subscription) ScheduleDue(schedule Schedule, now time.Time) (bool, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	since := s.LastDeliveredAt
+	if s.CreatedAt.After(since) {
+		since = s.CreatedAt
+	}
+
+	return !schedule.Next(since.In(loc)).After(now), nil
 }
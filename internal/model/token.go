@@ -9,6 +9,9 @@ const (
 	TokenType_Unsubscribe  TokenType = "unsubscribe"
 )
 
+// TokenNeverExpires is used for unsubscribe tokens, they are removed together with subscription
+var TokenNeverExpires = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
 type Token struct {
 	Token          string
 	SubscriptionId int32
@@ -16,4 +19,10 @@ type Token struct {
 	CreatedAt      time.Time
 	ExpiresAt      time.Time
 	UsedAt         time.Time
+	// Legacy tokens were issued before signing, emails sent before then contain them as stored
+	Legacy bool
+}
+
+func (t Token) IsUsed() bool {
+	return !t.UsedAt.IsZero()
 }
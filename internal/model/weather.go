@@ -3,12 +3,22 @@ package model
 import "time"
 
 type Weather struct {
-	LocationId  int32
-	LastUpdated time.Time
-	FetchedAt   time.Time
-	Temperature float32
-	Humidity    float32
-	Description string
+	LocationId    int32
+	LastUpdated   time.Time
+	FetchedAt     time.Time
+	Temperature   float32
+	Humidity      float32
+	Description   string
+	FeelsLike     float32
+	WindSpeed     float32
+	WindDegree    int32
+	WindDirection string
+	Pressure      float32
+	UVIndex       float32
+	Precipitation float32
+	CloudCover    float32
+	Visibility    float32
+	Provider      string
 }
 
 type WeatherWithLocation struct {
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type WeatherAlert struct {
+	Id          int32
+	LocationId  int32
+	ExternalId  string
+	Event       string
+	Headline    string
+	Severity    string
+	Areas       string
+	Description string
+	Instruction string
+	Effective   time.Time
+	Expires     time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,83 @@
+package posgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+)
+
+type AirQualityRepository struct{}
+
+func NewAirQualityRepository() *AirQualityRepository {
+	return &AirQualityRepository{}
+}
+
+func (r *AirQualityRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, airQuality *model.AirQuality) error {
+	const op = "repository.postgresql.air_quality.Save"
+	const query = `
+		INSERT INTO air_quality (location_id, measured_at, fetched_at, pm2_5, pm10, o3, no2, us_epa_index, provider) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (location_id, measured_at) DO NOTHING
+	`
+	_, err := ex.ExecContext(
+		ctx,
+		query,
+		airQuality.LocationId,
+		airQuality.MeasuredAt.UTC(),
+		airQuality.FetchedAt.UTC(),
+		airQuality.PM25,
+		airQuality.PM10,
+		airQuality.O3,
+		airQuality.NO2,
+		airQuality.USEPAIndex,
+		airQuality.Provider,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: insert failed: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *AirQualityRepository) FindLastByLocationId(ctx context.Context, ex sqlutil.SQLExecutor, locationId int32) (*model.AirQuality, error) {
+	const op = "repository.postgresql.air_quality.FindLastByLocationId"
+	const query = `
+		SELECT 
+			a.location_id, 
+			a.measured_at, 
+			a.fetched_at, 
+			a.pm2_5, 
+			a.pm10, 
+			a.o3,
+			a.no2,
+			a.us_epa_index,
+			a.provider
+		FROM air_quality a
+		WHERE a.location_id = $1
+		ORDER BY a.measured_at DESC
+		LIMIT 1;
+	`
+
+	var a model.AirQuality
+	err := ex.QueryRowContext(ctx, query, locationId).Scan(
+		&a.LocationId,
+		&a.MeasuredAt,
+		&a.FetchedAt,
+		&a.PM25,
+		&a.PM10,
+		&a.O3,
+		&a.NO2,
+		&a.USEPAIndex,
+		&a.Provider,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+	return &a, nil
+}
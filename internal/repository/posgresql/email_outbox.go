@@ -0,0 +1,194 @@
+package posgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
+)
+
+type EmailOutboxRepository struct{}
+
+func NewEmailOutboxRepository() *EmailOutboxRepository {
+	return &EmailOutboxRepository{}
+}
+
+func (r *EmailOutboxRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, email *model.OutboxEmail) (int64, error) {
+	const op = "repository.postgresql.email_outbox.Save"
+	const query = `
+		INSERT INTO email_outbox (sender, recipient, subject, body, headers, status, attempts, next_attempt_at, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id;
+	`
+
+	headers, err := json.Marshal(email.Headers)
+	if err != nil {
+		return 0, fmt.Errorf("%s: marshal headers: %w", op, err)
+	}
+
+	var id int64
+	err = ex.QueryRowContext(
+		ctx,
+		query,
+		email.From,
+		email.To,
+		email.Subject,
+		email.Text,
+		headers,
+		email.Status,
+		email.Attempts,
+		email.NextAttemptAt.UTC(),
+		email.CreatedAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: scan id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// ClaimDue moves next attempt of due emails to claimedUntil and returns them, so the dispatcher sends without holding
+// row locks and another dispatcher takes the emails only after the claim expires. Rows being claimed by another
+// dispatcher are skipped rather than waited for
+func (r *EmailOutboxRepository) ClaimDue(ctx context.Context, ex sqlutil.SQLExecutor, now time.Time, claimedUntil time.Time, limit int) (emails []*model.OutboxEmail, err error) {
+	const op = "repository.postgresql.email_outbox.ClaimDue"
+	const query = `
+		UPDATE email_outbox
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id
+			FROM email_outbox
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING 
+			id,
+			sender,
+			recipient,
+			subject,
+			body,
+			headers,
+			status,
+			attempts,
+			next_attempt_at,
+			last_error,
+			created_at;
+	`
+
+	rows, err := ex.QueryContext(ctx, query, now.UTC(), claimedUntil.UTC(), limit)
+	if err != nil {
+		err = fmt.Errorf("%s: query failed: %w", op, err)
+
+		return
+	}
+	defer func(rows *sql.Rows) {
+		cerr := rows.Close()
+		err = errors.Join(err, cerr)
+	}(rows)
+
+	for rows.Next() {
+		var email model.OutboxEmail
+		var headers []byte
+		err = rows.Scan(
+			&email.Id,
+			&email.From,
+			&email.To,
+			&email.Subject,
+			&email.Text,
+			&headers,
+			&email.Status,
+			&email.Attempts,
+			&email.NextAttemptAt,
+			&email.LastError,
+			&email.CreatedAt,
+		)
+		if err != nil {
+			err = fmt.Errorf("%s: scan failed: %w", op, err)
+
+			return
+		}
+		if err = json.Unmarshal(headers, &email.Headers); err != nil {
+			err = fmt.Errorf("%s: unmarshal headers: %w", op, err)
+
+			return
+		}
+		emails = append(emails, &email)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("%s: rows iteration error: %w", op, err)
+
+		return
+	}
+
+	return
+}
+
+func (r *EmailOutboxRepository) UpdateDelivery(ctx context.Context, ex sqlutil.SQLExecutor, email *model.OutboxEmail) error {
+	const op = "repository.postgresql.email_outbox.UpdateDelivery"
+	const query = `
+		UPDATE email_outbox
+		SET status = $1,
+		    attempts = $2,
+		    next_attempt_at = $3,
+		    last_error = $4,
+		    sent_at = $5
+		WHERE id = $6;
+	`
+
+	_, err := ex.ExecContext(
+		ctx,
+		query,
+		email.Status,
+		email.Attempts,
+		email.NextAttemptAt.UTC(),
+		email.LastError,
+		nullTime(email.SentAt),
+		email.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: exec query: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *EmailOutboxRepository) CountByStatus(ctx context.Context, ex sqlutil.SQLExecutor, status model.OutboxStatus) (int, error) {
+	const op = "repository.postgresql.email_outbox.CountByStatus"
+	const query = "SELECT count(*) FROM email_outbox WHERE status = $1"
+
+	var count int
+	if err := ex.QueryRowContext(ctx, query, status).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+	return count, nil
+}
+
+func (r *EmailOutboxRepository) DeleteSentBatch(ctx context.Context, ex sqlutil.SQLExecutor, sentBefore time.Time, limit int) (int64, error) {
+	const op = "repository.postgresql.email_outbox.DeleteSentBatch"
+	const query = `
+		DELETE FROM email_outbox
+		WHERE id IN (
+			SELECT id
+			FROM email_outbox
+			WHERE status = 'sent' AND sent_at < $1
+			LIMIT $2
+		);
+	`
+
+	res, err := ex.ExecContext(ctx, query, sentBefore.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	return deleted, nil
+}
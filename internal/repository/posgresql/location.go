@@ -17,12 +17,22 @@ func NewLocationRepository() *LocationRepository {
 
 func (r *LocationRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, location *model.Location) (int32, error) {
 	const op = "repository.postgresql.location.Save"
-	const query = "INSERT INTO location (name) VALUES ($1) RETURNING id"
+	const query = `
+		INSERT INTO location (name, region, country, latitude, longitude, tz_id, canonical_key) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7) 
+		RETURNING id
+	`
 	var id int32
 	err := ex.QueryRowContext(
 		ctx,
 		query,
 		location.Name,
+		location.Region,
+		location.Country,
+		location.Latitude,
+		location.Longitude,
+		location.TzId,
+		location.Key,
 	).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("%s: scan id: %w", op, err)
@@ -31,21 +41,105 @@ func (r *LocationRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, l
 	return id, nil
 }
 
-func (r *LocationRepository) FindByName(ctx context.Context, ex sqlutil.SQLExecutor, name string) (*model.Location, error) {
-	const op = "repository.postgresql.location.FindByName"
+// Upsert saves location or returns id of the existing one with the same canonical key, a known timezone is never
+// overwritten with an empty one
+func (r *LocationRepository) Upsert(ctx context.Context, ex sqlutil.SQLExecutor, location *model.Location) (int32, error) {
+	const op = "repository.postgresql.location.Upsert"
+	const query = `
+		INSERT INTO location (name, region, country, latitude, longitude, tz_id, canonical_key) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7) 
+		ON CONFLICT (canonical_key) DO UPDATE
+		SET tz_id = CASE WHEN EXCLUDED.tz_id <> '' THEN EXCLUDED.tz_id ELSE location.tz_id END
+		RETURNING id
+	`
+	var id int32
+	err := ex.QueryRowContext(
+		ctx,
+		query,
+		location.Name,
+		location.Region,
+		location.Country,
+		location.Latitude,
+		location.Longitude,
+		location.TzId,
+		location.Key,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: scan id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *LocationRepository) FindByKey(ctx context.Context, ex sqlutil.SQLExecutor, key string) (*model.Location, error) {
+	const op = "repository.postgresql.location.FindByKey"
+	const query = `
+		SELECT 
+			l.id,
+			l.name,
+			l.region,
+			l.country,
+			l.latitude,
+			l.longitude,
+			l.tz_id,
+			l.canonical_key
+		FROM location l
+		WHERE l.canonical_key = $1
+		LIMIT 1;
+	`
+
+	var l model.Location
+	err := ex.QueryRowContext(ctx, query, key).Scan(
+		&l.Id,
+		&l.Name,
+		&l.Region,
+		&l.Country,
+		&l.Latitude,
+		&l.Longitude,
+		&l.TzId,
+		&l.Key,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+	return &l, nil
+}
+
+// FindSamePlace returns the closest stored location with the same name part of the key as location within
+// model.SamePlaceTolerance degrees, providers place the same city a few kilometres apart
+func (r *LocationRepository) FindSamePlace(ctx context.Context, ex sqlutil.SQLExecutor, location *model.Location) (*model.Location, error) {
+	const op = "repository.postgresql.location.FindSamePlace"
 	const query = `
 		SELECT 
 			l.id,
-			l.name
+			l.name,
+			l.region,
+			l.country,
+			l.latitude,
+			l.longitude,
+			l.tz_id,
+			l.canonical_key
 		FROM location l
-		WHERE l.name = $1
+		WHERE split_part(l.canonical_key, '|', 1) = split_part($1, '|', 1)
+		  AND abs(l.latitude - $2) <= $4
+		  AND abs(l.longitude - $3) <= $4
+		ORDER BY (l.latitude - $2) ^ 2 + (l.longitude - $3) ^ 2, l.id
 		LIMIT 1;
 	`
 
 	var l model.Location
-	err := ex.QueryRowContext(ctx, query, name).Scan(
+	err := ex.QueryRowContext(ctx, query, location.Key, location.Latitude, location.Longitude, model.SamePlaceTolerance).Scan(
 		&l.Id,
 		&l.Name,
+		&l.Region,
+		&l.Country,
+		&l.Latitude,
+		&l.Longitude,
+		&l.TzId,
+		&l.Key,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -61,7 +155,13 @@ func (r *LocationRepository) FindById(ctx context.Context, ex sqlutil.SQLExecuto
 	const query = `
 		SELECT 
 			l.id,
-			l.name
+			l.name,
+			l.region,
+			l.country,
+			l.latitude,
+			l.longitude,
+			l.tz_id,
+			l.canonical_key
 		FROM location l
 		WHERE l.id = $1
 		LIMIT 1;
@@ -71,6 +171,12 @@ func (r *LocationRepository) FindById(ctx context.Context, ex sqlutil.SQLExecuto
 	err := ex.QueryRowContext(ctx, query, id).Scan(
 		&l.Id,
 		&l.Name,
+		&l.Region,
+		&l.Country,
+		&l.Latitude,
+		&l.Longitude,
+		&l.TzId,
+		&l.Key,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -0,0 +1,96 @@
+package posgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
+)
+
+type NotificationDeliveryRepository struct{}
+
+func NewNotificationDeliveryRepository() *NotificationDeliveryRepository {
+	return &NotificationDeliveryRepository{}
+}
+
+func (r *NotificationDeliveryRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, delivery *model.NotificationDelivery) (int64, error) {
+	const op = "repository.postgresql.notification_delivery.Save"
+	const query = `
+		INSERT INTO notification_delivery (subscription_id, kind, reference, status, error, provider_message_id, attempted_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;
+	`
+
+	var id int64
+	err := ex.QueryRowContext(
+		ctx,
+		query,
+		delivery.SubscriptionId,
+		delivery.Kind,
+		delivery.Reference,
+		delivery.Status,
+		delivery.Error,
+		delivery.ProviderMessageId,
+		delivery.AttemptedAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: scan id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *NotificationDeliveryRepository) ExistsSent(ctx context.Context, ex sqlutil.SQLExecutor, subscriptionId int32, kind model.NotificationKind, reference string) (bool, error) {
+	const op = "repository.postgresql.notification_delivery.ExistsSent"
+	const query = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM notification_delivery
+			WHERE subscription_id = $1 AND kind = $2 AND reference = $3 AND status = 'sent'
+		);
+	`
+
+	var exists bool
+	if err := ex.QueryRowContext(ctx, query, subscriptionId, kind, reference).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+	return exists, nil
+}
+
+// DeleteAttemptedBeforeBatch keeps sent weather alert deliveries while the alert is active, they are what stops
+// the alert from being sent again on every poll. Alert without expiry is treated as active
+func (r *NotificationDeliveryRepository) DeleteAttemptedBeforeBatch(ctx context.Context, ex sqlutil.SQLExecutor, attemptedBefore time.Time, limit int) (int64, error) {
+	const op = "repository.postgresql.notification_delivery.DeleteAttemptedBeforeBatch"
+	const query = `
+		DELETE FROM notification_delivery
+		WHERE id IN (
+			SELECT d.id
+			FROM notification_delivery d
+			WHERE d.attempted_at < $1
+			  AND NOT (
+			      d.kind = 'weather_alert'
+			      AND d.status = 'sent'
+			      AND EXISTS (
+			          SELECT 1
+			          FROM weather_alert wa
+			          JOIN subscription s ON s.location_id = wa.location_id
+			          WHERE s.id = d.subscription_id
+			            AND wa.external_id = d.reference
+			            AND (wa.expires IS NULL OR wa.expires > $3)
+			      )
+			  )
+			LIMIT $2
+		);
+	`
+
+	res, err := ex.ExecContext(ctx, query, attemptedBefore.UTC(), limit, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	return deleted, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
 )
 
 type SubscriberRepository struct{}
@@ -17,12 +18,15 @@ func NewSubscriberRepository() *SubscriberRepository {
 
 func (r *SubscriberRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, subscriber *model.Subscriber) (int32, error) {
 	const op = "repository.postgresql.subscriber.Save"
-	const query = "INSERT INTO subscriber (email, created_at) VALUES ($1, $2) RETURNING id"
+	const query = "INSERT INTO subscriber (email, temperature_unit, wind_unit, language, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
 	var id int32
 	err := ex.QueryRowContext(
 		ctx,
 		query,
 		subscriber.Email,
+		subscriber.TemperatureUnit,
+		subscriber.WindUnit,
+		subscriber.Language,
 		subscriber.CreatedAt.UTC(),
 	).Scan(&id)
 	if err != nil {
@@ -38,6 +42,9 @@ func (r *SubscriberRepository) FindByEmail(ctx context.Context, ex sqlutil.SQLEx
 		SELECT 
 			s.id,
 			s.email,
+			s.temperature_unit,
+			s.wind_unit,
+			s.language,
 			s.created_at
 		FROM subscriber s
 		WHERE s.email = $1
@@ -48,6 +55,9 @@ func (r *SubscriberRepository) FindByEmail(ctx context.Context, ex sqlutil.SQLEx
 	err := ex.QueryRowContext(ctx, query, email).Scan(
 		&s.Id,
 		&s.Email,
+		&s.TemperatureUnit,
+		&s.WindUnit,
+		&s.Language,
 		&s.CreatedAt,
 	)
 	if err != nil {
@@ -59,12 +69,32 @@ func (r *SubscriberRepository) FindByEmail(ctx context.Context, ex sqlutil.SQLEx
 	return &s, nil
 }
 
+func (r *SubscriberRepository) UpdatePreferences(ctx context.Context, ex sqlutil.SQLExecutor, id int32, prefs model.Preferences) error {
+	const op = "repository.postgresql.subscriber.UpdatePreferences"
+	const query = `
+		UPDATE subscriber
+		SET temperature_unit = $1,
+		    wind_unit = $2,
+		    language = $3
+		WHERE id = $4;
+	`
+
+	_, err := ex.ExecContext(ctx, query, prefs.TemperatureUnit, prefs.WindUnit, prefs.Language, id)
+	if err != nil {
+		return fmt.Errorf("%s: update failed: %w", op, err)
+	}
+	return nil
+}
+
 func (r *SubscriberRepository) FindById(ctx context.Context, ex sqlutil.SQLExecutor, id int32) (*model.Subscriber, error) {
 	const op = "repository.postgresql.subscriber.FindById"
 	const query = `
 		SELECT 
 			s.id,
 			s.email,
+			s.temperature_unit,
+			s.wind_unit,
+			s.language,
 			s.created_at
 		FROM subscriber s
 		WHERE s.id = $1
@@ -75,6 +105,9 @@ func (r *SubscriberRepository) FindById(ctx context.Context, ex sqlutil.SQLExecu
 	err := ex.QueryRowContext(ctx, query, id).Scan(
 		&s.Id,
 		&s.Email,
+		&s.TemperatureUnit,
+		&s.WindUnit,
+		&s.Language,
 		&s.CreatedAt,
 	)
 	if err != nil {
@@ -85,3 +118,31 @@ func (r *SubscriberRepository) FindById(ctx context.Context, ex sqlutil.SQLExecu
 	}
 	return &s, nil
 }
+
+func (r *SubscriberRepository) DeleteOrphanedBatch(ctx context.Context, ex sqlutil.SQLExecutor, createdBefore time.Time, limit int) (int64, error) {
+	const op = "repository.postgresql.subscriber.DeleteOrphanedBatch"
+	const query = `
+		DELETE FROM subscriber
+		WHERE id IN (
+			SELECT sr.id
+			FROM subscriber sr
+			WHERE sr.created_at < $1
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM subscription s
+				WHERE s.subscriber_id = sr.id
+			  )
+			LIMIT $2
+		);
+	`
+
+	res, err := ex.ExecContext(ctx, query, createdBefore.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	return deleted, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
 )
 
 type SubscriptionRepository struct{}
@@ -17,7 +18,7 @@ func NewSubscriptionRepository() *SubscriptionRepository {
 
 func (r *SubscriptionRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, subscription *model.Subscription) (int32, error) {
 	const op = "repository.postgresql.subscription.Save"
-	const query = "INSERT INTO subscription (subscriber_id, location_id, frequency, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	const query = "INSERT INTO subscription (subscriber_id, location_id, frequency, status, aqi_threshold, aqi_alert_active, delivery_hour, timezone, schedule, condition_logic, condition_active, last_delivered_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id"
 	var id int32
 	err := ex.QueryRowContext(
 		ctx,
@@ -26,6 +27,14 @@ func (r *SubscriptionRepository) Save(ctx context.Context, ex sqlutil.SQLExecuto
 		subscription.LocationId,
 		subscription.Frequency,
 		subscription.Status,
+		subscription.AqiThreshold,
+		subscription.AqiAlertActive,
+		subscription.DeliveryHour,
+		subscription.Timezone,
+		subscription.Schedule,
+		subscription.ConditionLogic,
+		subscription.ConditionActive,
+		subscription.LastDeliveredAt.UTC(),
 		subscription.CreatedAt.UTC(),
 		subscription.UpdatedAt.UTC(),
 	).Scan(&id)
@@ -45,6 +54,14 @@ func (r *SubscriptionRepository) FindBySubscriberIdAndLocationId(ctx context.Con
 			s.location_id,
 			s.frequency,
 			s.status,
+			s.aqi_threshold,
+			s.aqi_alert_active,
+			s.delivery_hour,
+			s.timezone,
+			s.schedule,
+			s.condition_logic,
+			s.condition_active,
+			s.last_delivered_at,
 			s.created_at,
 			s.updated_at
 		FROM subscription s
@@ -59,6 +76,14 @@ func (r *SubscriptionRepository) FindBySubscriberIdAndLocationId(ctx context.Con
 		&s.LocationId,
 		&s.Frequency,
 		&s.Status,
+		&s.AqiThreshold,
+		&s.AqiAlertActive,
+		&s.DeliveryHour,
+		&s.Timezone,
+		&s.Schedule,
+		&s.ConditionLogic,
+		&s.ConditionActive,
+		&s.LastDeliveredAt,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 	)
@@ -80,6 +105,14 @@ func (r *SubscriptionRepository) FindById(ctx context.Context, ex sqlutil.SQLExe
 			s.location_id,
 			s.frequency,
 			s.status,
+			s.aqi_threshold,
+			s.aqi_alert_active,
+			s.delivery_hour,
+			s.timezone,
+			s.schedule,
+			s.condition_logic,
+			s.condition_active,
+			s.last_delivered_at,
 			s.created_at,
 			s.updated_at
 		FROM subscription s
@@ -94,6 +127,14 @@ func (r *SubscriptionRepository) FindById(ctx context.Context, ex sqlutil.SQLExe
 		&s.LocationId,
 		&s.Frequency,
 		&s.Status,
+		&s.AqiThreshold,
+		&s.AqiAlertActive,
+		&s.DeliveryHour,
+		&s.Timezone,
+		&s.Schedule,
+		&s.ConditionLogic,
+		&s.ConditionActive,
+		&s.LastDeliveredAt,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 	)
@@ -128,14 +169,28 @@ func (r *SubscriptionRepository) Update(ctx context.Context, ex sqlutil.SQLExecu
 		    location_id = $2,
 		    frequency = $3,
 		    status = $4,
-		    updated_at = $5
-		WHERE id = $6
+		    aqi_threshold = $5,
+		    aqi_alert_active = $6,
+		    delivery_hour = $7,
+		    timezone = $8,
+		    schedule = $9,
+		    condition_logic = $10,
+		    updated_at = $11
+		WHERE id = $12
 		RETURNING 
 		    id,
 		    subscriber_id,
 		    location_id,
 		    frequency,
 		    status,
+		    aqi_threshold,
+		    aqi_alert_active,
+		    delivery_hour,
+		    timezone,
+		    schedule,
+		    condition_logic,
+		    condition_active,
+		    last_delivered_at,
 		    created_at,
 		    updated_at;
 	`
@@ -146,7 +201,13 @@ func (r *SubscriptionRepository) Update(ctx context.Context, ex sqlutil.SQLExecu
 		subscription.LocationId,
 		subscription.Frequency,
 		subscription.Status,
-		subscription.UpdatedAt,
+		subscription.AqiThreshold,
+		subscription.AqiAlertActive,
+		subscription.DeliveryHour,
+		subscription.Timezone,
+		subscription.Schedule,
+		subscription.ConditionLogic,
+		subscription.UpdatedAt.UTC(),
 		subscription.Id,
 	).Scan(
 		&updated.Id,
@@ -154,6 +215,14 @@ func (r *SubscriptionRepository) Update(ctx context.Context, ex sqlutil.SQLExecu
 		&updated.LocationId,
 		&updated.Frequency,
 		&updated.Status,
+		&updated.AqiThreshold,
+		&updated.AqiAlertActive,
+		&updated.DeliveryHour,
+		&updated.Timezone,
+		&updated.Schedule,
+		&updated.ConditionLogic,
+		&updated.ConditionActive,
+		&updated.LastDeliveredAt,
 		&updated.CreatedAt,
 		&updated.UpdatedAt,
 	)
@@ -172,6 +241,14 @@ func (r *SubscriptionRepository) FindAllByFrequencyAndConfirmedStatus(ctx contex
 			s.location_id,
 			s.frequency,
 			s.status,
+			s.aqi_threshold,
+			s.aqi_alert_active,
+			s.delivery_hour,
+			s.timezone,
+			s.schedule,
+			s.condition_logic,
+			s.condition_active,
+			s.last_delivered_at,
 			s.created_at,
 			s.updated_at
 		FROM subscription s
@@ -197,6 +274,14 @@ func (r *SubscriptionRepository) FindAllByFrequencyAndConfirmedStatus(ctx contex
 			&s.LocationId,
 			&s.Frequency,
 			&s.Status,
+			&s.AqiThreshold,
+			&s.AqiAlertActive,
+			&s.DeliveryHour,
+			&s.Timezone,
+			&s.Schedule,
+			&s.ConditionLogic,
+			&s.ConditionActive,
+			&s.LastDeliveredAt,
 			&s.CreatedAt,
 			&s.UpdatedAt,
 		)
@@ -216,3 +301,350 @@ func (r *SubscriptionRepository) FindAllByFrequencyAndConfirmedStatus(ctx contex
 
 	return
 }
+
+// FindNotificationTargetsPage returns up to limit confirmed subscriptions with id greater than afterId ordered by id,
+// joined with subscriber, location and the newest unsubscribe token
+func (r *SubscriptionRepository) FindNotificationTargetsPage(ctx context.Context, ex sqlutil.SQLExecutor, frequency model.Frequency, afterId int32, limit int) (targets []*model.NotificationTarget, err error) {
+	const op = "repository.postgresql.subscription.FindNotificationTargetsPage"
+	const query = `
+		SELECT 
+			s.id,
+			s.subscriber_id,
+			s.location_id,
+			s.frequency,
+			s.status,
+			s.aqi_threshold,
+			s.aqi_alert_active,
+			s.delivery_hour,
+			s.timezone,
+			s.schedule,
+			s.condition_logic,
+			s.condition_active,
+			s.last_delivered_at,
+			s.created_at,
+			s.updated_at,
+			sr.id,
+			sr.email,
+			sr.temperature_unit,
+			sr.wind_unit,
+			sr.language,
+			sr.created_at,
+			l.id,
+			l.name,
+			l.region,
+			l.country,
+			l.latitude,
+			l.longitude,
+			l.tz_id,
+			l.canonical_key,
+			t.token,
+			t.subscription_id,
+			t.type,
+			t.created_at,
+			t.expires_at,
+			t.legacy
+		FROM subscription s
+		JOIN subscriber sr ON sr.id = s.subscriber_id
+		JOIN location l ON l.id = s.location_id
+		JOIN LATERAL (
+			SELECT tk.token, tk.subscription_id, tk.type, tk.created_at, tk.expires_at, tk.legacy
+			FROM token tk
+			WHERE tk.subscription_id = s.id AND tk.type = 'unsubscribe'
+			ORDER BY tk.created_at DESC
+			LIMIT 1
+		) t ON TRUE
+		WHERE s.frequency = $1 AND s.status = 'confirmed' AND s.id > $2
+		ORDER BY s.id
+		LIMIT $3;
+	`
+
+	rows, err := ex.QueryContext(ctx, query, frequency, afterId, limit)
+	if err != nil {
+		err = fmt.Errorf("%s: query failed: %w", op, err)
+
+		return
+	}
+	defer func(rows *sql.Rows) {
+		cerr := rows.Close()
+		err = errors.Join(err, cerr)
+	}(rows)
+
+	for rows.Next() {
+		var t model.NotificationTarget
+		err = rows.Scan(
+			&t.Subscription.Id,
+			&t.Subscription.SubscriberId,
+			&t.Subscription.LocationId,
+			&t.Subscription.Frequency,
+			&t.Subscription.Status,
+			&t.Subscription.AqiThreshold,
+			&t.Subscription.AqiAlertActive,
+			&t.Subscription.DeliveryHour,
+			&t.Subscription.Timezone,
+			&t.Subscription.Schedule,
+			&t.Subscription.ConditionLogic,
+			&t.Subscription.ConditionActive,
+			&t.Subscription.LastDeliveredAt,
+			&t.Subscription.CreatedAt,
+			&t.Subscription.UpdatedAt,
+			&t.Subscriber.Id,
+			&t.Subscriber.Email,
+			&t.Subscriber.TemperatureUnit,
+			&t.Subscriber.WindUnit,
+			&t.Subscriber.Language,
+			&t.Subscriber.CreatedAt,
+			&t.Location.Id,
+			&t.Location.Name,
+			&t.Location.Region,
+			&t.Location.Country,
+			&t.Location.Latitude,
+			&t.Location.Longitude,
+			&t.Location.TzId,
+			&t.Location.Key,
+			&t.Token.Token,
+			&t.Token.SubscriptionId,
+			&t.Token.Type,
+			&t.Token.CreatedAt,
+			&t.Token.ExpiresAt,
+			&t.Token.Legacy,
+		)
+		if err != nil {
+			err = fmt.Errorf("%s: scan failed: %w", op, err)
+
+			return
+		}
+		targets = append(targets, &t)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("%s: rows iteration error: %w", op, err)
+
+		return
+	}
+
+	return
+}
+
+func (r *SubscriptionRepository) FindAllWithAqiThresholdAndConfirmedStatus(ctx context.Context, ex sqlutil.SQLExecutor) (subscriptions []*model.Subscription, err error) {
+	const op = "repository.postgresql.subscription.FindAllWithAqiThresholdAndConfirmedStatus"
+	const query = `
+		SELECT 
+			s.id,
+			s.subscriber_id,
+			s.location_id,
+			s.frequency,
+			s.status,
+			s.aqi_threshold,
+			s.aqi_alert_active,
+			s.delivery_hour,
+			s.timezone,
+			s.schedule,
+			s.condition_logic,
+			s.condition_active,
+			s.last_delivered_at,
+			s.created_at,
+			s.updated_at
+		FROM subscription s
+		WHERE s.aqi_threshold > 0 AND s.status = 'confirmed';
+	`
+
+	rows, err := ex.QueryContext(ctx, query)
+	if err != nil {
+		err = fmt.Errorf("%s: query failed: %w", op, err)
+
+		return
+	}
+	defer func(rows *sql.Rows) {
+		cerr := rows.Close()
+		err = errors.Join(err, cerr)
+	}(rows)
+
+	for rows.Next() {
+		var s model.Subscription
+		err = rows.Scan(
+			&s.Id,
+			&s.SubscriberId,
+			&s.LocationId,
+			&s.Frequency,
+			&s.Status,
+			&s.AqiThreshold,
+			&s.AqiAlertActive,
+			&s.DeliveryHour,
+			&s.Timezone,
+			&s.Schedule,
+			&s.ConditionLogic,
+			&s.ConditionActive,
+			&s.LastDeliveredAt,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+		if err != nil {
+			err = fmt.Errorf("%s: scan failed: %w", op, err)
+
+			return
+		}
+		subscriptions = append(subscriptions, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("%s: rows iteration error: %w", op, err)
+
+		return
+	}
+
+	return
+}
+
+func (r *SubscriptionRepository) FindAllBySubscriberId(ctx context.Context, ex sqlutil.SQLExecutor, subscriberId int32) (subscriptions []*model.Subscription, err error) {
+	const op = "repository.postgresql.subscription.FindAllBySubscriberId"
+	const query = `
+		SELECT 
+			s.id,
+			s.subscriber_id,
+			s.location_id,
+			s.frequency,
+			s.status,
+			s.aqi_threshold,
+			s.aqi_alert_active,
+			s.delivery_hour,
+			s.timezone,
+			s.schedule,
+			s.condition_logic,
+			s.condition_active,
+			s.last_delivered_at,
+			s.created_at,
+			s.updated_at
+		FROM subscription s
+		WHERE s.subscriber_id = $1
+		ORDER BY s.id;
+	`
+
+	rows, err := ex.QueryContext(ctx, query, subscriberId)
+	if err != nil {
+		err = fmt.Errorf("%s: query failed: %w", op, err)
+
+		return
+	}
+	defer func(rows *sql.Rows) {
+		cerr := rows.Close()
+		err = errors.Join(err, cerr)
+	}(rows)
+
+	for rows.Next() {
+		var s model.Subscription
+		err = rows.Scan(
+			&s.Id,
+			&s.SubscriberId,
+			&s.LocationId,
+			&s.Frequency,
+			&s.Status,
+			&s.AqiThreshold,
+			&s.AqiAlertActive,
+			&s.DeliveryHour,
+			&s.Timezone,
+			&s.Schedule,
+			&s.ConditionLogic,
+			&s.ConditionActive,
+			&s.LastDeliveredAt,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+		if err != nil {
+			err = fmt.Errorf("%s: scan failed: %w", op, err)
+
+			return
+		}
+		subscriptions = append(subscriptions, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("%s: rows iteration error: %w", op, err)
+
+		return
+	}
+
+	return
+}
+
+func (r *SubscriptionRepository) UpdateAqiAlertActive(ctx context.Context, ex sqlutil.SQLExecutor, id int32, active bool) error {
+	const op = "repository.postgresql.subscription.UpdateAqiAlertActive"
+	const query = `
+		UPDATE subscription
+		SET aqi_alert_active = $1
+		WHERE id = $2;
+	`
+
+	_, err := ex.ExecContext(ctx, query, active, id)
+	if err != nil {
+		return fmt.Errorf("%s: update failed: %w", op, err)
+	}
+	return nil
+}
+
+// UpdateLastDeliveredAt is the conditional update that claims delivery, it succeeds only while last delivery time is still the
+// one the caller has read, so of overlapping runs only the first one gets true
+func (r *SubscriptionRepository) UpdateLastDeliveredAt(ctx context.Context, ex sqlutil.SQLExecutor, id int32, previous time.Time, deliveredAt time.Time) (bool, error) {
+	const op = "repository.postgresql.subscription.UpdateLastDeliveredAt"
+	const query = `
+		UPDATE subscription
+		SET last_delivered_at = $1
+		WHERE id = $2 AND last_delivered_at = $3;
+	`
+
+	res, err := ex.ExecContext(ctx, query, deliveredAt.UTC(), id, previous.UTC())
+	if err != nil {
+		return false, fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	return updated == 1, nil
+}
+
+func (r *SubscriptionRepository) UpdateConditionActive(ctx context.Context, ex sqlutil.SQLExecutor, id int32, active bool) error {
+	const op = "repository.postgresql.subscription.UpdateConditionActive"
+	const query = `
+		UPDATE subscription
+		SET condition_active = $1
+		WHERE id = $2;
+	`
+
+	_, err := ex.ExecContext(ctx, query, active, id)
+	if err != nil {
+		return fmt.Errorf("%s: update failed: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteAbandonedPendingBatch removes pending subscriptions whose every confirmation token expired before given time
+func (r *SubscriptionRepository) DeleteAbandonedPendingBatch(ctx context.Context, ex sqlutil.SQLExecutor, expiredBefore time.Time, limit int) (int64, error) {
+	const op = "repository.postgresql.subscription.DeleteAbandonedPendingBatch"
+	const query = `
+		DELETE FROM subscription
+		WHERE id IN (
+			SELECT s.id
+			FROM subscription s
+			WHERE s.status = 'pending'
+			  AND s.created_at < $1
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM token t
+				WHERE t.subscription_id = s.id
+				  AND t.type = 'confirmation'
+				  AND t.expires_at >= $1
+			  )
+			LIMIT $2
+		);
+	`
+
+	res, err := ex.ExecContext(ctx, query, expiredBefore.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	return deleted, nil
+}
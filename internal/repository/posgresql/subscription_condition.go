@@ -0,0 +1,100 @@
+package posgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+)
+
+type SubscriptionConditionRepository struct{}
+
+func NewSubscriptionConditionRepository() *SubscriptionConditionRepository {
+	return &SubscriptionConditionRepository{}
+}
+
+func (r *SubscriptionConditionRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, condition *model.Condition) (int32, error) {
+	const op = "repository.postgresql.subscription_condition.Save"
+	const query = "INSERT INTO subscription_condition (subscription_id, field, operator, threshold, text) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	var id int32
+	err := ex.QueryRowContext(
+		ctx,
+		query,
+		condition.SubscriptionId,
+		condition.Field,
+		condition.Operator,
+		condition.Threshold,
+		condition.Text,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: scan id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionConditionRepository) FindAllBySubscriptionId(ctx context.Context, ex sqlutil.SQLExecutor, subscriptionId int32) (conditions []model.Condition, err error) {
+	const op = "repository.postgresql.subscription_condition.FindAllBySubscriptionId"
+	const query = `
+		SELECT 
+			c.id,
+			c.subscription_id,
+			c.field,
+			c.operator,
+			c.threshold,
+			c.text
+		FROM subscription_condition c
+		WHERE c.subscription_id = $1
+		ORDER BY c.id;
+	`
+
+	rows, err := ex.QueryContext(ctx, query, subscriptionId)
+	if err != nil {
+		err = fmt.Errorf("%s: query failed: %w", op, err)
+
+		return
+	}
+	defer func(rows *sql.Rows) {
+		cerr := rows.Close()
+		err = errors.Join(err, cerr)
+	}(rows)
+
+	for rows.Next() {
+		var c model.Condition
+		err = rows.Scan(
+			&c.Id,
+			&c.SubscriptionId,
+			&c.Field,
+			&c.Operator,
+			&c.Threshold,
+			&c.Text,
+		)
+		if err != nil {
+			err = fmt.Errorf("%s: scan failed: %w", op, err)
+
+			return
+		}
+		conditions = append(conditions, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("%s: rows iteration error: %w", op, err)
+
+		return
+	}
+
+	return
+}
+
+func (r *SubscriptionConditionRepository) DeleteAllBySubscriptionId(ctx context.Context, ex sqlutil.SQLExecutor, subscriptionId int32) error {
+	const op = "repository.postgresql.subscription_condition.DeleteAllBySubscriptionId"
+	const query = "DELETE FROM subscription_condition WHERE subscription_id = $1"
+
+	_, err := ex.ExecContext(ctx, query, subscriptionId)
+	if err != nil {
+		return fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
 )
 
 type TokenRepository struct{}
@@ -17,7 +18,7 @@ func NewTokenRepository() *TokenRepository {
 
 func (r *TokenRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, token *model.Token) error {
 	const op = "repository.postgresql.token.Save"
-	const query = "INSERT INTO token (token, subscription_id, type, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)"
+	const query = "INSERT INTO token (token, subscription_id, type, created_at, expires_at, used_at) VALUES ($1, $2, $3, $4, $5, $6)"
 	_, err := ex.ExecContext(
 		ctx,
 		query,
@@ -26,6 +27,7 @@ func (r *TokenRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, toke
 		token.Type,
 		token.CreatedAt.UTC(),
 		token.ExpiresAt.UTC(),
+		nullTime(token.UsedAt),
 	)
 	if err != nil {
 		return fmt.Errorf("%s: scan id: %w", op, err)
@@ -42,19 +44,24 @@ func (r *TokenRepository) FindByToken(ctx context.Context, ex sqlutil.SQLExecuto
 			t.subscription_id, 
 			t.type, 
 			t.created_at, 
-			t.expires_at
+			t.expires_at,
+			t.used_at,
+			t.legacy
 		FROM token t
 		WHERE t.token = $1
 		LIMIT 1;
 	`
 
 	var t model.Token
+	var usedAt sql.NullTime
 	err := ex.QueryRowContext(ctx, query, token).Scan(
 		&t.Token,
 		&t.SubscriptionId,
 		&t.Type,
 		&t.CreatedAt,
 		&t.ExpiresAt,
+		&usedAt,
+		&t.Legacy,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -62,6 +69,7 @@ func (r *TokenRepository) FindByToken(ctx context.Context, ex sqlutil.SQLExecuto
 		}
 		return nil, fmt.Errorf("%s: query failed: %w", op, err)
 	}
+	t.UsedAt = usedAt.Time
 	return &t, nil
 }
 
@@ -73,19 +81,25 @@ func (r *TokenRepository) FindBySubscriptionIdAndType(ctx context.Context, ex sq
 			t.subscription_id, 
 			t.type, 
 			t.created_at, 
-			t.expires_at
+			t.expires_at,
+			t.used_at,
+			t.legacy
 		FROM token t
 		WHERE t.subscription_id = $1 AND t.type = $2
+		ORDER BY t.created_at DESC
 		LIMIT 1;
 	`
 
 	var t model.Token
+	var usedAt sql.NullTime
 	err := ex.QueryRowContext(ctx, query, subscriptionId, tokenType).Scan(
 		&t.Token,
 		&t.SubscriptionId,
 		&t.Type,
 		&t.CreatedAt,
 		&t.ExpiresAt,
+		&usedAt,
+		&t.Legacy,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -93,5 +107,73 @@ func (r *TokenRepository) FindBySubscriptionIdAndType(ctx context.Context, ex sq
 		}
 		return nil, fmt.Errorf("%s: query failed: %w", op, err)
 	}
+	t.UsedAt = usedAt.Time
 	return &t, nil
 }
+
+func (r *TokenRepository) CountBySubscriptionIdAndTypeCreatedAfter(ctx context.Context, ex sqlutil.SQLExecutor, subscriptionId int32, tokenType model.TokenType, after time.Time) (int, error) {
+	const op = "repository.postgresql.token.CountBySubscriptionIdAndTypeCreatedAfter"
+	const query = `
+		SELECT count(*)
+		FROM token t
+		WHERE t.subscription_id = $1 AND t.type = $2 AND t.created_at > $3;
+	`
+
+	var count int
+	err := ex.QueryRowContext(ctx, query, subscriptionId, tokenType, after.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+	return count, nil
+}
+
+// DeleteExpiredBatch keeps the newest unsubscribe token of each subscription, notification emails still link to it.
+// Tokens outlive their deleted subscription so that a replayed token is told apart from an unknown one, they are deleted
+// once used or created before the same cutoff
+func (r *TokenRepository) DeleteExpiredBatch(ctx context.Context, ex sqlutil.SQLExecutor, expiredBefore time.Time, limit int) (int64, error) {
+	const op = "repository.postgresql.token.DeleteExpiredBatch"
+	const query = `
+		DELETE FROM token
+		WHERE token IN (
+			SELECT t.token
+			FROM token t
+			WHERE (t.expires_at < $1
+			  AND (t.type <> 'unsubscribe' OR EXISTS (
+				SELECT 1
+				FROM token n
+				WHERE n.subscription_id = t.subscription_id
+				  AND n.type = t.type
+				  AND n.created_at > t.created_at
+			  )))
+			   OR (COALESCE(t.used_at, t.created_at) < $1
+			  AND NOT EXISTS (SELECT 1 FROM subscription s WHERE s.id = t.subscription_id))
+			LIMIT $2
+		);
+	`
+
+	res, err := ex.ExecContext(ctx, query, expiredBefore.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	return deleted, nil
+}
+
+// MarkUsed is the conditional update that makes token single use, only the first caller gets true
+func (r *TokenRepository) MarkUsed(ctx context.Context, ex sqlutil.SQLExecutor, token string, usedAt time.Time) (bool, error) {
+	const op = "repository.postgresql.token.MarkUsed"
+	const query = "UPDATE token SET used_at = $2 WHERE token = $1 AND used_at IS NULL"
+
+	res, err := ex.ExecContext(ctx, query, token, usedAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("%s: exec query: %w", op, err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	return updated == 1, nil
+}
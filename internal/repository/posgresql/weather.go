@@ -18,7 +18,13 @@ func NewWeatherRepository() *WeatherRepository {
 
 func (r *WeatherRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, weather *model.Weather) error {
 	const op = "repository.postgresql.weather.Save"
-	const query = "INSERT INTO weather (location_id, last_updated, fetched_at, temperature, humidity, description) VALUES ($1, $2, $3, $4, $5, $6)"
+	const query = `
+		INSERT INTO weather (location_id, last_updated, fetched_at, temperature, humidity, description, feels_like, 
+		                     wind_speed, wind_degree, wind_direction, pressure, uv_index, precipitation, cloud_cover, 
+		                     visibility, provider) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (location_id, last_updated) DO NOTHING
+	`
 	_, err := ex.ExecContext(
 		ctx,
 		query,
@@ -28,6 +34,16 @@ func (r *WeatherRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, we
 		weather.Temperature,
 		weather.Humidity,
 		weather.Description,
+		weather.FeelsLike,
+		weather.WindSpeed,
+		weather.WindDegree,
+		weather.WindDirection,
+		weather.Pressure,
+		weather.UVIndex,
+		weather.Precipitation,
+		weather.CloudCover,
+		weather.Visibility,
+		weather.Provider,
 	)
 	if err != nil {
 		return fmt.Errorf("%s: scan id: %w", op, err)
@@ -36,12 +52,12 @@ func (r *WeatherRepository) Save(ctx context.Context, ex sqlutil.SQLExecutor, we
 	return nil
 }
 
-func (r *WeatherRepository) FindLastUpdatedByLocation(
+func (r *WeatherRepository) FindLastUpdatedByLocationId(
 	ctx context.Context,
 	ex sqlutil.SQLExecutor,
-	location string,
+	locationId int32,
 ) (*model.Weather, error) {
-	const op = "repository.postgresql.weather.FindLastUpdatedByLocation"
+	const op = "repository.postgresql.weather.FindLastUpdatedByLocationId"
 	const query = `
 		SELECT 
 			w.location_id, 
@@ -49,22 +65,41 @@ func (r *WeatherRepository) FindLastUpdatedByLocation(
 			w.fetched_at, 
 			w.temperature, 
 			w.humidity, 
-			w.description
+			w.description,
+			w.feels_like,
+			w.wind_speed,
+			w.wind_degree,
+			w.wind_direction,
+			w.pressure,
+			w.uv_index,
+			w.precipitation,
+			w.cloud_cover,
+			w.visibility,
+			w.provider
 		FROM weather w
-		JOIN location l ON w.location_id = l.id
-		WHERE l.name = $1
+		WHERE w.location_id = $1
 		ORDER BY w.last_updated DESC
 		LIMIT 1;
 	`
 
 	var w model.Weather
-	err := ex.QueryRowContext(ctx, query, location).Scan(
+	err := ex.QueryRowContext(ctx, query, locationId).Scan(
 		&w.LocationId,
 		&w.LastUpdated,
 		&w.FetchedAt,
 		&w.Temperature,
 		&w.Humidity,
 		&w.Description,
+		&w.FeelsLike,
+		&w.WindSpeed,
+		&w.WindDegree,
+		&w.WindDirection,
+		&w.Pressure,
+		&w.UVIndex,
+		&w.Precipitation,
+		&w.CloudCover,
+		&w.Visibility,
+		&w.Provider,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
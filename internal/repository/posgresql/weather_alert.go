@@ -0,0 +1,61 @@
+package posgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
+)
+
+type WeatherAlertRepository struct{}
+
+func NewWeatherAlertRepository() *WeatherAlertRepository {
+	return &WeatherAlertRepository{}
+}
+
+// SaveIfAbsent stores the alert and reports whether it was new for the location
+func (r *WeatherAlertRepository) SaveIfAbsent(ctx context.Context, ex sqlutil.SQLExecutor, alert *model.WeatherAlert) (bool, error) {
+	const op = "repository.postgresql.weather_alert.SaveIfAbsent"
+	const query = `
+		INSERT INTO weather_alert (location_id, external_id, event, headline, severity, areas, description, instruction, effective, expires, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (location_id, external_id) DO NOTHING
+		RETURNING id;
+	`
+
+	var id int32
+	err := ex.QueryRowContext(
+		ctx,
+		query,
+		alert.LocationId,
+		alert.ExternalId,
+		alert.Event,
+		alert.Headline,
+		alert.Severity,
+		alert.Areas,
+		alert.Description,
+		alert.Instruction,
+		nullTime(alert.Effective),
+		nullTime(alert.Expires),
+		alert.CreatedAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: insert failed: %w", op, err)
+	}
+	alert.Id = id
+
+	return true, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -2,21 +2,105 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"strings"
+	"time"
 )
 
 type WeatherProvider interface {
-	GetCurrentWeather(string) (*model.WeatherWithLocation, error)
+	GetCurrentWeather(context.Context, string, string) (*model.WeatherWithLocation, error)
+	GetForecast(context.Context, string, int, string) (*model.Forecast, error)
+	GetAirQuality(context.Context, string) (*model.AirQualityWithLocation, error)
+	GetAlerts(context.Context, string) ([]model.WeatherAlert, error)
+	SearchLocations(context.Context, string) ([]model.Location, error)
 }
 
 type LocationRepository interface {
 	Save(context.Context, sqlutil.SQLExecutor, *model.Location) (int32, error)
-	FindByName(context.Context, sqlutil.SQLExecutor, string) (*model.Location, error)
+	Upsert(context.Context, sqlutil.SQLExecutor, *model.Location) (int32, error)
+	FindByKey(context.Context, sqlutil.SQLExecutor, string) (*model.Location, error)
+	FindSamePlace(context.Context, sqlutil.SQLExecutor, *model.Location) (*model.Location, error)
 	FindById(context.Context, sqlutil.SQLExecutor, int32) (*model.Location, error)
 }
 
 type EmailSender interface {
-	Send(context.Context, dto.SimpleEmail) error
+	Send(context.Context, dto.SimpleEmail) (string, error)
+}
+
+type EmailOutboxRepository interface {
+	Save(context.Context, sqlutil.SQLExecutor, *model.OutboxEmail) (int64, error)
+	ClaimDue(context.Context, sqlutil.SQLExecutor, time.Time, time.Time, int) ([]*model.OutboxEmail, error)
+	UpdateDelivery(context.Context, sqlutil.SQLExecutor, *model.OutboxEmail) error
+	CountByStatus(context.Context, sqlutil.SQLExecutor, model.OutboxStatus) (int, error)
+	DeleteSentBatch(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)
+}
+
+type ManageLinker interface {
+	Link(int32) string
+	URL(string) string
+	Verify(string, time.Time) (int32, error)
+}
+
+type TokenSigner interface {
+	Sign(signedtoken.Claims) string
+	Verify(string, time.Time) (signedtoken.Claims, error)
+}
+
+const manageLinkFooter = "\n\nManage your subscriptions: %s"
+
+func withManageLink(text string, link string) string {
+	return text + fmt.Sprintf(manageLinkFooter, link)
+}
+
+// unsubscribeHeaders enable one-click unsubscribe in mail clients as described in RFC 8058
+func unsubscribeHeaders(unsubscribeURL string, token string) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      "<" + strings.TrimSuffix(unsubscribeURL, "/") + "/" + token + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// signToken gives the value sent in emails, legacy tokens are signed as well so that their raw form can be phased out
+func signToken(signer TokenSigner, token *model.Token) string {
+	return signer.Sign(signedtoken.Claims{
+		Id:        token.Token,
+		Subject:   token.SubscriptionId,
+		Purpose:   string(token.Type),
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+// enqueueEmail stores email in the transaction of the change it reports, EmailDispatcher delivers it once committed
+func enqueueEmail(ctx context.Context, ex sqlutil.SQLExecutor, repository EmailOutboxRepository, email dto.SimpleEmail) error {
+	now := time.Now().UTC()
+	_, err := repository.Save(ctx, ex, &model.OutboxEmail{
+		From:          email.From,
+		To:            email.To,
+		Subject:       email.Subject,
+		Text:          email.Text,
+		Headers:       email.Headers,
+		Status:        model.OutboxStatus_Pending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	return err
+}
+
+// upsertLocation stores location under the key of a stored location of the same place when there is one,
+// so a city reported by another provider a few kilometres away is not stored twice
+func upsertLocation(ctx context.Context, ex sqlutil.SQLExecutor, repository LocationRepository, location *model.Location) (int32, error) {
+	samePlace, err := repository.FindSamePlace(ctx, ex, location)
+	if err != nil {
+		return 0, err
+	}
+	if samePlace != nil && samePlace.Key != location.Key {
+		stored := *location
+		stored.Key = samePlace.Key
+		location = &stored
+	}
+	return repository.Upsert(ctx, ex, location)
 }
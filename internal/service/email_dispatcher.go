@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/retry"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"log/slog"
+	"time"
+)
+
+type EmailDispatcher struct {
+	db                    *sql.DB
+	emailOutboxRepository EmailOutboxRepository
+	emailSender           EmailSender
+	cfg                   config.EmailOutbox
+	backoff               retry.Backoff
+	log                   *slog.Logger
+}
+
+func NewEmailDispatcher(db *sql.DB,
+	emailOutboxRepository EmailOutboxRepository,
+	emailSender EmailSender,
+	cfg config.EmailOutbox,
+	log *slog.Logger) *EmailDispatcher {
+	return &EmailDispatcher{
+		db:                    db,
+		emailOutboxRepository: emailOutboxRepository,
+		emailSender:           emailSender,
+		cfg:                   cfg,
+		backoff:               retry.Backoff{BaseDelay: cfg.BaseDelay, MaxDelay: cfg.MaxDelay},
+		log:                   log,
+	}
+}
+
+// Dispatch delivers due emails batch by batch until no full batch is left, then logs the backlog.
+// Cancelled ctx stops it from taking further emails, an email being sent is still sent and recorded
+func (d *EmailDispatcher) Dispatch(ctx context.Context) {
+	var sent, failed int
+	for ctx.Err() == nil {
+		batchSent, batchFailed, err := d.dispatchBatch(ctx)
+		sent += batchSent
+		failed += batchFailed
+		if err != nil {
+			d.log.Error("failed to dispatch emails", "error", err)
+			break
+		}
+		if batchSent+batchFailed < d.cfg.BatchSize {
+			break
+		}
+	}
+
+	statsCtx := context.WithoutCancel(ctx)
+	backlog, err := d.Backlog(statsCtx)
+	if err != nil {
+		d.log.Error("failed to count email outbox backlog", "error", err)
+		return
+	}
+	dead, err := d.emailOutboxRepository.CountByStatus(statsCtx, d.db, model.OutboxStatus_Dead)
+	if err != nil {
+		d.log.Error("failed to count dead emails", "error", err)
+		return
+	}
+	if sent > 0 || failed > 0 || backlog > 0 {
+		d.log.Info("email outbox stats", "sent", sent, "failed", failed, "backlog", backlog, "dead", dead)
+	}
+}
+
+// Backlog is the number of emails still waiting for delivery
+func (d *EmailDispatcher) Backlog(ctx context.Context) (int, error) {
+	return d.emailOutboxRepository.CountByStatus(ctx, d.db, model.OutboxStatus_Pending)
+}
+
+// dispatchBatch claims due emails with one statement and sends them without a transaction open, outcome of every
+// email is stored on its own. An email whose outcome was not stored is sent again once its claim expires.
+// Cancelled ctx releases the emails not sent yet, so they are due right away for the next run
+func (d *EmailDispatcher) dispatchBatch(ctx context.Context) (sent int, failed int, err error) {
+	now := time.Now().UTC()
+	emails, err := d.emailOutboxRepository.ClaimDue(ctx, d.db, now, now.Add(d.cfg.ClaimTimeout), d.cfg.BatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	persistCtx := context.WithoutCancel(ctx)
+	for i, email := range emails {
+		if ctx.Err() != nil {
+			d.release(persistCtx, emails[i:], now)
+			break
+		}
+		if d.deliver(persistCtx, email) {
+			sent++
+		} else {
+			failed++
+		}
+		if errUpdate := d.emailOutboxRepository.UpdateDelivery(persistCtx, d.db, email); errUpdate != nil {
+			d.log.Error("failed to store email delivery", "emailId", email.Id, "status", email.Status, "error", errUpdate)
+			err = errors.Join(err, errUpdate)
+		}
+	}
+	return sent, failed, err
+}
+
+func (d *EmailDispatcher) release(ctx context.Context, emails []*model.OutboxEmail, now time.Time) {
+	for _, email := range emails {
+		email.NextAttemptAt = now
+		if err := d.emailOutboxRepository.UpdateDelivery(ctx, d.db, email); err != nil {
+			d.log.Warn("failed to release claimed email, it is due once the claim expires", "emailId", email.Id, "error", err)
+		}
+	}
+}
+
+// deliver records the outcome of one attempt on the email
+func (d *EmailDispatcher) deliver(ctx context.Context, email *model.OutboxEmail) bool {
+	sendCtx, cancel := context.WithTimeout(ctx, d.cfg.SendTimeout)
+	defer cancel()
+
+	_, err := d.emailSender.Send(sendCtx, dto.SimpleEmail{
+		From:    email.From,
+		To:      email.To,
+		Subject: email.Subject,
+		Text:    email.Text,
+		Headers: email.Headers,
+	})
+	now := time.Now().UTC()
+	email.Attempts++
+	if err == nil {
+		email.Status = model.OutboxStatus_Sent
+		email.SentAt = now
+		email.LastError = ""
+		return true
+	}
+
+	email.LastError = err.Error()
+	if email.Attempts >= d.cfg.MaxAttempts {
+		email.Status = model.OutboxStatus_Dead
+		d.log.Error("email delivery gave up", "emailId", email.Id, "attempts", email.Attempts, "error", err)
+		return false
+	}
+	email.NextAttemptAt = now.Add(d.backoff.Delay(int(email.Attempts - 1)))
+	d.log.Warn("email delivery failed, will retry", "emailId", email.Id, "attempts", email.Attempts, "nextAttemptAt", email.NextAttemptAt, "error", err)
+	return false
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type dispatcherTestEnv struct {
+	driver     *txDriver
+	outbox     *MockEmailOutboxRepository
+	sender     *MockEmailSender
+	dispatcher *EmailDispatcher
+	// stored collects a copy of every outcome written by UpdateDelivery
+	stored []model.OutboxEmail
+}
+
+func newDispatcherTestEnv(t *testing.T, batchSize int) *dispatcherTestEnv {
+	db, driver := newTxDB()
+	env := &dispatcherTestEnv{
+		driver: driver,
+		outbox: NewMockEmailOutboxRepository(t),
+		sender: NewMockEmailSender(t),
+	}
+	env.dispatcher = NewEmailDispatcher(db, env.outbox, env.sender, config.EmailOutbox{
+		BatchSize:    batchSize,
+		ClaimTimeout: 5 * time.Minute,
+		SendTimeout:  time.Second,
+		MaxAttempts:  3,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+	}, discardLogger())
+	return env
+}
+
+func (env *dispatcherTestEnv) expectStore() {
+	env.outbox.EXPECT().UpdateDelivery(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, email *model.OutboxEmail) error {
+			env.stored = append(env.stored, *email)
+			return nil
+		})
+}
+
+func (env *dispatcherTestEnv) expectStats() {
+	env.outbox.EXPECT().CountByStatus(mock.Anything, mock.Anything, mock.Anything).Return(0, nil)
+}
+
+func outboxEmail(id int64, attempts int32) *model.OutboxEmail {
+	return &model.OutboxEmail{Id: id, To: "user@example.com", Subject: "subject", Status: model.OutboxStatus_Pending, Attempts: attempts}
+}
+
+func TestDispatchClaimsBatchAndSendsOutsideTransaction(t *testing.T) {
+	env := newDispatcherTestEnv(t, 10)
+	start := time.Now().UTC()
+	env.outbox.EXPECT().ClaimDue(mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(claimedUntil time.Time) bool {
+		return claimedUntil.Sub(start) >= 5*time.Minute && claimedUntil.Sub(start) < 6*time.Minute
+	}), 10).Return([]*model.OutboxEmail{outboxEmail(1, 0)}, nil)
+	env.sender.EXPECT().Send(mock.Anything, mock.MatchedBy(func(email dto.SimpleEmail) bool {
+		return email.To == "user@example.com"
+	})).Return("id", nil)
+	env.expectStore()
+	env.expectStats()
+
+	env.dispatcher.Dispatch(context.Background())
+
+	require.Len(t, env.stored, 1)
+	require.Equal(t, model.OutboxStatus_Sent, env.stored[0].Status)
+	require.Equal(t, int32(1), env.stored[0].Attempts)
+	require.False(t, env.stored[0].SentAt.IsZero())
+	require.Zero(t, env.driver.commits.Load()+env.driver.rollbacks.Load())
+}
+
+func TestDispatchSchedulesRetryWithBackoff(t *testing.T) {
+	env := newDispatcherTestEnv(t, 10)
+	env.outbox.EXPECT().ClaimDue(mock.Anything, mock.Anything, mock.Anything, mock.Anything, 10).
+		Return([]*model.OutboxEmail{outboxEmail(1, 0), outboxEmail(2, 1)}, nil)
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).Return("", errors.New("mailgun is down"))
+	env.expectStore()
+	env.expectStats()
+
+	start := time.Now().UTC()
+	env.dispatcher.Dispatch(context.Background())
+
+	require.Len(t, env.stored, 2)
+	for i, maxDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		email := env.stored[i]
+		require.Equal(t, model.OutboxStatus_Pending, email.Status)
+		require.Equal(t, int32(i+1), email.Attempts)
+		require.Equal(t, "mailgun is down", email.LastError)
+		require.True(t, email.NextAttemptAt.After(start))
+		require.True(t, email.NextAttemptAt.Before(start.Add(maxDelay+time.Second)), email.NextAttemptAt)
+	}
+}
+
+func TestDispatchMarksEmailDeadAfterMaxAttempts(t *testing.T) {
+	env := newDispatcherTestEnv(t, 10)
+	env.outbox.EXPECT().ClaimDue(mock.Anything, mock.Anything, mock.Anything, mock.Anything, 10).
+		Return([]*model.OutboxEmail{outboxEmail(1, 2)}, nil)
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).Return("", errors.New("rejected"))
+	env.expectStore()
+	env.expectStats()
+
+	env.dispatcher.Dispatch(context.Background())
+
+	require.Len(t, env.stored, 1)
+	require.Equal(t, model.OutboxStatus_Dead, env.stored[0].Status)
+	require.Equal(t, int32(3), env.stored[0].Attempts)
+}
+
+func TestDispatchTakesBatchesUntilPartialOne(t *testing.T) {
+	env := newDispatcherTestEnv(t, 2)
+	env.outbox.EXPECT().ClaimDue(mock.Anything, mock.Anything, mock.Anything, mock.Anything, 2).
+		Return([]*model.OutboxEmail{outboxEmail(1, 0), outboxEmail(2, 0)}, nil).Once()
+	env.outbox.EXPECT().ClaimDue(mock.Anything, mock.Anything, mock.Anything, mock.Anything, 2).
+		Return([]*model.OutboxEmail{outboxEmail(3, 0)}, nil).Once()
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).Return("id", nil).Times(3)
+	env.expectStore()
+	env.expectStats()
+
+	env.dispatcher.Dispatch(context.Background())
+
+	require.Len(t, env.stored, 3)
+}
+
+// outcome of one email failing to be stored does not keep the rest of the batch from being sent and stored
+func TestDispatchStoresEveryOutcomeOnItsOwn(t *testing.T) {
+	env := newDispatcherTestEnv(t, 2)
+	env.outbox.EXPECT().ClaimDue(mock.Anything, mock.Anything, mock.Anything, mock.Anything, 2).
+		Return([]*model.OutboxEmail{outboxEmail(1, 0), outboxEmail(2, 0)}, nil).Once()
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).Return("id", nil).Times(2)
+	env.outbox.EXPECT().UpdateDelivery(mock.Anything, mock.Anything, mock.MatchedBy(func(email *model.OutboxEmail) bool {
+		return email.Id == 1
+	})).Return(errors.New("connection reset")).Once()
+	env.outbox.EXPECT().UpdateDelivery(mock.Anything, mock.Anything, mock.MatchedBy(func(email *model.OutboxEmail) bool {
+		return email.Id == 2 && email.Status == model.OutboxStatus_Sent
+	})).Return(nil).Once()
+	env.expectStats()
+
+	// full batch would be followed by another claim, failed store stops the run instead
+	env.dispatcher.Dispatch(context.Background())
+}
+
+func TestDispatchReleasesUnsentEmailsWhenCancelled(t *testing.T) {
+	env := newDispatcherTestEnv(t, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	env.outbox.EXPECT().ClaimDue(mock.Anything, mock.Anything, mock.Anything, mock.Anything, 10).
+		Return([]*model.OutboxEmail{outboxEmail(1, 0), outboxEmail(2, 0), outboxEmail(3, 0)}, nil)
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).
+		RunAndReturn(func(sendCtx context.Context, _ dto.SimpleEmail) (string, error) {
+			cancel()
+			// email being sent is not cut short by shutdown
+			return "id", sendCtx.Err()
+		}).Once()
+	env.expectStore()
+	env.expectStats()
+
+	start := time.Now().UTC()
+	env.dispatcher.Dispatch(ctx)
+
+	require.Len(t, env.stored, 3)
+	require.Equal(t, model.OutboxStatus_Sent, env.stored[0].Status)
+	for _, released := range env.stored[1:] {
+		require.Equal(t, model.OutboxStatus_Pending, released.Status)
+		require.Zero(t, released.Attempts)
+		require.False(t, released.NextAttemptAt.After(start.Add(time.Second)))
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"log/slog"
+	"time"
+)
+
+type JanitorService struct {
+	db                     *sql.DB
+	tokenRepository        TokenRepository
+	subscriptionRepository SubscriptionRepository
+	subscriberRepository   SubscriberRepository
+	emailOutboxRepository  EmailOutboxRepository
+	deliveryRepository     NotificationDeliveryRepository
+	cfg                    config.Janitor
+	log                    *slog.Logger
+}
+
+func NewJanitorService(db *sql.DB,
+	tokenRepository TokenRepository,
+	subscriptionRepository SubscriptionRepository,
+	subscriberRepository SubscriberRepository,
+	emailOutboxRepository EmailOutboxRepository,
+	deliveryRepository NotificationDeliveryRepository,
+	cfg config.Janitor,
+	log *slog.Logger) *JanitorService {
+	return &JanitorService{
+		db:                     db,
+		tokenRepository:        tokenRepository,
+		subscriptionRepository: subscriptionRepository,
+		subscriberRepository:   subscriberRepository,
+		emailOutboxRepository:  emailOutboxRepository,
+		deliveryRepository:     deliveryRepository,
+		cfg:                    cfg,
+		log:                    log,
+	}
+}
+
+// Cleanup runs every delete in batches outside of a transaction, so each statement holds its row locks only briefly.
+// Pending subscriptions go before subscribers, so subscribers left without subscriptions are removed in the same run.
+// Cancelled ctx stops the run between batches, what is left is deleted by the next run
+func (s *JanitorService) Cleanup(ctx context.Context) {
+	s.log.Info("triggered Cleanup")
+	start := time.Now()
+	now := start.UTC()
+
+	tokens, err := s.deleteInBatches(ctx, now.Add(-s.cfg.TokenRetention), s.tokenRepository.DeleteExpiredBatch)
+	if err != nil {
+		s.log.Error("failed to delete expired tokens", "deleted", tokens, "error", err)
+	}
+	subscriptions, err := s.deleteInBatches(ctx, now.Add(-s.cfg.PendingRetention), s.subscriptionRepository.DeleteAbandonedPendingBatch)
+	if err != nil {
+		s.log.Error("failed to delete abandoned pending subscriptions", "deleted", subscriptions, "error", err)
+	}
+	subscribers, err := s.deleteInBatches(ctx, now.Add(-s.cfg.SubscriberRetention), s.subscriberRepository.DeleteOrphanedBatch)
+	if err != nil {
+		s.log.Error("failed to delete orphaned subscribers", "deleted", subscribers, "error", err)
+	}
+	emails, err := s.deleteInBatches(ctx, now.Add(-s.cfg.OutboxRetention), s.emailOutboxRepository.DeleteSentBatch)
+	if err != nil {
+		s.log.Error("failed to delete sent emails", "deleted", emails, "error", err)
+	}
+	deliveries, err := s.deleteInBatches(ctx, now.Add(-s.cfg.DeliveryRetention), s.deliveryRepository.DeleteAttemptedBeforeBatch)
+	if err != nil {
+		s.log.Error("failed to delete notification deliveries", "deleted", deliveries, "error", err)
+	}
+
+	s.log.Info("cleanup finished",
+		"expiredTokens", tokens,
+		"pendingSubscriptions", subscriptions,
+		"orphanedSubscribers", subscribers,
+		"sentEmails", emails,
+		"notificationDeliveries", deliveries,
+		"duration", time.Since(start),
+	)
+}
+
+func (s *JanitorService) deleteInBatches(ctx context.Context, before time.Time, deleteBatch func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)) (int64, error) {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		deleted, err := deleteBatch(ctx, s.db, before, s.cfg.BatchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted == 0 || deleted < int64(s.cfg.BatchSize) {
+			return total, nil
+		}
+	}
+}
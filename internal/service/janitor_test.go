@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type janitorTestEnv struct {
+	tokens        *MockTokenRepository
+	subscriptions *MockSubscriptionRepository
+	subscribers   *MockSubscriberRepository
+	outbox        *MockEmailOutboxRepository
+	deliveries    *MockNotificationDeliveryRepository
+	service       *JanitorService
+}
+
+func newJanitorTestEnv(t *testing.T) *janitorTestEnv {
+	db, _ := newTxDB()
+	env := &janitorTestEnv{
+		tokens:        NewMockTokenRepository(t),
+		subscriptions: NewMockSubscriptionRepository(t),
+		subscribers:   NewMockSubscriberRepository(t),
+		outbox:        NewMockEmailOutboxRepository(t),
+		deliveries:    NewMockNotificationDeliveryRepository(t),
+	}
+	env.service = NewJanitorService(db, env.tokens, env.subscriptions, env.subscribers, env.outbox, env.deliveries,
+		config.Janitor{BatchSize: 10, TokenRetention: time.Hour, PendingRetention: time.Hour, SubscriberRetention: time.Hour,
+			OutboxRetention: time.Hour, DeliveryRetention: time.Hour}, discardLogger())
+	return env
+}
+
+// batches returns delete function answering with given counts one call after another
+func batches(calls *int, counts ...int64) func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error) {
+	return func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error) {
+		deleted := counts[*calls]
+		*calls++
+		return deleted, nil
+	}
+}
+
+func TestDeleteInBatchesStopsAfterPartialBatch(t *testing.T) {
+	env := newJanitorTestEnv(t)
+	var calls int
+
+	total, err := env.service.deleteInBatches(context.Background(), time.Now(), batches(&calls, 10, 10, 3))
+	require.NoError(t, err)
+	require.Equal(t, int64(23), total)
+	require.Equal(t, 3, calls)
+}
+
+func TestDeleteInBatchesStopsAfterEmptyBatch(t *testing.T) {
+	env := newJanitorTestEnv(t)
+	var calls int
+
+	total, err := env.service.deleteInBatches(context.Background(), time.Now(), batches(&calls, 10, 0))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), total)
+	require.Equal(t, 2, calls)
+}
+
+func TestDeleteInBatchesStopsOnError(t *testing.T) {
+	env := newJanitorTestEnv(t)
+	failure := errors.New("lock timeout")
+	var calls int
+
+	total, err := env.service.deleteInBatches(context.Background(), time.Now(), func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error) {
+		calls++
+		if calls == 2 {
+			return 0, failure
+		}
+		return 10, nil
+	})
+	require.ErrorIs(t, err, failure)
+	require.Equal(t, int64(10), total)
+	require.Equal(t, 2, calls)
+}
+
+func TestDeleteInBatchesStopsWhenCancelled(t *testing.T) {
+	env := newJanitorTestEnv(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+
+	total, err := env.service.deleteInBatches(ctx, time.Now(), func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error) {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return 10, nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, int64(20), total)
+	require.Equal(t, 2, calls)
+}
+
+func TestCleanupDeletesInDependencyOrder(t *testing.T) {
+	env := newJanitorTestEnv(t)
+	var order []string
+	record := func(name string) func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error) {
+		return func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error) {
+			order = append(order, name)
+			return 0, nil
+		}
+	}
+	env.tokens.EXPECT().DeleteExpiredBatch(mock.Anything, mock.Anything, mock.Anything, 10).RunAndReturn(record("tokens"))
+	env.subscriptions.EXPECT().DeleteAbandonedPendingBatch(mock.Anything, mock.Anything, mock.Anything, 10).RunAndReturn(record("subscriptions"))
+	env.subscribers.EXPECT().DeleteOrphanedBatch(mock.Anything, mock.Anything, mock.Anything, 10).RunAndReturn(record("subscribers"))
+	env.outbox.EXPECT().DeleteSentBatch(mock.Anything, mock.Anything, mock.Anything, 10).RunAndReturn(record("emails"))
+	env.deliveries.EXPECT().DeleteAttemptedBeforeBatch(mock.Anything, mock.Anything, mock.Anything, 10).RunAndReturn(record("deliveries"))
+
+	env.service.Cleanup(context.Background())
+	require.Equal(t, []string{"tokens", "subscriptions", "subscribers", "emails", "deliveries"}, order)
+}
+
+func TestCleanupCancelledDeletesNothing(t *testing.T) {
+	env := newJanitorTestEnv(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// mocks fail the test on any call
+	env.service.Cleanup(ctx)
+}
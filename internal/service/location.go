@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/mapper"
+	"log/slog"
+)
+
+type LocationService struct {
+	weatherProvider WeatherProvider
+	log             *slog.Logger
+}
+
+func NewLocationService(weatherProvider WeatherProvider, log *slog.Logger) *LocationService {
+	return &LocationService{
+		weatherProvider: weatherProvider,
+		log:             log,
+	}
+}
+
+// SearchLocations does not store anything, key of a match is used to subscribe to exactly that location and the location
+// is stored only then
+func (s *LocationService) SearchLocations(ctx context.Context, query string) ([]dto.LocationDTO, error) {
+	locations, err := s.weatherProvider.SearchLocations(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	locationDtos := make([]dto.LocationDTO, 0, len(locations))
+	for _, location := range locations {
+		locationDtos = append(locationDtos, mapper.LocationToLocationDTO(location))
+	}
+
+	return locationDtos, nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSearchLocationsReturnsKeysWithoutStoring(t *testing.T) {
+	weatherProvider := NewMockWeatherProvider(t)
+	weatherProvider.EXPECT().SearchLocations(mock.Anything, "Kyiv").Return([]model.Location{
+		{Name: "Kyiv", Country: "Ukraine", Latitude: 50.43, Longitude: 30.52, Key: "kyiv|50.43|30.52"},
+	}, nil)
+
+	locations, err := NewLocationService(weatherProvider, discardLogger()).SearchLocations(context.Background(), "Kyiv")
+	require.NoError(t, err)
+	require.Len(t, locations, 1)
+	require.Equal(t, "kyiv|50.43|30.52", locations[0].Key)
+	require.Zero(t, locations[0].Id)
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/schedule"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/mapper"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+func (s *SubscriptionService) ManageURL(manageToken string) string {
+	return s.manageLinker.URL(manageToken)
+}
+
+func (s *SubscriptionService) GetManagedSubscriptions(ctx context.Context, manageToken string) (*dto.ManagementDTO, error) {
+	subscriberId, err := s.manageLinker.Verify(manageToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var managementDto *dto.ManagementDTO
+	err = sqlutil.WithTx(ctx, s.db, &sql.TxOptions{ReadOnly: true}, func(tx *sql.Tx) error {
+		subscriber, errIn := s.subscriberRepository.FindById(ctx, tx, subscriberId)
+		if errIn != nil {
+			return errIn
+		}
+		if subscriber == nil {
+			return commonerrors.ErrInvalidToken
+		}
+
+		subscriptions, errIn := s.subscriptionRepository.FindAllBySubscriberId(ctx, tx, subscriberId)
+		if errIn != nil {
+			return errIn
+		}
+
+		managed := make([]dto.ManagedSubscriptionDTO, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			location, errIn := s.locationRepository.FindById(ctx, tx, subscription.LocationId)
+			if errIn != nil {
+				return errIn
+			}
+			if location == nil {
+				return commonerrors.ErrUnexpectedState
+			}
+			managed = append(managed, mapper.SubscriptionToManagedSubscriptionDTO(*subscription, *location))
+		}
+
+		managementDto = &dto.ManagementDTO{
+			Email:         subscriber.Email,
+			Subscriptions: managed,
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Info("rollback transaction")
+		return nil, err
+	}
+	s.log.Info("transaction commited successfully")
+
+	return managementDto, nil
+}
+
+// AddManagedSubscription subscribes already verified subscriber to one more location, so it is confirmed right away
+func (s *SubscriptionService) AddManagedSubscription(ctx context.Context, manageToken string, req dto.ManagedSubscriptionRequest) error {
+	subscriberId, err := s.manageLinker.Verify(manageToken, time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		subscriber, errIn := s.subscriberRepository.FindById(ctx, tx, subscriberId)
+		if errIn != nil {
+			return errIn
+		}
+		if subscriber == nil {
+			return commonerrors.ErrInvalidToken
+		}
+
+		if req.Schedule != "" {
+			if _, errIn := schedule.Parse(req.Schedule, model.DefaultDeliveryHour); errIn != nil {
+				return errIn
+			}
+		}
+
+		locId, errIn := s.resolveLocation(ctx, tx, req.City, req.LocationKey)
+		if errIn != nil {
+			return errIn
+		}
+
+		subscription, errIn := s.subscriptionRepository.FindBySubscriberIdAndLocationId(ctx, tx, subscriberId, locId)
+		if errIn != nil {
+			return errIn
+		}
+		if subscription != nil {
+			return commonerrors.ErrSubscriptionAlreadyExists
+		}
+
+		subscription, conditions, errIn := s.buildSubscription(ctx, tx, dto.SubscriptionRequest{
+			Frequency:      req.Frequency,
+			Schedule:       req.Schedule,
+			AqiThreshold:   req.AqiThreshold,
+			Conditions:     req.Conditions,
+			ConditionLogic: req.ConditionLogic,
+		}, subscriberId, locId, subscriber.Preferences)
+		if errIn != nil {
+			return errIn
+		}
+		subscription.Status = model.SubscriptionStatus_Confirmed
+		subscriptionId, errIn := s.subscriptionRepository.Save(ctx, tx, subscription)
+		if errIn != nil {
+			return errIn
+		}
+		if errIn = s.saveConditions(ctx, tx, subscriptionId, conditions); errIn != nil {
+			return errIn
+		}
+
+		unsubToken := model.Token{
+			Token:          uuid.NewString(),
+			SubscriptionId: subscriptionId,
+			Type:           model.TokenType_Unsubscribe,
+			CreatedAt:      time.Now().UTC(),
+			ExpiresAt:      model.TokenNeverExpires,
+		}
+		if errIn = s.tokenRepository.Save(ctx, tx, &unsubToken); errIn != nil {
+			return errIn
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.log.Info("rollback transaction")
+		return err
+	}
+	s.log.Info("transaction commited successfully")
+
+	return nil
+}
+
+// checkConditionsSupported keeps frequency from changing to one whose notifications lack fields the conditions use
+func (s *SubscriptionService) checkConditionsSupported(ctx context.Context, tx *sql.Tx, subscription *model.Subscription) error {
+	conditions, err := s.conditionRepository.FindAllBySubscriptionId(ctx, tx, subscription.Id)
+	if err != nil {
+		return err
+	}
+	for _, c := range conditions {
+		if !c.SupportedBy(subscription.Frequency) {
+			return fmt.Errorf("%w: %s is not available for %s notifications", commonerrors.ErrInvalidCondition, c.Field, subscription.Frequency)
+		}
+	}
+	return nil
+}
+
+func (s *SubscriptionService) DeleteManagedSubscription(ctx context.Context, manageToken string, subscriptionId int32) error {
+	subscriberId, err := s.manageLinker.Verify(manageToken, time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		if _, errIn := s.findOwnedSubscription(ctx, tx, subscriberId, subscriptionId); errIn != nil {
+			return errIn
+		}
+
+		return s.subscriptionRepository.DeleteById(ctx, tx, subscriptionId)
+	})
+	if err != nil {
+		s.log.Info("rollback transaction")
+		return err
+	}
+	s.log.Info("transaction commited successfully")
+
+	return nil
+}
+
+// UpdateManagedSubscription changes subscription in place, so switching frequency or city does not require resubscribing
+func (s *SubscriptionService) UpdateManagedSubscription(ctx context.Context, manageToken string, subscriptionId int32, req dto.UpdateSubscriptionRequest) (*dto.ManagedSubscriptionDTO, error) {
+	subscriberId, err := s.manageLinker.Verify(manageToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedDto *dto.ManagedSubscriptionDTO
+	err = sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		subscription, errIn := s.findOwnedSubscription(ctx, tx, subscriberId, subscriptionId)
+		if errIn != nil {
+			return errIn
+		}
+
+		locationChanged := false
+		if req.City != "" || req.LocationKey != "" {
+			locId, errIn := s.resolveLocation(ctx, tx, req.City, req.LocationKey)
+			if errIn != nil {
+				return errIn
+			}
+			if locId != subscription.LocationId {
+				existing, errIn := s.subscriptionRepository.FindBySubscriberIdAndLocationId(ctx, tx, subscriberId, locId)
+				if errIn != nil {
+					return errIn
+				}
+				if existing != nil {
+					return commonerrors.ErrSubscriptionAlreadyExists
+				}
+				subscription.LocationId = locId
+				subscription.AqiAlertActive = false
+				locationChanged = true
+			}
+		}
+
+		if req.Frequency != "" {
+			subscription.Frequency = model.Frequency(req.Frequency)
+			if subscription.Frequency != model.Frequency_Custom {
+				subscription.Schedule = ""
+			}
+			if errIn = s.checkConditionsSupported(ctx, tx, subscription); errIn != nil {
+				return errIn
+			}
+		}
+		if req.Schedule != "" {
+			if subscription.Frequency != model.Frequency_Custom {
+				return fmt.Errorf("%w: schedule requires custom frequency", commonerrors.ErrInvalidSchedule)
+			}
+			subscription.Schedule = req.Schedule
+		}
+		if req.DeliveryHour != nil {
+			subscription.DeliveryHour = *req.DeliveryHour
+		}
+		if req.Timezone != "" || locationChanged {
+			subscription.Timezone, errIn = s.resolveTimezone(ctx, tx, req.Timezone, subscription.LocationId)
+			if errIn != nil {
+				return errIn
+			}
+		}
+		if subscription.Frequency == model.Frequency_Custom {
+			if _, errIn = schedule.Parse(subscription.Schedule, subscription.DeliveryHour); errIn != nil {
+				return errIn
+			}
+		}
+		subscription.UpdatedAt = time.Now().UTC()
+
+		subscription, errIn = s.subscriptionRepository.Update(ctx, tx, subscription)
+		if errIn != nil {
+			return errIn
+		}
+		if locationChanged {
+			if errIn = s.subscriptionRepository.UpdateConditionActive(ctx, tx, subscription.Id, false); errIn != nil {
+				return errIn
+			}
+		}
+
+		location, errIn := s.locationRepository.FindById(ctx, tx, subscription.LocationId)
+		if errIn != nil {
+			return errIn
+		}
+		subscriber, errIn := s.subscriberRepository.FindById(ctx, tx, subscriberId)
+		if errIn != nil {
+			return errIn
+		}
+		if location == nil || subscriber == nil {
+			return commonerrors.ErrUnexpectedState
+		}
+
+		frequency := string(subscription.Frequency)
+		if subscription.Schedule != "" {
+			frequency += " (" + subscription.Schedule + ")"
+		}
+		email := dto.SimpleEmail{
+			From:    s.changeEmailData.From,
+			To:      subscriber.Email,
+			Subject: s.changeEmailData.Subject,
+			Text: withManageLink(fmt.Sprintf(
+				s.changeEmailData.Text,
+				location.Name,
+				frequency,
+				subscription.DeliveryHour,
+				subscription.Timezone,
+			), s.manageLinker.Link(subscriber.Id)),
+		}
+		if errIn = enqueueEmail(ctx, tx, s.emailOutboxRepository, email); errIn != nil {
+			return errIn
+		}
+		s.log.Info("subscription change email is queued")
+
+		managed := mapper.SubscriptionToManagedSubscriptionDTO(*subscription, *location)
+		updatedDto = &managed
+		return nil
+	})
+	if err != nil {
+		s.log.Info("rollback transaction")
+		return nil, err
+	}
+	s.log.Info("transaction commited successfully")
+
+	return updatedDto, nil
+}
+
+// findOwnedSubscription hides subscriptions of other subscribers behind not found
+func (s *SubscriptionService) findOwnedSubscription(ctx context.Context, tx *sql.Tx, subscriberId int32, subscriptionId int32) (*model.Subscription, error) {
+	subscription, err := s.subscriptionRepository.FindById(ctx, tx, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil || subscription.SubscriberId != subscriberId {
+		return nil, commonerrors.ErrSubscriptionNotFound
+	}
+	return subscription, nil
+}
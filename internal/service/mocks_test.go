@@ -6,8 +6,11 @@ package service
 
 import (
 	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -39,107 +42,3032 @@ func (_m *MockWeatherProvider) EXPECT() *MockWeatherProvider_Expecter {
 	return &MockWeatherProvider_Expecter{mock: &_m.Mock}
 }
 
+// GetAirQuality provides a mock function for the type MockWeatherProvider
+func (_mock *MockWeatherProvider) GetAirQuality(context1 context.Context, s string) (*model.AirQualityWithLocation, error) {
+	ret := _mock.Called(context1, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAirQuality")
+	}
+
+	var r0 *model.AirQualityWithLocation
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*model.AirQualityWithLocation, error)); ok {
+		return returnFunc(context1, s)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *model.AirQualityWithLocation); ok {
+		r0 = returnFunc(context1, s)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.AirQualityWithLocation)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(context1, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWeatherProvider_GetAirQuality_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAirQuality'
+type MockWeatherProvider_GetAirQuality_Call struct {
+	*mock.Call
+}
+
+// GetAirQuality is a helper method to define mock.On call
+//   - context1
+//   - s
+func (_e *MockWeatherProvider_Expecter) GetAirQuality(context1 interface{}, s interface{}) *MockWeatherProvider_GetAirQuality_Call {
+	return &MockWeatherProvider_GetAirQuality_Call{Call: _e.mock.On("GetAirQuality", context1, s)}
+}
+
+func (_c *MockWeatherProvider_GetAirQuality_Call) Run(run func(context1 context.Context, s string)) *MockWeatherProvider_GetAirQuality_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetAirQuality_Call) Return(airQualityWithLocation *model.AirQualityWithLocation, err error) *MockWeatherProvider_GetAirQuality_Call {
+	_c.Call.Return(airQualityWithLocation, err)
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetAirQuality_Call) RunAndReturn(run func(context1 context.Context, s string) (*model.AirQualityWithLocation, error)) *MockWeatherProvider_GetAirQuality_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAlerts provides a mock function for the type MockWeatherProvider
+func (_mock *MockWeatherProvider) GetAlerts(context1 context.Context, s string) ([]model.WeatherAlert, error) {
+	ret := _mock.Called(context1, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAlerts")
+	}
+
+	var r0 []model.WeatherAlert
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]model.WeatherAlert, error)); ok {
+		return returnFunc(context1, s)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []model.WeatherAlert); ok {
+		r0 = returnFunc(context1, s)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.WeatherAlert)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(context1, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWeatherProvider_GetAlerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAlerts'
+type MockWeatherProvider_GetAlerts_Call struct {
+	*mock.Call
+}
+
+// GetAlerts is a helper method to define mock.On call
+//   - context1
+//   - s
+func (_e *MockWeatherProvider_Expecter) GetAlerts(context1 interface{}, s interface{}) *MockWeatherProvider_GetAlerts_Call {
+	return &MockWeatherProvider_GetAlerts_Call{Call: _e.mock.On("GetAlerts", context1, s)}
+}
+
+func (_c *MockWeatherProvider_GetAlerts_Call) Run(run func(context1 context.Context, s string)) *MockWeatherProvider_GetAlerts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetAlerts_Call) Return(weatherAlerts []model.WeatherAlert, err error) *MockWeatherProvider_GetAlerts_Call {
+	_c.Call.Return(weatherAlerts, err)
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetAlerts_Call) RunAndReturn(run func(context1 context.Context, s string) ([]model.WeatherAlert, error)) *MockWeatherProvider_GetAlerts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetCurrentWeather provides a mock function for the type MockWeatherProvider
-func (_mock *MockWeatherProvider) GetCurrentWeather(s string) (*model.WeatherWithLocation, error) {
-	ret := _mock.Called(s)
+func (_mock *MockWeatherProvider) GetCurrentWeather(context1 context.Context, s string, s1 string) (*model.WeatherWithLocation, error) {
+	ret := _mock.Called(context1, s, s1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCurrentWeather")
+	}
+
+	var r0 *model.WeatherWithLocation
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*model.WeatherWithLocation, error)); ok {
+		return returnFunc(context1, s, s1)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *model.WeatherWithLocation); ok {
+		r0 = returnFunc(context1, s, s1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.WeatherWithLocation)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(context1, s, s1)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWeatherProvider_GetCurrentWeather_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCurrentWeather'
+type MockWeatherProvider_GetCurrentWeather_Call struct {
+	*mock.Call
+}
+
+// GetCurrentWeather is a helper method to define mock.On call
+//   - context1
+//   - s
+//   - s1
+func (_e *MockWeatherProvider_Expecter) GetCurrentWeather(context1 interface{}, s interface{}, s1 interface{}) *MockWeatherProvider_GetCurrentWeather_Call {
+	return &MockWeatherProvider_GetCurrentWeather_Call{Call: _e.mock.On("GetCurrentWeather", context1, s, s1)}
+}
+
+func (_c *MockWeatherProvider_GetCurrentWeather_Call) Run(run func(context1 context.Context, s string, s1 string)) *MockWeatherProvider_GetCurrentWeather_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetCurrentWeather_Call) Return(weatherWithLocation *model.WeatherWithLocation, err error) *MockWeatherProvider_GetCurrentWeather_Call {
+	_c.Call.Return(weatherWithLocation, err)
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetCurrentWeather_Call) RunAndReturn(run func(context1 context.Context, s string, s1 string) (*model.WeatherWithLocation, error)) *MockWeatherProvider_GetCurrentWeather_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetForecast provides a mock function for the type MockWeatherProvider
+func (_mock *MockWeatherProvider) GetForecast(context1 context.Context, s string, n int, s1 string) (*model.Forecast, error) {
+	ret := _mock.Called(context1, s, n, s1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetForecast")
+	}
+
+	var r0 *model.Forecast
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, string) (*model.Forecast, error)); ok {
+		return returnFunc(context1, s, n, s1)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, string) *model.Forecast); ok {
+		r0 = returnFunc(context1, s, n, s1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Forecast)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, string) error); ok {
+		r1 = returnFunc(context1, s, n, s1)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWeatherProvider_GetForecast_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetForecast'
+type MockWeatherProvider_GetForecast_Call struct {
+	*mock.Call
+}
+
+// GetForecast is a helper method to define mock.On call
+//   - context1
+//   - s
+//   - n
+//   - s1
+func (_e *MockWeatherProvider_Expecter) GetForecast(context1 interface{}, s interface{}, n interface{}, s1 interface{}) *MockWeatherProvider_GetForecast_Call {
+	return &MockWeatherProvider_GetForecast_Call{Call: _e.mock.On("GetForecast", context1, s, n, s1)}
+}
+
+func (_c *MockWeatherProvider_GetForecast_Call) Run(run func(context1 context.Context, s string, n int, s1 string)) *MockWeatherProvider_GetForecast_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetForecast_Call) Return(forecast *model.Forecast, err error) *MockWeatherProvider_GetForecast_Call {
+	_c.Call.Return(forecast, err)
+	return _c
+}
+
+func (_c *MockWeatherProvider_GetForecast_Call) RunAndReturn(run func(context1 context.Context, s string, n int, s1 string) (*model.Forecast, error)) *MockWeatherProvider_GetForecast_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SearchLocations provides a mock function for the type MockWeatherProvider
+func (_mock *MockWeatherProvider) SearchLocations(context1 context.Context, s string) ([]model.Location, error) {
+	ret := _mock.Called(context1, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchLocations")
+	}
+
+	var r0 []model.Location
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]model.Location, error)); ok {
+		return returnFunc(context1, s)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []model.Location); ok {
+		r0 = returnFunc(context1, s)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Location)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(context1, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWeatherProvider_SearchLocations_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchLocations'
+type MockWeatherProvider_SearchLocations_Call struct {
+	*mock.Call
+}
+
+// SearchLocations is a helper method to define mock.On call
+//   - context1
+//   - s
+func (_e *MockWeatherProvider_Expecter) SearchLocations(context1 interface{}, s interface{}) *MockWeatherProvider_SearchLocations_Call {
+	return &MockWeatherProvider_SearchLocations_Call{Call: _e.mock.On("SearchLocations", context1, s)}
+}
+
+func (_c *MockWeatherProvider_SearchLocations_Call) Run(run func(context1 context.Context, s string)) *MockWeatherProvider_SearchLocations_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockWeatherProvider_SearchLocations_Call) Return(locations []model.Location, err error) *MockWeatherProvider_SearchLocations_Call {
+	_c.Call.Return(locations, err)
+	return _c
+}
+
+func (_c *MockWeatherProvider_SearchLocations_Call) RunAndReturn(run func(context1 context.Context, s string) ([]model.Location, error)) *MockWeatherProvider_SearchLocations_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockLocationRepository creates a new instance of MockLocationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockLocationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockLocationRepository {
+	mock := &MockLocationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockLocationRepository is an autogenerated mock type for the LocationRepository type
+type MockLocationRepository struct {
+	mock.Mock
+}
+
+type MockLocationRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockLocationRepository) EXPECT() *MockLocationRepository_Expecter {
+	return &MockLocationRepository_Expecter{mock: &_m.Mock}
+}
+
+// FindById provides a mock function for the type MockLocationRepository
+func (_mock *MockLocationRepository) FindById(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Location, error) {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindById")
+	}
+
+	var r0 *model.Location
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) (*model.Location, error)); ok {
+		return returnFunc(context1, sQLExecutor, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) *model.Location); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Location)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockLocationRepository_FindById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindById'
+type MockLocationRepository_FindById_Call struct {
+	*mock.Call
+}
+
+// FindById is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockLocationRepository_Expecter) FindById(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockLocationRepository_FindById_Call {
+	return &MockLocationRepository_FindById_Call{Call: _e.mock.On("FindById", context1, sQLExecutor, n)}
+}
+
+func (_c *MockLocationRepository_FindById_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockLocationRepository_FindById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockLocationRepository_FindById_Call) Return(location *model.Location, err error) *MockLocationRepository_FindById_Call {
+	_c.Call.Return(location, err)
+	return _c
+}
+
+func (_c *MockLocationRepository_FindById_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Location, error)) *MockLocationRepository_FindById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByKey provides a mock function for the type MockLocationRepository
+func (_mock *MockLocationRepository) FindByKey(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Location, error) {
+	ret := _mock.Called(context1, sQLExecutor, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByKey")
+	}
+
+	var r0 *model.Location
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) (*model.Location, error)); ok {
+		return returnFunc(context1, sQLExecutor, s)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) *model.Location); ok {
+		r0 = returnFunc(context1, sQLExecutor, s)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Location)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, string) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockLocationRepository_FindByKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByKey'
+type MockLocationRepository_FindByKey_Call struct {
+	*mock.Call
+}
+
+// FindByKey is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - s
+func (_e *MockLocationRepository_Expecter) FindByKey(context1 interface{}, sQLExecutor interface{}, s interface{}) *MockLocationRepository_FindByKey_Call {
+	return &MockLocationRepository_FindByKey_Call{Call: _e.mock.On("FindByKey", context1, sQLExecutor, s)}
+}
+
+func (_c *MockLocationRepository_FindByKey_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string)) *MockLocationRepository_FindByKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockLocationRepository_FindByKey_Call) Return(location *model.Location, err error) *MockLocationRepository_FindByKey_Call {
+	_c.Call.Return(location, err)
+	return _c
+}
+
+func (_c *MockLocationRepository_FindByKey_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Location, error)) *MockLocationRepository_FindByKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindSamePlace provides a mock function for the type MockLocationRepository
+func (_mock *MockLocationRepository) FindSamePlace(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (*model.Location, error) {
+	ret := _mock.Called(context1, sQLExecutor, location)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindSamePlace")
+	}
+
+	var r0 *model.Location
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) (*model.Location, error)); ok {
+		return returnFunc(context1, sQLExecutor, location)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) *model.Location); ok {
+		r0 = returnFunc(context1, sQLExecutor, location)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Location)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Location) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, location)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockLocationRepository_FindSamePlace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindSamePlace'
+type MockLocationRepository_FindSamePlace_Call struct {
+	*mock.Call
+}
+
+// FindSamePlace is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - location
+func (_e *MockLocationRepository_Expecter) FindSamePlace(context1 interface{}, sQLExecutor interface{}, location interface{}) *MockLocationRepository_FindSamePlace_Call {
+	return &MockLocationRepository_FindSamePlace_Call{Call: _e.mock.On("FindSamePlace", context1, sQLExecutor, location)}
+}
+
+func (_c *MockLocationRepository_FindSamePlace_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location)) *MockLocationRepository_FindSamePlace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Location))
+	})
+	return _c
+}
+
+func (_c *MockLocationRepository_FindSamePlace_Call) Return(location *model.Location, err error) *MockLocationRepository_FindSamePlace_Call {
+	_c.Call.Return(location, err)
+	return _c
+}
+
+func (_c *MockLocationRepository_FindSamePlace_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (*model.Location, error)) *MockLocationRepository_FindSamePlace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockLocationRepository
+func (_mock *MockLocationRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (int32, error) {
+	ret := _mock.Called(context1, sQLExecutor, location)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 int32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) (int32, error)); ok {
+		return returnFunc(context1, sQLExecutor, location)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) int32); ok {
+		r0 = returnFunc(context1, sQLExecutor, location)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Location) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, location)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockLocationRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockLocationRepository_Save_Call struct {
+	*mock.Call
+}
+
+// Save is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - location
+func (_e *MockLocationRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, location interface{}) *MockLocationRepository_Save_Call {
+	return &MockLocationRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, location)}
+}
+
+func (_c *MockLocationRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location)) *MockLocationRepository_Save_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Location))
+	})
+	return _c
+}
+
+func (_c *MockLocationRepository_Save_Call) Return(n int32, err error) *MockLocationRepository_Save_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockLocationRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (int32, error)) *MockLocationRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Upsert provides a mock function for the type MockLocationRepository
+func (_mock *MockLocationRepository) Upsert(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (int32, error) {
+	ret := _mock.Called(context1, sQLExecutor, location)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Upsert")
+	}
+
+	var r0 int32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) (int32, error)); ok {
+		return returnFunc(context1, sQLExecutor, location)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) int32); ok {
+		r0 = returnFunc(context1, sQLExecutor, location)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Location) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, location)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockLocationRepository_Upsert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Upsert'
+type MockLocationRepository_Upsert_Call struct {
+	*mock.Call
+}
+
+// Upsert is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - location
+func (_e *MockLocationRepository_Expecter) Upsert(context1 interface{}, sQLExecutor interface{}, location interface{}) *MockLocationRepository_Upsert_Call {
+	return &MockLocationRepository_Upsert_Call{Call: _e.mock.On("Upsert", context1, sQLExecutor, location)}
+}
+
+func (_c *MockLocationRepository_Upsert_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location)) *MockLocationRepository_Upsert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Location))
+	})
+	return _c
+}
+
+func (_c *MockLocationRepository_Upsert_Call) Return(n int32, err error) *MockLocationRepository_Upsert_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockLocationRepository_Upsert_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (int32, error)) *MockLocationRepository_Upsert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockEmailSender creates a new instance of MockEmailSender. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockEmailSender(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockEmailSender {
+	mock := &MockEmailSender{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockEmailSender is an autogenerated mock type for the EmailSender type
+type MockEmailSender struct {
+	mock.Mock
+}
+
+type MockEmailSender_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEmailSender) EXPECT() *MockEmailSender_Expecter {
+	return &MockEmailSender_Expecter{mock: &_m.Mock}
+}
+
+// Send provides a mock function for the type MockEmailSender
+func (_mock *MockEmailSender) Send(context1 context.Context, simpleEmail dto.SimpleEmail) (string, error) {
+	ret := _mock.Called(context1, simpleEmail)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Send")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, dto.SimpleEmail) (string, error)); ok {
+		return returnFunc(context1, simpleEmail)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, dto.SimpleEmail) string); ok {
+		r0 = returnFunc(context1, simpleEmail)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, dto.SimpleEmail) error); ok {
+		r1 = returnFunc(context1, simpleEmail)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEmailSender_Send_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Send'
+type MockEmailSender_Send_Call struct {
+	*mock.Call
+}
+
+// Send is a helper method to define mock.On call
+//   - context1
+//   - simpleEmail
+func (_e *MockEmailSender_Expecter) Send(context1 interface{}, simpleEmail interface{}) *MockEmailSender_Send_Call {
+	return &MockEmailSender_Send_Call{Call: _e.mock.On("Send", context1, simpleEmail)}
+}
+
+func (_c *MockEmailSender_Send_Call) Run(run func(context1 context.Context, simpleEmail dto.SimpleEmail)) *MockEmailSender_Send_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(dto.SimpleEmail))
+	})
+	return _c
+}
+
+func (_c *MockEmailSender_Send_Call) Return(s string, err error) *MockEmailSender_Send_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockEmailSender_Send_Call) RunAndReturn(run func(context1 context.Context, simpleEmail dto.SimpleEmail) (string, error)) *MockEmailSender_Send_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockEmailOutboxRepository creates a new instance of MockEmailOutboxRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockEmailOutboxRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockEmailOutboxRepository {
+	mock := &MockEmailOutboxRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockEmailOutboxRepository is an autogenerated mock type for the EmailOutboxRepository type
+type MockEmailOutboxRepository struct {
+	mock.Mock
+}
+
+type MockEmailOutboxRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEmailOutboxRepository) EXPECT() *MockEmailOutboxRepository_Expecter {
+	return &MockEmailOutboxRepository_Expecter{mock: &_m.Mock}
+}
+
+// ClaimDue provides a mock function for the type MockEmailOutboxRepository
+func (_mock *MockEmailOutboxRepository) ClaimDue(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, time11 time.Time, n int) ([]*model.OutboxEmail, error) {
+	ret := _mock.Called(context1, sQLExecutor, time1, time11, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDue")
+	}
+
+	var r0 []*model.OutboxEmail
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, time.Time, int) ([]*model.OutboxEmail, error)); ok {
+		return returnFunc(context1, sQLExecutor, time1, time11, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, time.Time, int) []*model.OutboxEmail); ok {
+		r0 = returnFunc(context1, sQLExecutor, time1, time11, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.OutboxEmail)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, time.Time, time.Time, int) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, time1, time11, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEmailOutboxRepository_ClaimDue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimDue'
+type MockEmailOutboxRepository_ClaimDue_Call struct {
+	*mock.Call
+}
+
+// ClaimDue is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - time1
+//   - time11
+//   - n
+func (_e *MockEmailOutboxRepository_Expecter) ClaimDue(context1 interface{}, sQLExecutor interface{}, time1 interface{}, time11 interface{}, n interface{}) *MockEmailOutboxRepository_ClaimDue_Call {
+	return &MockEmailOutboxRepository_ClaimDue_Call{Call: _e.mock.On("ClaimDue", context1, sQLExecutor, time1, time11, n)}
+}
+
+func (_c *MockEmailOutboxRepository_ClaimDue_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, time11 time.Time, n int)) *MockEmailOutboxRepository_ClaimDue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(time.Time), args[3].(time.Time), args[4].(int))
+	})
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_ClaimDue_Call) Return(outboxEmails []*model.OutboxEmail, err error) *MockEmailOutboxRepository_ClaimDue_Call {
+	_c.Call.Return(outboxEmails, err)
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_ClaimDue_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, time11 time.Time, n int) ([]*model.OutboxEmail, error)) *MockEmailOutboxRepository_ClaimDue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountByStatus provides a mock function for the type MockEmailOutboxRepository
+func (_mock *MockEmailOutboxRepository) CountByStatus(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxStatus model.OutboxStatus) (int, error) {
+	ret := _mock.Called(context1, sQLExecutor, outboxStatus)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByStatus")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, model.OutboxStatus) (int, error)); ok {
+		return returnFunc(context1, sQLExecutor, outboxStatus)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, model.OutboxStatus) int); ok {
+		r0 = returnFunc(context1, sQLExecutor, outboxStatus)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, model.OutboxStatus) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, outboxStatus)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEmailOutboxRepository_CountByStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountByStatus'
+type MockEmailOutboxRepository_CountByStatus_Call struct {
+	*mock.Call
+}
+
+// CountByStatus is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - outboxStatus
+func (_e *MockEmailOutboxRepository_Expecter) CountByStatus(context1 interface{}, sQLExecutor interface{}, outboxStatus interface{}) *MockEmailOutboxRepository_CountByStatus_Call {
+	return &MockEmailOutboxRepository_CountByStatus_Call{Call: _e.mock.On("CountByStatus", context1, sQLExecutor, outboxStatus)}
+}
+
+func (_c *MockEmailOutboxRepository_CountByStatus_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxStatus model.OutboxStatus)) *MockEmailOutboxRepository_CountByStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(model.OutboxStatus))
+	})
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_CountByStatus_Call) Return(n int, err error) *MockEmailOutboxRepository_CountByStatus_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_CountByStatus_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxStatus model.OutboxStatus) (int, error)) *MockEmailOutboxRepository_CountByStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteSentBatch provides a mock function for the type MockEmailOutboxRepository
+func (_mock *MockEmailOutboxRepository) DeleteSentBatch(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error) {
+	ret := _mock.Called(context1, sQLExecutor, time1, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSentBatch")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)); ok {
+		return returnFunc(context1, sQLExecutor, time1, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) int64); ok {
+		r0 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEmailOutboxRepository_DeleteSentBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSentBatch'
+type MockEmailOutboxRepository_DeleteSentBatch_Call struct {
+	*mock.Call
+}
+
+// DeleteSentBatch is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - time1
+//   - n
+func (_e *MockEmailOutboxRepository_Expecter) DeleteSentBatch(context1 interface{}, sQLExecutor interface{}, time1 interface{}, n interface{}) *MockEmailOutboxRepository_DeleteSentBatch_Call {
+	return &MockEmailOutboxRepository_DeleteSentBatch_Call{Call: _e.mock.On("DeleteSentBatch", context1, sQLExecutor, time1, n)}
+}
+
+func (_c *MockEmailOutboxRepository_DeleteSentBatch_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int)) *MockEmailOutboxRepository_DeleteSentBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(time.Time), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_DeleteSentBatch_Call) Return(n int64, err error) *MockEmailOutboxRepository_DeleteSentBatch_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_DeleteSentBatch_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error)) *MockEmailOutboxRepository_DeleteSentBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockEmailOutboxRepository
+func (_mock *MockEmailOutboxRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxEmail *model.OutboxEmail) (int64, error) {
+	ret := _mock.Called(context1, sQLExecutor, outboxEmail)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.OutboxEmail) (int64, error)); ok {
+		return returnFunc(context1, sQLExecutor, outboxEmail)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.OutboxEmail) int64); ok {
+		r0 = returnFunc(context1, sQLExecutor, outboxEmail)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.OutboxEmail) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, outboxEmail)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEmailOutboxRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockEmailOutboxRepository_Save_Call struct {
+	*mock.Call
+}
+
+// Save is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - outboxEmail
+func (_e *MockEmailOutboxRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, outboxEmail interface{}) *MockEmailOutboxRepository_Save_Call {
+	return &MockEmailOutboxRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, outboxEmail)}
+}
+
+func (_c *MockEmailOutboxRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxEmail *model.OutboxEmail)) *MockEmailOutboxRepository_Save_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.OutboxEmail))
+	})
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_Save_Call) Return(n int64, err error) *MockEmailOutboxRepository_Save_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxEmail *model.OutboxEmail) (int64, error)) *MockEmailOutboxRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateDelivery provides a mock function for the type MockEmailOutboxRepository
+func (_mock *MockEmailOutboxRepository) UpdateDelivery(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxEmail *model.OutboxEmail) error {
+	ret := _mock.Called(context1, sQLExecutor, outboxEmail)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDelivery")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.OutboxEmail) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, outboxEmail)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockEmailOutboxRepository_UpdateDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateDelivery'
+type MockEmailOutboxRepository_UpdateDelivery_Call struct {
+	*mock.Call
+}
+
+// UpdateDelivery is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - outboxEmail
+func (_e *MockEmailOutboxRepository_Expecter) UpdateDelivery(context1 interface{}, sQLExecutor interface{}, outboxEmail interface{}) *MockEmailOutboxRepository_UpdateDelivery_Call {
+	return &MockEmailOutboxRepository_UpdateDelivery_Call{Call: _e.mock.On("UpdateDelivery", context1, sQLExecutor, outboxEmail)}
+}
+
+func (_c *MockEmailOutboxRepository_UpdateDelivery_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxEmail *model.OutboxEmail)) *MockEmailOutboxRepository_UpdateDelivery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.OutboxEmail))
+	})
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_UpdateDelivery_Call) Return(err error) *MockEmailOutboxRepository_UpdateDelivery_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockEmailOutboxRepository_UpdateDelivery_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, outboxEmail *model.OutboxEmail) error) *MockEmailOutboxRepository_UpdateDelivery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockManageLinker creates a new instance of MockManageLinker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockManageLinker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockManageLinker {
+	mock := &MockManageLinker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockManageLinker is an autogenerated mock type for the ManageLinker type
+type MockManageLinker struct {
+	mock.Mock
+}
+
+type MockManageLinker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockManageLinker) EXPECT() *MockManageLinker_Expecter {
+	return &MockManageLinker_Expecter{mock: &_m.Mock}
+}
+
+// Link provides a mock function for the type MockManageLinker
+func (_mock *MockManageLinker) Link(n int32) string {
+	ret := _mock.Called(n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Link")
+	}
+
+	var r0 string
+	if returnFunc, ok := ret.Get(0).(func(int32) string); ok {
+		r0 = returnFunc(n)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	return r0
+}
+
+// MockManageLinker_Link_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Link'
+type MockManageLinker_Link_Call struct {
+	*mock.Call
+}
+
+// Link is a helper method to define mock.On call
+//   - n
+func (_e *MockManageLinker_Expecter) Link(n interface{}) *MockManageLinker_Link_Call {
+	return &MockManageLinker_Link_Call{Call: _e.mock.On("Link", n)}
+}
+
+func (_c *MockManageLinker_Link_Call) Run(run func(n int32)) *MockManageLinker_Link_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int32))
+	})
+	return _c
+}
+
+func (_c *MockManageLinker_Link_Call) Return(s string) *MockManageLinker_Link_Call {
+	_c.Call.Return(s)
+	return _c
+}
+
+func (_c *MockManageLinker_Link_Call) RunAndReturn(run func(n int32) string) *MockManageLinker_Link_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// URL provides a mock function for the type MockManageLinker
+func (_mock *MockManageLinker) URL(s string) string {
+	ret := _mock.Called(s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for URL")
+	}
+
+	var r0 string
+	if returnFunc, ok := ret.Get(0).(func(string) string); ok {
+		r0 = returnFunc(s)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	return r0
+}
+
+// MockManageLinker_URL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'URL'
+type MockManageLinker_URL_Call struct {
+	*mock.Call
+}
+
+// URL is a helper method to define mock.On call
+//   - s
+func (_e *MockManageLinker_Expecter) URL(s interface{}) *MockManageLinker_URL_Call {
+	return &MockManageLinker_URL_Call{Call: _e.mock.On("URL", s)}
+}
+
+func (_c *MockManageLinker_URL_Call) Run(run func(s string)) *MockManageLinker_URL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockManageLinker_URL_Call) Return(s string) *MockManageLinker_URL_Call {
+	_c.Call.Return(s)
+	return _c
+}
+
+func (_c *MockManageLinker_URL_Call) RunAndReturn(run func(s string) string) *MockManageLinker_URL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Verify provides a mock function for the type MockManageLinker
+func (_mock *MockManageLinker) Verify(s string, time1 time.Time) (int32, error) {
+	ret := _mock.Called(s, time1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Verify")
+	}
+
+	var r0 int32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, time.Time) (int32, error)); ok {
+		return returnFunc(s, time1)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, time.Time) int32); ok {
+		r0 = returnFunc(s, time1)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = returnFunc(s, time1)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockManageLinker_Verify_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Verify'
+type MockManageLinker_Verify_Call struct {
+	*mock.Call
+}
+
+// Verify is a helper method to define mock.On call
+//   - s
+//   - time1
+func (_e *MockManageLinker_Expecter) Verify(s interface{}, time1 interface{}) *MockManageLinker_Verify_Call {
+	return &MockManageLinker_Verify_Call{Call: _e.mock.On("Verify", s, time1)}
+}
+
+func (_c *MockManageLinker_Verify_Call) Run(run func(s string, time1 time.Time)) *MockManageLinker_Verify_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockManageLinker_Verify_Call) Return(n int32, err error) *MockManageLinker_Verify_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockManageLinker_Verify_Call) RunAndReturn(run func(s string, time1 time.Time) (int32, error)) *MockManageLinker_Verify_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockTokenSigner creates a new instance of MockTokenSigner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockTokenSigner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockTokenSigner {
+	mock := &MockTokenSigner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockTokenSigner is an autogenerated mock type for the TokenSigner type
+type MockTokenSigner struct {
+	mock.Mock
+}
+
+type MockTokenSigner_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockTokenSigner) EXPECT() *MockTokenSigner_Expecter {
+	return &MockTokenSigner_Expecter{mock: &_m.Mock}
+}
+
+// Sign provides a mock function for the type MockTokenSigner
+func (_mock *MockTokenSigner) Sign(claims signedtoken.Claims) string {
+	ret := _mock.Called(claims)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sign")
+	}
+
+	var r0 string
+	if returnFunc, ok := ret.Get(0).(func(signedtoken.Claims) string); ok {
+		r0 = returnFunc(claims)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	return r0
+}
+
+// MockTokenSigner_Sign_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Sign'
+type MockTokenSigner_Sign_Call struct {
+	*mock.Call
+}
+
+// Sign is a helper method to define mock.On call
+//   - claims
+func (_e *MockTokenSigner_Expecter) Sign(claims interface{}) *MockTokenSigner_Sign_Call {
+	return &MockTokenSigner_Sign_Call{Call: _e.mock.On("Sign", claims)}
+}
+
+func (_c *MockTokenSigner_Sign_Call) Run(run func(claims signedtoken.Claims)) *MockTokenSigner_Sign_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(signedtoken.Claims))
+	})
+	return _c
+}
+
+func (_c *MockTokenSigner_Sign_Call) Return(s string) *MockTokenSigner_Sign_Call {
+	_c.Call.Return(s)
+	return _c
+}
+
+func (_c *MockTokenSigner_Sign_Call) RunAndReturn(run func(claims signedtoken.Claims) string) *MockTokenSigner_Sign_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Verify provides a mock function for the type MockTokenSigner
+func (_mock *MockTokenSigner) Verify(s string, time1 time.Time) (signedtoken.Claims, error) {
+	ret := _mock.Called(s, time1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Verify")
+	}
+
+	var r0 signedtoken.Claims
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, time.Time) (signedtoken.Claims, error)); ok {
+		return returnFunc(s, time1)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, time.Time) signedtoken.Claims); ok {
+		r0 = returnFunc(s, time1)
+	} else {
+		r0 = ret.Get(0).(signedtoken.Claims)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = returnFunc(s, time1)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockTokenSigner_Verify_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Verify'
+type MockTokenSigner_Verify_Call struct {
+	*mock.Call
+}
+
+// Verify is a helper method to define mock.On call
+//   - s
+//   - time1
+func (_e *MockTokenSigner_Expecter) Verify(s interface{}, time1 interface{}) *MockTokenSigner_Verify_Call {
+	return &MockTokenSigner_Verify_Call{Call: _e.mock.On("Verify", s, time1)}
+}
+
+func (_c *MockTokenSigner_Verify_Call) Run(run func(s string, time1 time.Time)) *MockTokenSigner_Verify_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockTokenSigner_Verify_Call) Return(claims signedtoken.Claims, err error) *MockTokenSigner_Verify_Call {
+	_c.Call.Return(claims, err)
+	return _c
+}
+
+func (_c *MockTokenSigner_Verify_Call) RunAndReturn(run func(s string, time1 time.Time) (signedtoken.Claims, error)) *MockTokenSigner_Verify_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockWeatherAlertRepository creates a new instance of MockWeatherAlertRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockWeatherAlertRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockWeatherAlertRepository {
+	mock := &MockWeatherAlertRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockWeatherAlertRepository is an autogenerated mock type for the WeatherAlertRepository type
+type MockWeatherAlertRepository struct {
+	mock.Mock
+}
+
+type MockWeatherAlertRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockWeatherAlertRepository) EXPECT() *MockWeatherAlertRepository_Expecter {
+	return &MockWeatherAlertRepository_Expecter{mock: &_m.Mock}
+}
+
+// SaveIfAbsent provides a mock function for the type MockWeatherAlertRepository
+func (_mock *MockWeatherAlertRepository) SaveIfAbsent(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, weatherAlert *model.WeatherAlert) (bool, error) {
+	ret := _mock.Called(context1, sQLExecutor, weatherAlert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveIfAbsent")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.WeatherAlert) (bool, error)); ok {
+		return returnFunc(context1, sQLExecutor, weatherAlert)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.WeatherAlert) bool); ok {
+		r0 = returnFunc(context1, sQLExecutor, weatherAlert)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.WeatherAlert) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, weatherAlert)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWeatherAlertRepository_SaveIfAbsent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveIfAbsent'
+type MockWeatherAlertRepository_SaveIfAbsent_Call struct {
+	*mock.Call
+}
+
+// SaveIfAbsent is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - weatherAlert
+func (_e *MockWeatherAlertRepository_Expecter) SaveIfAbsent(context1 interface{}, sQLExecutor interface{}, weatherAlert interface{}) *MockWeatherAlertRepository_SaveIfAbsent_Call {
+	return &MockWeatherAlertRepository_SaveIfAbsent_Call{Call: _e.mock.On("SaveIfAbsent", context1, sQLExecutor, weatherAlert)}
+}
+
+func (_c *MockWeatherAlertRepository_SaveIfAbsent_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, weatherAlert *model.WeatherAlert)) *MockWeatherAlertRepository_SaveIfAbsent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.WeatherAlert))
+	})
+	return _c
+}
+
+func (_c *MockWeatherAlertRepository_SaveIfAbsent_Call) Return(b bool, err error) *MockWeatherAlertRepository_SaveIfAbsent_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockWeatherAlertRepository_SaveIfAbsent_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, weatherAlert *model.WeatherAlert) (bool, error)) *MockWeatherAlertRepository_SaveIfAbsent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockNotificationDeliveryRepository creates a new instance of MockNotificationDeliveryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockNotificationDeliveryRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockNotificationDeliveryRepository {
+	mock := &MockNotificationDeliveryRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockNotificationDeliveryRepository is an autogenerated mock type for the NotificationDeliveryRepository type
+type MockNotificationDeliveryRepository struct {
+	mock.Mock
+}
+
+type MockNotificationDeliveryRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockNotificationDeliveryRepository) EXPECT() *MockNotificationDeliveryRepository_Expecter {
+	return &MockNotificationDeliveryRepository_Expecter{mock: &_m.Mock}
+}
+
+// DeleteAttemptedBeforeBatch provides a mock function for the type MockNotificationDeliveryRepository
+func (_mock *MockNotificationDeliveryRepository) DeleteAttemptedBeforeBatch(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error) {
+	ret := _mock.Called(context1, sQLExecutor, time1, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAttemptedBeforeBatch")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)); ok {
+		return returnFunc(context1, sQLExecutor, time1, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) int64); ok {
+		r0 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteAttemptedBeforeBatch'
+type MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call struct {
+	*mock.Call
+}
+
+// DeleteAttemptedBeforeBatch is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - time1
+//   - n
+func (_e *MockNotificationDeliveryRepository_Expecter) DeleteAttemptedBeforeBatch(context1 interface{}, sQLExecutor interface{}, time1 interface{}, n interface{}) *MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call {
+	return &MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call{Call: _e.mock.On("DeleteAttemptedBeforeBatch", context1, sQLExecutor, time1, n)}
+}
+
+func (_c *MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int)) *MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(time.Time), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call) Return(n int64, err error) *MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error)) *MockNotificationDeliveryRepository_DeleteAttemptedBeforeBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExistsSent provides a mock function for the type MockNotificationDeliveryRepository
+func (_mock *MockNotificationDeliveryRepository) ExistsSent(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, notificationKind model.NotificationKind, s string) (bool, error) {
+	ret := _mock.Called(context1, sQLExecutor, n, notificationKind, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExistsSent")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, model.NotificationKind, string) (bool, error)); ok {
+		return returnFunc(context1, sQLExecutor, n, notificationKind, s)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, model.NotificationKind, string) bool); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, notificationKind, s)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32, model.NotificationKind, string) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n, notificationKind, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockNotificationDeliveryRepository_ExistsSent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExistsSent'
+type MockNotificationDeliveryRepository_ExistsSent_Call struct {
+	*mock.Call
+}
+
+// ExistsSent is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - notificationKind
+//   - s
+func (_e *MockNotificationDeliveryRepository_Expecter) ExistsSent(context1 interface{}, sQLExecutor interface{}, n interface{}, notificationKind interface{}, s interface{}) *MockNotificationDeliveryRepository_ExistsSent_Call {
+	return &MockNotificationDeliveryRepository_ExistsSent_Call{Call: _e.mock.On("ExistsSent", context1, sQLExecutor, n, notificationKind, s)}
+}
+
+func (_c *MockNotificationDeliveryRepository_ExistsSent_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, notificationKind model.NotificationKind, s string)) *MockNotificationDeliveryRepository_ExistsSent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(model.NotificationKind), args[4].(string))
+	})
+	return _c
+}
+
+func (_c *MockNotificationDeliveryRepository_ExistsSent_Call) Return(b bool, err error) *MockNotificationDeliveryRepository_ExistsSent_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockNotificationDeliveryRepository_ExistsSent_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, notificationKind model.NotificationKind, s string) (bool, error)) *MockNotificationDeliveryRepository_ExistsSent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockNotificationDeliveryRepository
+func (_mock *MockNotificationDeliveryRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, notificationDelivery *model.NotificationDelivery) (int64, error) {
+	ret := _mock.Called(context1, sQLExecutor, notificationDelivery)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.NotificationDelivery) (int64, error)); ok {
+		return returnFunc(context1, sQLExecutor, notificationDelivery)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.NotificationDelivery) int64); ok {
+		r0 = returnFunc(context1, sQLExecutor, notificationDelivery)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.NotificationDelivery) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, notificationDelivery)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockNotificationDeliveryRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockNotificationDeliveryRepository_Save_Call struct {
+	*mock.Call
+}
+
+// Save is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - notificationDelivery
+func (_e *MockNotificationDeliveryRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, notificationDelivery interface{}) *MockNotificationDeliveryRepository_Save_Call {
+	return &MockNotificationDeliveryRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, notificationDelivery)}
+}
+
+func (_c *MockNotificationDeliveryRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, notificationDelivery *model.NotificationDelivery)) *MockNotificationDeliveryRepository_Save_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.NotificationDelivery))
+	})
+	return _c
+}
+
+func (_c *MockNotificationDeliveryRepository_Save_Call) Return(n int64, err error) *MockNotificationDeliveryRepository_Save_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockNotificationDeliveryRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, notificationDelivery *model.NotificationDelivery) (int64, error)) *MockNotificationDeliveryRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockSubscriberRepository creates a new instance of MockSubscriberRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSubscriberRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSubscriberRepository {
+	mock := &MockSubscriberRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSubscriberRepository is an autogenerated mock type for the SubscriberRepository type
+type MockSubscriberRepository struct {
+	mock.Mock
+}
+
+type MockSubscriberRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSubscriberRepository) EXPECT() *MockSubscriberRepository_Expecter {
+	return &MockSubscriberRepository_Expecter{mock: &_m.Mock}
+}
+
+// DeleteOrphanedBatch provides a mock function for the type MockSubscriberRepository
+func (_mock *MockSubscriberRepository) DeleteOrphanedBatch(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error) {
+	ret := _mock.Called(context1, sQLExecutor, time1, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteOrphanedBatch")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)); ok {
+		return returnFunc(context1, sQLExecutor, time1, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) int64); ok {
+		r0 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriberRepository_DeleteOrphanedBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteOrphanedBatch'
+type MockSubscriberRepository_DeleteOrphanedBatch_Call struct {
+	*mock.Call
+}
+
+// DeleteOrphanedBatch is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - time1
+//   - n
+func (_e *MockSubscriberRepository_Expecter) DeleteOrphanedBatch(context1 interface{}, sQLExecutor interface{}, time1 interface{}, n interface{}) *MockSubscriberRepository_DeleteOrphanedBatch_Call {
+	return &MockSubscriberRepository_DeleteOrphanedBatch_Call{Call: _e.mock.On("DeleteOrphanedBatch", context1, sQLExecutor, time1, n)}
+}
+
+func (_c *MockSubscriberRepository_DeleteOrphanedBatch_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int)) *MockSubscriberRepository_DeleteOrphanedBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(time.Time), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockSubscriberRepository_DeleteOrphanedBatch_Call) Return(n int64, err error) *MockSubscriberRepository_DeleteOrphanedBatch_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockSubscriberRepository_DeleteOrphanedBatch_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error)) *MockSubscriberRepository_DeleteOrphanedBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByEmail provides a mock function for the type MockSubscriberRepository
+func (_mock *MockSubscriberRepository) FindByEmail(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Subscriber, error) {
+	ret := _mock.Called(context1, sQLExecutor, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByEmail")
+	}
+
+	var r0 *model.Subscriber
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) (*model.Subscriber, error)); ok {
+		return returnFunc(context1, sQLExecutor, s)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) *model.Subscriber); ok {
+		r0 = returnFunc(context1, sQLExecutor, s)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Subscriber)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, string) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriberRepository_FindByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByEmail'
+type MockSubscriberRepository_FindByEmail_Call struct {
+	*mock.Call
+}
+
+// FindByEmail is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - s
+func (_e *MockSubscriberRepository_Expecter) FindByEmail(context1 interface{}, sQLExecutor interface{}, s interface{}) *MockSubscriberRepository_FindByEmail_Call {
+	return &MockSubscriberRepository_FindByEmail_Call{Call: _e.mock.On("FindByEmail", context1, sQLExecutor, s)}
+}
+
+func (_c *MockSubscriberRepository_FindByEmail_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string)) *MockSubscriberRepository_FindByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockSubscriberRepository_FindByEmail_Call) Return(subscriber *model.Subscriber, err error) *MockSubscriberRepository_FindByEmail_Call {
+	_c.Call.Return(subscriber, err)
+	return _c
+}
+
+func (_c *MockSubscriberRepository_FindByEmail_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Subscriber, error)) *MockSubscriberRepository_FindByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindById provides a mock function for the type MockSubscriberRepository
+func (_mock *MockSubscriberRepository) FindById(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Subscriber, error) {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindById")
+	}
+
+	var r0 *model.Subscriber
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) (*model.Subscriber, error)); ok {
+		return returnFunc(context1, sQLExecutor, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) *model.Subscriber); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Subscriber)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriberRepository_FindById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindById'
+type MockSubscriberRepository_FindById_Call struct {
+	*mock.Call
+}
+
+// FindById is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockSubscriberRepository_Expecter) FindById(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockSubscriberRepository_FindById_Call {
+	return &MockSubscriberRepository_FindById_Call{Call: _e.mock.On("FindById", context1, sQLExecutor, n)}
+}
+
+func (_c *MockSubscriberRepository_FindById_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockSubscriberRepository_FindById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockSubscriberRepository_FindById_Call) Return(subscriber *model.Subscriber, err error) *MockSubscriberRepository_FindById_Call {
+	_c.Call.Return(subscriber, err)
+	return _c
+}
+
+func (_c *MockSubscriberRepository_FindById_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Subscriber, error)) *MockSubscriberRepository_FindById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockSubscriberRepository
+func (_mock *MockSubscriberRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscriber *model.Subscriber) (int32, error) {
+	ret := _mock.Called(context1, sQLExecutor, subscriber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 int32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Subscriber) (int32, error)); ok {
+		return returnFunc(context1, sQLExecutor, subscriber)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Subscriber) int32); ok {
+		r0 = returnFunc(context1, sQLExecutor, subscriber)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Subscriber) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, subscriber)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriberRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockSubscriberRepository_Save_Call struct {
+	*mock.Call
+}
+
+// Save is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - subscriber
+func (_e *MockSubscriberRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, subscriber interface{}) *MockSubscriberRepository_Save_Call {
+	return &MockSubscriberRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, subscriber)}
+}
+
+func (_c *MockSubscriberRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscriber *model.Subscriber)) *MockSubscriberRepository_Save_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Subscriber))
+	})
+	return _c
+}
+
+func (_c *MockSubscriberRepository_Save_Call) Return(n int32, err error) *MockSubscriberRepository_Save_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockSubscriberRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscriber *model.Subscriber) (int32, error)) *MockSubscriberRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdatePreferences provides a mock function for the type MockSubscriberRepository
+func (_mock *MockSubscriberRepository) UpdatePreferences(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, preferences model.Preferences) error {
+	ret := _mock.Called(context1, sQLExecutor, n, preferences)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePreferences")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, model.Preferences) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, preferences)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSubscriberRepository_UpdatePreferences_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePreferences'
+type MockSubscriberRepository_UpdatePreferences_Call struct {
+	*mock.Call
+}
+
+// UpdatePreferences is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - preferences
+func (_e *MockSubscriberRepository_Expecter) UpdatePreferences(context1 interface{}, sQLExecutor interface{}, n interface{}, preferences interface{}) *MockSubscriberRepository_UpdatePreferences_Call {
+	return &MockSubscriberRepository_UpdatePreferences_Call{Call: _e.mock.On("UpdatePreferences", context1, sQLExecutor, n, preferences)}
+}
+
+func (_c *MockSubscriberRepository_UpdatePreferences_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, preferences model.Preferences)) *MockSubscriberRepository_UpdatePreferences_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(model.Preferences))
+	})
+	return _c
+}
+
+func (_c *MockSubscriberRepository_UpdatePreferences_Call) Return(err error) *MockSubscriberRepository_UpdatePreferences_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSubscriberRepository_UpdatePreferences_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, preferences model.Preferences) error) *MockSubscriberRepository_UpdatePreferences_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockSubscriptionRepository creates a new instance of MockSubscriptionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSubscriptionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSubscriptionRepository {
+	mock := &MockSubscriptionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSubscriptionRepository is an autogenerated mock type for the SubscriptionRepository type
+type MockSubscriptionRepository struct {
+	mock.Mock
+}
+
+type MockSubscriptionRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSubscriptionRepository) EXPECT() *MockSubscriptionRepository_Expecter {
+	return &MockSubscriptionRepository_Expecter{mock: &_m.Mock}
+}
+
+// DeleteAbandonedPendingBatch provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) DeleteAbandonedPendingBatch(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error) {
+	ret := _mock.Called(context1, sQLExecutor, time1, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAbandonedPendingBatch")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)); ok {
+		return returnFunc(context1, sQLExecutor, time1, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) int64); ok {
+		r0 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteAbandonedPendingBatch'
+type MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call struct {
+	*mock.Call
+}
+
+// DeleteAbandonedPendingBatch is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - time1
+//   - n
+func (_e *MockSubscriptionRepository_Expecter) DeleteAbandonedPendingBatch(context1 interface{}, sQLExecutor interface{}, time1 interface{}, n interface{}) *MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call {
+	return &MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call{Call: _e.mock.On("DeleteAbandonedPendingBatch", context1, sQLExecutor, time1, n)}
+}
+
+func (_c *MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int)) *MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(time.Time), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call) Return(n int64, err error) *MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error)) *MockSubscriptionRepository_DeleteAbandonedPendingBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteById provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) DeleteById(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) error {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteById")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSubscriptionRepository_DeleteById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteById'
+type MockSubscriptionRepository_DeleteById_Call struct {
+	*mock.Call
+}
+
+// DeleteById is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockSubscriptionRepository_Expecter) DeleteById(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockSubscriptionRepository_DeleteById_Call {
+	return &MockSubscriptionRepository_DeleteById_Call{Call: _e.mock.On("DeleteById", context1, sQLExecutor, n)}
+}
+
+func (_c *MockSubscriptionRepository_DeleteById_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockSubscriptionRepository_DeleteById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_DeleteById_Call) Return(err error) *MockSubscriptionRepository_DeleteById_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_DeleteById_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) error) *MockSubscriptionRepository_DeleteById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAllByFrequencyAndConfirmedStatus provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) FindAllByFrequencyAndConfirmedStatus(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, frequency model.Frequency) ([]*model.Subscription, error) {
+	ret := _mock.Called(context1, sQLExecutor, frequency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAllByFrequencyAndConfirmedStatus")
+	}
+
+	var r0 []*model.Subscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, model.Frequency) ([]*model.Subscription, error)); ok {
+		return returnFunc(context1, sQLExecutor, frequency)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, model.Frequency) []*model.Subscription); ok {
+		r0 = returnFunc(context1, sQLExecutor, frequency)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Subscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, model.Frequency) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, frequency)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAllByFrequencyAndConfirmedStatus'
+type MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call struct {
+	*mock.Call
+}
+
+// FindAllByFrequencyAndConfirmedStatus is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - frequency
+func (_e *MockSubscriptionRepository_Expecter) FindAllByFrequencyAndConfirmedStatus(context1 interface{}, sQLExecutor interface{}, frequency interface{}) *MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call {
+	return &MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call{Call: _e.mock.On("FindAllByFrequencyAndConfirmedStatus", context1, sQLExecutor, frequency)}
+}
+
+func (_c *MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, frequency model.Frequency)) *MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(model.Frequency))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call) Return(subscriptions []*model.Subscription, err error) *MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call {
+	_c.Call.Return(subscriptions, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, frequency model.Frequency) ([]*model.Subscription, error)) *MockSubscriptionRepository_FindAllByFrequencyAndConfirmedStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAllBySubscriberId provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) FindAllBySubscriberId(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) ([]*model.Subscription, error) {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAllBySubscriberId")
+	}
+
+	var r0 []*model.Subscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) ([]*model.Subscription, error)); ok {
+		return returnFunc(context1, sQLExecutor, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) []*model.Subscription); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Subscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_FindAllBySubscriberId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAllBySubscriberId'
+type MockSubscriptionRepository_FindAllBySubscriberId_Call struct {
+	*mock.Call
+}
+
+// FindAllBySubscriberId is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockSubscriptionRepository_Expecter) FindAllBySubscriberId(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockSubscriptionRepository_FindAllBySubscriberId_Call {
+	return &MockSubscriptionRepository_FindAllBySubscriberId_Call{Call: _e.mock.On("FindAllBySubscriberId", context1, sQLExecutor, n)}
+}
+
+func (_c *MockSubscriptionRepository_FindAllBySubscriberId_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockSubscriptionRepository_FindAllBySubscriberId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindAllBySubscriberId_Call) Return(subscriptions []*model.Subscription, err error) *MockSubscriptionRepository_FindAllBySubscriberId_Call {
+	_c.Call.Return(subscriptions, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindAllBySubscriberId_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) ([]*model.Subscription, error)) *MockSubscriptionRepository_FindAllBySubscriberId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAllWithAqiThresholdAndConfirmedStatus provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) FindAllWithAqiThresholdAndConfirmedStatus(context1 context.Context, sQLExecutor sqlutil.SQLExecutor) ([]*model.Subscription, error) {
+	ret := _mock.Called(context1, sQLExecutor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAllWithAqiThresholdAndConfirmedStatus")
+	}
+
+	var r0 []*model.Subscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor) ([]*model.Subscription, error)); ok {
+		return returnFunc(context1, sQLExecutor)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor) []*model.Subscription); ok {
+		r0 = returnFunc(context1, sQLExecutor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Subscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor) error); ok {
+		r1 = returnFunc(context1, sQLExecutor)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAllWithAqiThresholdAndConfirmedStatus'
+type MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call struct {
+	*mock.Call
+}
+
+// FindAllWithAqiThresholdAndConfirmedStatus is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+func (_e *MockSubscriptionRepository_Expecter) FindAllWithAqiThresholdAndConfirmedStatus(context1 interface{}, sQLExecutor interface{}) *MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call {
+	return &MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call{Call: _e.mock.On("FindAllWithAqiThresholdAndConfirmedStatus", context1, sQLExecutor)}
+}
+
+func (_c *MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor)) *MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call) Return(subscriptions []*model.Subscription, err error) *MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call {
+	_c.Call.Return(subscriptions, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor) ([]*model.Subscription, error)) *MockSubscriptionRepository_FindAllWithAqiThresholdAndConfirmedStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindById provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) FindById(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Subscription, error) {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindById")
+	}
+
+	var r0 *model.Subscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) (*model.Subscription, error)); ok {
+		return returnFunc(context1, sQLExecutor, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) *model.Subscription); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Subscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_FindById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindById'
+type MockSubscriptionRepository_FindById_Call struct {
+	*mock.Call
+}
+
+// FindById is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockSubscriptionRepository_Expecter) FindById(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockSubscriptionRepository_FindById_Call {
+	return &MockSubscriptionRepository_FindById_Call{Call: _e.mock.On("FindById", context1, sQLExecutor, n)}
+}
+
+func (_c *MockSubscriptionRepository_FindById_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockSubscriptionRepository_FindById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindById_Call) Return(subscription *model.Subscription, err error) *MockSubscriptionRepository_FindById_Call {
+	_c.Call.Return(subscription, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindById_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Subscription, error)) *MockSubscriptionRepository_FindById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindBySubscriberIdAndLocationId provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) FindBySubscriberIdAndLocationId(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, n1 int32) (*model.Subscription, error) {
+	ret := _mock.Called(context1, sQLExecutor, n, n1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindBySubscriberIdAndLocationId")
+	}
+
+	var r0 *model.Subscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, int32) (*model.Subscription, error)); ok {
+		return returnFunc(context1, sQLExecutor, n, n1)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, int32) *model.Subscription); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, n1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Subscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n, n1)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindBySubscriberIdAndLocationId'
+type MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call struct {
+	*mock.Call
+}
+
+// FindBySubscriberIdAndLocationId is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - n1
+func (_e *MockSubscriptionRepository_Expecter) FindBySubscriberIdAndLocationId(context1 interface{}, sQLExecutor interface{}, n interface{}, n1 interface{}) *MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call {
+	return &MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call{Call: _e.mock.On("FindBySubscriberIdAndLocationId", context1, sQLExecutor, n, n1)}
+}
+
+func (_c *MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, n1 int32)) *MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(int32))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call) Return(subscription *model.Subscription, err error) *MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call {
+	_c.Call.Return(subscription, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, n1 int32) (*model.Subscription, error)) *MockSubscriptionRepository_FindBySubscriberIdAndLocationId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindNotificationTargetsPage provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) FindNotificationTargetsPage(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, frequency model.Frequency, n int32, n1 int) ([]*model.NotificationTarget, error) {
+	ret := _mock.Called(context1, sQLExecutor, frequency, n, n1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindNotificationTargetsPage")
+	}
+
+	var r0 []*model.NotificationTarget
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, model.Frequency, int32, int) ([]*model.NotificationTarget, error)); ok {
+		return returnFunc(context1, sQLExecutor, frequency, n, n1)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, model.Frequency, int32, int) []*model.NotificationTarget); ok {
+		r0 = returnFunc(context1, sQLExecutor, frequency, n, n1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.NotificationTarget)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, model.Frequency, int32, int) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, frequency, n, n1)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_FindNotificationTargetsPage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindNotificationTargetsPage'
+type MockSubscriptionRepository_FindNotificationTargetsPage_Call struct {
+	*mock.Call
+}
+
+// FindNotificationTargetsPage is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - frequency
+//   - n
+//   - n1
+func (_e *MockSubscriptionRepository_Expecter) FindNotificationTargetsPage(context1 interface{}, sQLExecutor interface{}, frequency interface{}, n interface{}, n1 interface{}) *MockSubscriptionRepository_FindNotificationTargetsPage_Call {
+	return &MockSubscriptionRepository_FindNotificationTargetsPage_Call{Call: _e.mock.On("FindNotificationTargetsPage", context1, sQLExecutor, frequency, n, n1)}
+}
+
+func (_c *MockSubscriptionRepository_FindNotificationTargetsPage_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, frequency model.Frequency, n int32, n1 int)) *MockSubscriptionRepository_FindNotificationTargetsPage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(model.Frequency), args[3].(int32), args[4].(int))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindNotificationTargetsPage_Call) Return(notificationTargets []*model.NotificationTarget, err error) *MockSubscriptionRepository_FindNotificationTargetsPage_Call {
+	_c.Call.Return(notificationTargets, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_FindNotificationTargetsPage_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, frequency model.Frequency, n int32, n1 int) ([]*model.NotificationTarget, error)) *MockSubscriptionRepository_FindNotificationTargetsPage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscription *model.Subscription) (int32, error) {
+	ret := _mock.Called(context1, sQLExecutor, subscription)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 int32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Subscription) (int32, error)); ok {
+		return returnFunc(context1, sQLExecutor, subscription)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Subscription) int32); ok {
+		r0 = returnFunc(context1, sQLExecutor, subscription)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Subscription) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, subscription)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockSubscriptionRepository_Save_Call struct {
+	*mock.Call
+}
+
+// Save is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - subscription
+func (_e *MockSubscriptionRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, subscription interface{}) *MockSubscriptionRepository_Save_Call {
+	return &MockSubscriptionRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, subscription)}
+}
+
+func (_c *MockSubscriptionRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscription *model.Subscription)) *MockSubscriptionRepository_Save_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Subscription))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_Save_Call) Return(n int32, err error) *MockSubscriptionRepository_Save_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscription *model.Subscription) (int32, error)) *MockSubscriptionRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Update provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) Update(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscription *model.Subscription) (*model.Subscription, error) {
+	ret := _mock.Called(context1, sQLExecutor, subscription)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 *model.Subscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Subscription) (*model.Subscription, error)); ok {
+		return returnFunc(context1, sQLExecutor, subscription)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Subscription) *model.Subscription); ok {
+		r0 = returnFunc(context1, sQLExecutor, subscription)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Subscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Subscription) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, subscription)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type MockSubscriptionRepository_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - subscription
+func (_e *MockSubscriptionRepository_Expecter) Update(context1 interface{}, sQLExecutor interface{}, subscription interface{}) *MockSubscriptionRepository_Update_Call {
+	return &MockSubscriptionRepository_Update_Call{Call: _e.mock.On("Update", context1, sQLExecutor, subscription)}
+}
+
+func (_c *MockSubscriptionRepository_Update_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscription *model.Subscription)) *MockSubscriptionRepository_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Subscription))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_Update_Call) Return(subscription *model.Subscription, err error) *MockSubscriptionRepository_Update_Call {
+	_c.Call.Return(subscription, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_Update_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, subscription *model.Subscription) (*model.Subscription, error)) *MockSubscriptionRepository_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateAqiAlertActive provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) UpdateAqiAlertActive(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, b bool) error {
+	ret := _mock.Called(context1, sQLExecutor, n, b)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAqiAlertActive")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, bool) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, b)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSubscriptionRepository_UpdateAqiAlertActive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateAqiAlertActive'
+type MockSubscriptionRepository_UpdateAqiAlertActive_Call struct {
+	*mock.Call
+}
+
+// UpdateAqiAlertActive is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - b
+func (_e *MockSubscriptionRepository_Expecter) UpdateAqiAlertActive(context1 interface{}, sQLExecutor interface{}, n interface{}, b interface{}) *MockSubscriptionRepository_UpdateAqiAlertActive_Call {
+	return &MockSubscriptionRepository_UpdateAqiAlertActive_Call{Call: _e.mock.On("UpdateAqiAlertActive", context1, sQLExecutor, n, b)}
+}
+
+func (_c *MockSubscriptionRepository_UpdateAqiAlertActive_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, b bool)) *MockSubscriptionRepository_UpdateAqiAlertActive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(bool))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_UpdateAqiAlertActive_Call) Return(err error) *MockSubscriptionRepository_UpdateAqiAlertActive_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_UpdateAqiAlertActive_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, b bool) error) *MockSubscriptionRepository_UpdateAqiAlertActive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateConditionActive provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) UpdateConditionActive(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, b bool) error {
+	ret := _mock.Called(context1, sQLExecutor, n, b)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateConditionActive")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, bool) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, b)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSubscriptionRepository_UpdateConditionActive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateConditionActive'
+type MockSubscriptionRepository_UpdateConditionActive_Call struct {
+	*mock.Call
+}
+
+// UpdateConditionActive is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - b
+func (_e *MockSubscriptionRepository_Expecter) UpdateConditionActive(context1 interface{}, sQLExecutor interface{}, n interface{}, b interface{}) *MockSubscriptionRepository_UpdateConditionActive_Call {
+	return &MockSubscriptionRepository_UpdateConditionActive_Call{Call: _e.mock.On("UpdateConditionActive", context1, sQLExecutor, n, b)}
+}
+
+func (_c *MockSubscriptionRepository_UpdateConditionActive_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, b bool)) *MockSubscriptionRepository_UpdateConditionActive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(bool))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_UpdateConditionActive_Call) Return(err error) *MockSubscriptionRepository_UpdateConditionActive_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_UpdateConditionActive_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, b bool) error) *MockSubscriptionRepository_UpdateConditionActive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateLastDeliveredAt provides a mock function for the type MockSubscriptionRepository
+func (_mock *MockSubscriptionRepository) UpdateLastDeliveredAt(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, time1 time.Time, time11 time.Time) (bool, error) {
+	ret := _mock.Called(context1, sQLExecutor, n, time1, time11)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLastDeliveredAt")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, time.Time, time.Time) (bool, error)); ok {
+		return returnFunc(context1, sQLExecutor, n, time1, time11)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, time.Time, time.Time) bool); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, time1, time11)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32, time.Time, time.Time) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n, time1, time11)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionRepository_UpdateLastDeliveredAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateLastDeliveredAt'
+type MockSubscriptionRepository_UpdateLastDeliveredAt_Call struct {
+	*mock.Call
+}
+
+// UpdateLastDeliveredAt is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - time1
+//   - time11
+func (_e *MockSubscriptionRepository_Expecter) UpdateLastDeliveredAt(context1 interface{}, sQLExecutor interface{}, n interface{}, time1 interface{}, time11 interface{}) *MockSubscriptionRepository_UpdateLastDeliveredAt_Call {
+	return &MockSubscriptionRepository_UpdateLastDeliveredAt_Call{Call: _e.mock.On("UpdateLastDeliveredAt", context1, sQLExecutor, n, time1, time11)}
+}
+
+func (_c *MockSubscriptionRepository_UpdateLastDeliveredAt_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, time1 time.Time, time11 time.Time)) *MockSubscriptionRepository_UpdateLastDeliveredAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(time.Time), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_UpdateLastDeliveredAt_Call) Return(b bool, err error) *MockSubscriptionRepository_UpdateLastDeliveredAt_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockSubscriptionRepository_UpdateLastDeliveredAt_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, time1 time.Time, time11 time.Time) (bool, error)) *MockSubscriptionRepository_UpdateLastDeliveredAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockSubscriptionConditionRepository creates a new instance of MockSubscriptionConditionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSubscriptionConditionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSubscriptionConditionRepository {
+	mock := &MockSubscriptionConditionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSubscriptionConditionRepository is an autogenerated mock type for the SubscriptionConditionRepository type
+type MockSubscriptionConditionRepository struct {
+	mock.Mock
+}
+
+type MockSubscriptionConditionRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSubscriptionConditionRepository) EXPECT() *MockSubscriptionConditionRepository_Expecter {
+	return &MockSubscriptionConditionRepository_Expecter{mock: &_m.Mock}
+}
+
+// DeleteAllBySubscriptionId provides a mock function for the type MockSubscriptionConditionRepository
+func (_mock *MockSubscriptionConditionRepository) DeleteAllBySubscriptionId(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) error {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAllBySubscriptionId")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteAllBySubscriptionId'
+type MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call struct {
+	*mock.Call
+}
+
+// DeleteAllBySubscriptionId is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockSubscriptionConditionRepository_Expecter) DeleteAllBySubscriptionId(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call {
+	return &MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call{Call: _e.mock.On("DeleteAllBySubscriptionId", context1, sQLExecutor, n)}
+}
+
+func (_c *MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call) Return(err error) *MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) error) *MockSubscriptionConditionRepository_DeleteAllBySubscriptionId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAllBySubscriptionId provides a mock function for the type MockSubscriptionConditionRepository
+func (_mock *MockSubscriptionConditionRepository) FindAllBySubscriptionId(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) ([]model.Condition, error) {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAllBySubscriptionId")
+	}
+
+	var r0 []model.Condition
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) ([]model.Condition, error)); ok {
+		return returnFunc(context1, sQLExecutor, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) []model.Condition); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Condition)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAllBySubscriptionId'
+type MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call struct {
+	*mock.Call
+}
+
+// FindAllBySubscriptionId is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockSubscriptionConditionRepository_Expecter) FindAllBySubscriptionId(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call {
+	return &MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call{Call: _e.mock.On("FindAllBySubscriptionId", context1, sQLExecutor, n)}
+}
+
+func (_c *MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call) Return(conditions []model.Condition, err error) *MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call {
+	_c.Call.Return(conditions, err)
+	return _c
+}
+
+func (_c *MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) ([]model.Condition, error)) *MockSubscriptionConditionRepository_FindAllBySubscriptionId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockSubscriptionConditionRepository
+func (_mock *MockSubscriptionConditionRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, condition *model.Condition) (int32, error) {
+	ret := _mock.Called(context1, sQLExecutor, condition)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 int32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Condition) (int32, error)); ok {
+		return returnFunc(context1, sQLExecutor, condition)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Condition) int32); ok {
+		r0 = returnFunc(context1, sQLExecutor, condition)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Condition) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, condition)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSubscriptionConditionRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockSubscriptionConditionRepository_Save_Call struct {
+	*mock.Call
+}
+
+// Save is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - condition
+func (_e *MockSubscriptionConditionRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, condition interface{}) *MockSubscriptionConditionRepository_Save_Call {
+	return &MockSubscriptionConditionRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, condition)}
+}
+
+func (_c *MockSubscriptionConditionRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, condition *model.Condition)) *MockSubscriptionConditionRepository_Save_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Condition))
+	})
+	return _c
+}
+
+func (_c *MockSubscriptionConditionRepository_Save_Call) Return(n int32, err error) *MockSubscriptionConditionRepository_Save_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockSubscriptionConditionRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, condition *model.Condition) (int32, error)) *MockSubscriptionConditionRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockTokenRepository creates a new instance of MockTokenRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockTokenRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockTokenRepository {
+	mock := &MockTokenRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockTokenRepository is an autogenerated mock type for the TokenRepository type
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+type MockTokenRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockTokenRepository) EXPECT() *MockTokenRepository_Expecter {
+	return &MockTokenRepository_Expecter{mock: &_m.Mock}
+}
+
+// CountBySubscriptionIdAndTypeCreatedAfter provides a mock function for the type MockTokenRepository
+func (_mock *MockTokenRepository) CountBySubscriptionIdAndTypeCreatedAfter(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, tokenType model.TokenType, time1 time.Time) (int, error) {
+	ret := _mock.Called(context1, sQLExecutor, n, tokenType, time1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetCurrentWeather")
+		panic("no return value specified for CountBySubscriptionIdAndTypeCreatedAfter")
 	}
 
-	var r0 *model.WeatherWithLocation
+	var r0 int
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string) (*model.WeatherWithLocation, error)); ok {
-		return returnFunc(s)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, model.TokenType, time.Time) (int, error)); ok {
+		return returnFunc(context1, sQLExecutor, n, tokenType, time1)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string) *model.WeatherWithLocation); ok {
-		r0 = returnFunc(s)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, model.TokenType, time.Time) int); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, tokenType, time1)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.WeatherWithLocation)
-		}
+		r0 = ret.Get(0).(int)
 	}
-	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
-		r1 = returnFunc(s)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32, model.TokenType, time.Time) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n, tokenType, time1)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockWeatherProvider_GetCurrentWeather_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCurrentWeather'
-type MockWeatherProvider_GetCurrentWeather_Call struct {
+// MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountBySubscriptionIdAndTypeCreatedAfter'
+type MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call struct {
 	*mock.Call
 }
 
-// GetCurrentWeather is a helper method to define mock.On call
-//   - s
-func (_e *MockWeatherProvider_Expecter) GetCurrentWeather(s interface{}) *MockWeatherProvider_GetCurrentWeather_Call {
-	return &MockWeatherProvider_GetCurrentWeather_Call{Call: _e.mock.On("GetCurrentWeather", s)}
+// CountBySubscriptionIdAndTypeCreatedAfter is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - tokenType
+//   - time1
+func (_e *MockTokenRepository_Expecter) CountBySubscriptionIdAndTypeCreatedAfter(context1 interface{}, sQLExecutor interface{}, n interface{}, tokenType interface{}, time1 interface{}) *MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call {
+	return &MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call{Call: _e.mock.On("CountBySubscriptionIdAndTypeCreatedAfter", context1, sQLExecutor, n, tokenType, time1)}
 }
 
-func (_c *MockWeatherProvider_GetCurrentWeather_Call) Run(run func(s string)) *MockWeatherProvider_GetCurrentWeather_Call {
+func (_c *MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, tokenType model.TokenType, time1 time.Time)) *MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(model.TokenType), args[4].(time.Time))
 	})
 	return _c
 }
 
-func (_c *MockWeatherProvider_GetCurrentWeather_Call) Return(weatherWithLocation *model.WeatherWithLocation, err error) *MockWeatherProvider_GetCurrentWeather_Call {
-	_c.Call.Return(weatherWithLocation, err)
+func (_c *MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call) Return(n int, err error) *MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *MockWeatherProvider_GetCurrentWeather_Call) RunAndReturn(run func(s string) (*model.WeatherWithLocation, error)) *MockWeatherProvider_GetCurrentWeather_Call {
+func (_c *MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, tokenType model.TokenType, time1 time.Time) (int, error)) *MockTokenRepository_CountBySubscriptionIdAndTypeCreatedAfter_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NewMockLocationRepository creates a new instance of MockLocationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func NewMockLocationRepository(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *MockLocationRepository {
-	mock := &MockLocationRepository{}
-	mock.Mock.Test(t)
+// DeleteExpiredBatch provides a mock function for the type MockTokenRepository
+func (_mock *MockTokenRepository) DeleteExpiredBatch(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error) {
+	ret := _mock.Called(context1, sQLExecutor, time1, n)
 
-	t.Cleanup(func() { mock.AssertExpectations(t) })
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredBatch")
+	}
 
-	return mock
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)); ok {
+		return returnFunc(context1, sQLExecutor, time1, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) int64); ok {
+		r0 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, time.Time, int) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, time1, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockLocationRepository is an autogenerated mock type for the LocationRepository type
-type MockLocationRepository struct {
-	mock.Mock
+// MockTokenRepository_DeleteExpiredBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteExpiredBatch'
+type MockTokenRepository_DeleteExpiredBatch_Call struct {
+	*mock.Call
 }
 
-type MockLocationRepository_Expecter struct {
-	mock *mock.Mock
+// DeleteExpiredBatch is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - time1
+//   - n
+func (_e *MockTokenRepository_Expecter) DeleteExpiredBatch(context1 interface{}, sQLExecutor interface{}, time1 interface{}, n interface{}) *MockTokenRepository_DeleteExpiredBatch_Call {
+	return &MockTokenRepository_DeleteExpiredBatch_Call{Call: _e.mock.On("DeleteExpiredBatch", context1, sQLExecutor, time1, n)}
 }
 
-func (_m *MockLocationRepository) EXPECT() *MockLocationRepository_Expecter {
-	return &MockLocationRepository_Expecter{mock: &_m.Mock}
+func (_c *MockTokenRepository_DeleteExpiredBatch_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int)) *MockTokenRepository_DeleteExpiredBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(time.Time), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockTokenRepository_DeleteExpiredBatch_Call) Return(n int64, err error) *MockTokenRepository_DeleteExpiredBatch_Call {
+	_c.Call.Return(n, err)
+	return _c
 }
 
-// FindByName provides a mock function for the type MockLocationRepository
-func (_mock *MockLocationRepository) FindByName(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Location, error) {
+func (_c *MockTokenRepository_DeleteExpiredBatch_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, time1 time.Time, n int) (int64, error)) *MockTokenRepository_DeleteExpiredBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindBySubscriptionIdAndType provides a mock function for the type MockTokenRepository
+func (_mock *MockTokenRepository) FindBySubscriptionIdAndType(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, tokenType model.TokenType) (*model.Token, error) {
+	ret := _mock.Called(context1, sQLExecutor, n, tokenType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindBySubscriptionIdAndType")
+	}
+
+	var r0 *model.Token
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, model.TokenType) (*model.Token, error)); ok {
+		return returnFunc(context1, sQLExecutor, n, tokenType)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32, model.TokenType) *model.Token); ok {
+		r0 = returnFunc(context1, sQLExecutor, n, tokenType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Token)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32, model.TokenType) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n, tokenType)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockTokenRepository_FindBySubscriptionIdAndType_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindBySubscriptionIdAndType'
+type MockTokenRepository_FindBySubscriptionIdAndType_Call struct {
+	*mock.Call
+}
+
+// FindBySubscriptionIdAndType is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+//   - tokenType
+func (_e *MockTokenRepository_Expecter) FindBySubscriptionIdAndType(context1 interface{}, sQLExecutor interface{}, n interface{}, tokenType interface{}) *MockTokenRepository_FindBySubscriptionIdAndType_Call {
+	return &MockTokenRepository_FindBySubscriptionIdAndType_Call{Call: _e.mock.On("FindBySubscriptionIdAndType", context1, sQLExecutor, n, tokenType)}
+}
+
+func (_c *MockTokenRepository_FindBySubscriptionIdAndType_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, tokenType model.TokenType)) *MockTokenRepository_FindBySubscriptionIdAndType_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32), args[3].(model.TokenType))
+	})
+	return _c
+}
+
+func (_c *MockTokenRepository_FindBySubscriptionIdAndType_Call) Return(token *model.Token, err error) *MockTokenRepository_FindBySubscriptionIdAndType_Call {
+	_c.Call.Return(token, err)
+	return _c
+}
+
+func (_c *MockTokenRepository_FindBySubscriptionIdAndType_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32, tokenType model.TokenType) (*model.Token, error)) *MockTokenRepository_FindBySubscriptionIdAndType_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByToken provides a mock function for the type MockTokenRepository
+func (_mock *MockTokenRepository) FindByToken(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Token, error) {
 	ret := _mock.Called(context1, sQLExecutor, s)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindByName")
+		panic("no return value specified for FindByToken")
 	}
 
-	var r0 *model.Location
+	var r0 *model.Token
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) (*model.Location, error)); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) (*model.Token, error)); ok {
 		return returnFunc(context1, sQLExecutor, s)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) *model.Location); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) *model.Token); ok {
 		r0 = returnFunc(context1, sQLExecutor, s)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Location)
+			r0 = ret.Get(0).(*model.Token)
 		}
 	}
 	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, string) error); ok {
@@ -150,88 +3078,136 @@ func (_mock *MockLocationRepository) FindByName(context1 context.Context, sQLExe
 	return r0, r1
 }
 
-// MockLocationRepository_FindByName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByName'
-type MockLocationRepository_FindByName_Call struct {
+// MockTokenRepository_FindByToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByToken'
+type MockTokenRepository_FindByToken_Call struct {
 	*mock.Call
 }
 
-// FindByName is a helper method to define mock.On call
+// FindByToken is a helper method to define mock.On call
 //   - context1
 //   - sQLExecutor
 //   - s
-func (_e *MockLocationRepository_Expecter) FindByName(context1 interface{}, sQLExecutor interface{}, s interface{}) *MockLocationRepository_FindByName_Call {
-	return &MockLocationRepository_FindByName_Call{Call: _e.mock.On("FindByName", context1, sQLExecutor, s)}
+func (_e *MockTokenRepository_Expecter) FindByToken(context1 interface{}, sQLExecutor interface{}, s interface{}) *MockTokenRepository_FindByToken_Call {
+	return &MockTokenRepository_FindByToken_Call{Call: _e.mock.On("FindByToken", context1, sQLExecutor, s)}
 }
 
-func (_c *MockLocationRepository_FindByName_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string)) *MockLocationRepository_FindByName_Call {
+func (_c *MockTokenRepository_FindByToken_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string)) *MockTokenRepository_FindByToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockLocationRepository_FindByName_Call) Return(location *model.Location, err error) *MockLocationRepository_FindByName_Call {
-	_c.Call.Return(location, err)
+func (_c *MockTokenRepository_FindByToken_Call) Return(token *model.Token, err error) *MockTokenRepository_FindByToken_Call {
+	_c.Call.Return(token, err)
 	return _c
 }
 
-func (_c *MockLocationRepository_FindByName_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Location, error)) *MockLocationRepository_FindByName_Call {
+func (_c *MockTokenRepository_FindByToken_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Token, error)) *MockTokenRepository_FindByToken_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Save provides a mock function for the type MockLocationRepository
-func (_mock *MockLocationRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (int32, error) {
-	ret := _mock.Called(context1, sQLExecutor, location)
+// MarkUsed provides a mock function for the type MockTokenRepository
+func (_mock *MockTokenRepository) MarkUsed(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string, time1 time.Time) (bool, error) {
+	ret := _mock.Called(context1, sQLExecutor, s, time1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Save")
+		panic("no return value specified for MarkUsed")
 	}
 
-	var r0 int32
+	var r0 bool
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) (int32, error)); ok {
-		return returnFunc(context1, sQLExecutor, location)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string, time.Time) (bool, error)); ok {
+		return returnFunc(context1, sQLExecutor, s, time1)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Location) int32); ok {
-		r0 = returnFunc(context1, sQLExecutor, location)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string, time.Time) bool); ok {
+		r0 = returnFunc(context1, sQLExecutor, s, time1)
 	} else {
-		r0 = ret.Get(0).(int32)
+		r0 = ret.Get(0).(bool)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, *model.Location) error); ok {
-		r1 = returnFunc(context1, sQLExecutor, location)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, string, time.Time) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, s, time1)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockLocationRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
-type MockLocationRepository_Save_Call struct {
+// MockTokenRepository_MarkUsed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkUsed'
+type MockTokenRepository_MarkUsed_Call struct {
+	*mock.Call
+}
+
+// MarkUsed is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - s
+//   - time1
+func (_e *MockTokenRepository_Expecter) MarkUsed(context1 interface{}, sQLExecutor interface{}, s interface{}, time1 interface{}) *MockTokenRepository_MarkUsed_Call {
+	return &MockTokenRepository_MarkUsed_Call{Call: _e.mock.On("MarkUsed", context1, sQLExecutor, s, time1)}
+}
+
+func (_c *MockTokenRepository_MarkUsed_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string, time1 time.Time)) *MockTokenRepository_MarkUsed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(string), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockTokenRepository_MarkUsed_Call) Return(b bool, err error) *MockTokenRepository_MarkUsed_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockTokenRepository_MarkUsed_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string, time1 time.Time) (bool, error)) *MockTokenRepository_MarkUsed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockTokenRepository
+func (_mock *MockTokenRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, token *model.Token) error {
+	ret := _mock.Called(context1, sQLExecutor, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.Token) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockTokenRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockTokenRepository_Save_Call struct {
 	*mock.Call
 }
 
 // Save is a helper method to define mock.On call
 //   - context1
 //   - sQLExecutor
-//   - location
-func (_e *MockLocationRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, location interface{}) *MockLocationRepository_Save_Call {
-	return &MockLocationRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, location)}
+//   - token
+func (_e *MockTokenRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, token interface{}) *MockTokenRepository_Save_Call {
+	return &MockTokenRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, token)}
 }
 
-func (_c *MockLocationRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location)) *MockLocationRepository_Save_Call {
+func (_c *MockTokenRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, token *model.Token)) *MockTokenRepository_Save_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Location))
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.Token))
 	})
 	return _c
 }
 
-func (_c *MockLocationRepository_Save_Call) Return(n int32, err error) *MockLocationRepository_Save_Call {
-	_c.Call.Return(n, err)
+func (_c *MockTokenRepository_Save_Call) Return(err error) *MockTokenRepository_Save_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockLocationRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, location *model.Location) (int32, error)) *MockLocationRepository_Save_Call {
+func (_c *MockTokenRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, token *model.Token) error) *MockTokenRepository_Save_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -263,60 +3239,60 @@ func (_m *MockWeatherRepository) EXPECT() *MockWeatherRepository_Expecter {
 	return &MockWeatherRepository_Expecter{mock: &_m.Mock}
 }
 
-// FindLastUpdatedByLocation provides a mock function for the type MockWeatherRepository
-func (_mock *MockWeatherRepository) FindLastUpdatedByLocation(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Weather, error) {
-	ret := _mock.Called(context1, sQLExecutor, s)
+// FindLastUpdatedByLocationId provides a mock function for the type MockWeatherRepository
+func (_mock *MockWeatherRepository) FindLastUpdatedByLocationId(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Weather, error) {
+	ret := _mock.Called(context1, sQLExecutor, n)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindLastUpdatedByLocation")
+		panic("no return value specified for FindLastUpdatedByLocationId")
 	}
 
 	var r0 *model.Weather
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) (*model.Weather, error)); ok {
-		return returnFunc(context1, sQLExecutor, s)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) (*model.Weather, error)); ok {
+		return returnFunc(context1, sQLExecutor, n)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, string) *model.Weather); ok {
-		r0 = returnFunc(context1, sQLExecutor, s)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) *model.Weather); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Weather)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, string) error); ok {
-		r1 = returnFunc(context1, sQLExecutor, s)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockWeatherRepository_FindLastUpdatedByLocation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindLastUpdatedByLocation'
-type MockWeatherRepository_FindLastUpdatedByLocation_Call struct {
+// MockWeatherRepository_FindLastUpdatedByLocationId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindLastUpdatedByLocationId'
+type MockWeatherRepository_FindLastUpdatedByLocationId_Call struct {
 	*mock.Call
 }
 
-// FindLastUpdatedByLocation is a helper method to define mock.On call
+// FindLastUpdatedByLocationId is a helper method to define mock.On call
 //   - context1
 //   - sQLExecutor
-//   - s
-func (_e *MockWeatherRepository_Expecter) FindLastUpdatedByLocation(context1 interface{}, sQLExecutor interface{}, s interface{}) *MockWeatherRepository_FindLastUpdatedByLocation_Call {
-	return &MockWeatherRepository_FindLastUpdatedByLocation_Call{Call: _e.mock.On("FindLastUpdatedByLocation", context1, sQLExecutor, s)}
+//   - n
+func (_e *MockWeatherRepository_Expecter) FindLastUpdatedByLocationId(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockWeatherRepository_FindLastUpdatedByLocationId_Call {
+	return &MockWeatherRepository_FindLastUpdatedByLocationId_Call{Call: _e.mock.On("FindLastUpdatedByLocationId", context1, sQLExecutor, n)}
 }
 
-func (_c *MockWeatherRepository_FindLastUpdatedByLocation_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string)) *MockWeatherRepository_FindLastUpdatedByLocation_Call {
+func (_c *MockWeatherRepository_FindLastUpdatedByLocationId_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockWeatherRepository_FindLastUpdatedByLocationId_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(string))
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
 	})
 	return _c
 }
 
-func (_c *MockWeatherRepository_FindLastUpdatedByLocation_Call) Return(weather *model.Weather, err error) *MockWeatherRepository_FindLastUpdatedByLocation_Call {
+func (_c *MockWeatherRepository_FindLastUpdatedByLocationId_Call) Return(weather *model.Weather, err error) *MockWeatherRepository_FindLastUpdatedByLocationId_Call {
 	_c.Call.Return(weather, err)
 	return _c
 }
 
-func (_c *MockWeatherRepository_FindLastUpdatedByLocation_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, s string) (*model.Weather, error)) *MockWeatherRepository_FindLastUpdatedByLocation_Call {
+func (_c *MockWeatherRepository_FindLastUpdatedByLocationId_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.Weather, error)) *MockWeatherRepository_FindLastUpdatedByLocationId_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -367,3 +3343,135 @@ func (_c *MockWeatherRepository_Save_Call) RunAndReturn(run func(context1 contex
 	_c.Call.Return(run)
 	return _c
 }
+
+// NewMockAirQualityRepository creates a new instance of MockAirQualityRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockAirQualityRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockAirQualityRepository {
+	mock := &MockAirQualityRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockAirQualityRepository is an autogenerated mock type for the AirQualityRepository type
+type MockAirQualityRepository struct {
+	mock.Mock
+}
+
+type MockAirQualityRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockAirQualityRepository) EXPECT() *MockAirQualityRepository_Expecter {
+	return &MockAirQualityRepository_Expecter{mock: &_m.Mock}
+}
+
+// FindLastByLocationId provides a mock function for the type MockAirQualityRepository
+func (_mock *MockAirQualityRepository) FindLastByLocationId(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.AirQuality, error) {
+	ret := _mock.Called(context1, sQLExecutor, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindLastByLocationId")
+	}
+
+	var r0 *model.AirQuality
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) (*model.AirQuality, error)); ok {
+		return returnFunc(context1, sQLExecutor, n)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, int32) *model.AirQuality); ok {
+		r0 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.AirQuality)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, sqlutil.SQLExecutor, int32) error); ok {
+		r1 = returnFunc(context1, sQLExecutor, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAirQualityRepository_FindLastByLocationId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindLastByLocationId'
+type MockAirQualityRepository_FindLastByLocationId_Call struct {
+	*mock.Call
+}
+
+// FindLastByLocationId is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - n
+func (_e *MockAirQualityRepository_Expecter) FindLastByLocationId(context1 interface{}, sQLExecutor interface{}, n interface{}) *MockAirQualityRepository_FindLastByLocationId_Call {
+	return &MockAirQualityRepository_FindLastByLocationId_Call{Call: _e.mock.On("FindLastByLocationId", context1, sQLExecutor, n)}
+}
+
+func (_c *MockAirQualityRepository_FindLastByLocationId_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32)) *MockAirQualityRepository_FindLastByLocationId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockAirQualityRepository_FindLastByLocationId_Call) Return(airQuality *model.AirQuality, err error) *MockAirQualityRepository_FindLastByLocationId_Call {
+	_c.Call.Return(airQuality, err)
+	return _c
+}
+
+func (_c *MockAirQualityRepository_FindLastByLocationId_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, n int32) (*model.AirQuality, error)) *MockAirQualityRepository_FindLastByLocationId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Save provides a mock function for the type MockAirQualityRepository
+func (_mock *MockAirQualityRepository) Save(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, airQuality *model.AirQuality) error {
+	ret := _mock.Called(context1, sQLExecutor, airQuality)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, sqlutil.SQLExecutor, *model.AirQuality) error); ok {
+		r0 = returnFunc(context1, sQLExecutor, airQuality)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockAirQualityRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockAirQualityRepository_Save_Call struct {
+	*mock.Call
+}
+
+// Save is a helper method to define mock.On call
+//   - context1
+//   - sQLExecutor
+//   - airQuality
+func (_e *MockAirQualityRepository_Expecter) Save(context1 interface{}, sQLExecutor interface{}, airQuality interface{}) *MockAirQualityRepository_Save_Call {
+	return &MockAirQualityRepository_Save_Call{Call: _e.mock.On("Save", context1, sQLExecutor, airQuality)}
+}
+
+func (_c *MockAirQualityRepository_Save_Call) Run(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, airQuality *model.AirQuality)) *MockAirQualityRepository_Save_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlutil.SQLExecutor), args[2].(*model.AirQuality))
+	})
+	return _c
+}
+
+func (_c *MockAirQualityRepository_Save_Call) Return(err error) *MockAirQualityRepository_Save_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockAirQualityRepository_Save_Call) RunAndReturn(run func(context1 context.Context, sQLExecutor sqlutil.SQLExecutor, airQuality *model.AirQuality) error) *MockAirQualityRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -3,25 +3,49 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/schedule"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"golang.org/x/sync/errgroup"
 	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+type WeatherAlertRepository interface {
+	SaveIfAbsent(context.Context, sqlutil.SQLExecutor, *model.WeatherAlert) (bool, error)
+}
+
+type NotificationDeliveryRepository interface {
+	Save(context.Context, sqlutil.SQLExecutor, *model.NotificationDelivery) (int64, error)
+	ExistsSent(context.Context, sqlutil.SQLExecutor, int32, model.NotificationKind, string) (bool, error)
+	DeleteAttemptedBeforeBatch(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)
+}
+
 type NotificationService struct {
-	db                     *sql.DB
-	weatherProvider        WeatherProvider
-	locationRepository     LocationRepository
-	weatherRepository      WeatherRepository
-	subscriberRepository   SubscriberRepository
-	subscriptionRepository SubscriptionRepository
-	tokenRepository        TokenRepository
-	emailSender            EmailSender
-	log                    *slog.Logger
+	db                             *sql.DB
+	weatherProvider                WeatherProvider
+	locationRepository             LocationRepository
+	weatherRepository              WeatherRepository
+	airQualityRepository           AirQualityRepository
+	weatherAlertRepository         WeatherAlertRepository
+	notificationDeliveryRepository NotificationDeliveryRepository
+	subscriberRepository           SubscriberRepository
+	subscriptionRepository         SubscriptionRepository
+	conditionRepository            SubscriptionConditionRepository
+	tokenRepository                TokenRepository
+	emailSender                    EmailSender
+	manageLinker                   ManageLinker
+	tokenSigner                    TokenSigner
+	unsubscribeURL                 string
+	cfg                            config.Notifications
+	log                            *slog.Logger
 }
 
 func NewNotificationService(
@@ -29,126 +53,835 @@ func NewNotificationService(
 	weatherProvider WeatherProvider,
 	locationRepository LocationRepository,
 	weatherRepository WeatherRepository,
+	airQualityRepository AirQualityRepository,
+	weatherAlertRepository WeatherAlertRepository,
+	notificationDeliveryRepository NotificationDeliveryRepository,
 	subscriberRepository SubscriberRepository,
 	subscriptionRepository SubscriptionRepository,
+	conditionRepository SubscriptionConditionRepository,
 	tokenRepository TokenRepository,
 	emailSender EmailSender,
+	manageLinker ManageLinker,
+	tokenSigner TokenSigner,
+	unsubscribeURL string,
+	cfg config.Notifications,
 	log *slog.Logger) *NotificationService {
 	return &NotificationService{
-		db:                     db,
-		weatherProvider:        weatherProvider,
-		locationRepository:     locationRepository,
-		weatherRepository:      weatherRepository,
-		subscriberRepository:   subscriberRepository,
-		subscriptionRepository: subscriptionRepository,
-		tokenRepository:        tokenRepository,
-		emailSender:            emailSender,
-		log:                    log,
+		db:                             db,
+		weatherProvider:                weatherProvider,
+		locationRepository:             locationRepository,
+		weatherRepository:              weatherRepository,
+		airQualityRepository:           airQualityRepository,
+		weatherAlertRepository:         weatherAlertRepository,
+		notificationDeliveryRepository: notificationDeliveryRepository,
+		subscriberRepository:           subscriberRepository,
+		subscriptionRepository:         subscriptionRepository,
+		conditionRepository:            conditionRepository,
+		tokenRepository:                tokenRepository,
+		emailSender:                    emailSender,
+		manageLinker:                   manageLinker,
+		tokenSigner:                    tokenSigner,
+		unsubscribeURL:                 unsubscribeURL,
+		cfg:                            cfg,
+		log:                            log,
 	}
 }
 
-func (s *NotificationService) SendDailyNotifications(emailData config.EmailData) {
+func (s *NotificationService) SendDailyNotifications(ctx context.Context, emailData config.EmailData) {
 	s.log.Info("triggered SendDailyNotifications")
-	ctx := context.Background()
+	s.sendDueNotifications(ctx, model.Frequency_Daily, model.NotificationKind_Daily, emailData, s.resolveDailyForecast, s.localizeDailyForecast, s.composeDailyForecastText, func(subscription *model.Subscription, now time.Time) (bool, error) {
+		return subscription.DailyDeliveryDue(now)
+	})
+}
 
-	err := sqlutil.WithTx(ctx, s.db, &sql.TxOptions{ReadOnly: true}, func(tx *sql.Tx) error {
-		subscriptions, errIn := s.subscriptionRepository.FindAllByFrequencyAndConfirmedStatus(ctx, tx, model.Frequency_Daily)
-		if errIn != nil {
-			return errIn
+func (s *NotificationService) SendHourlyNotifications(ctx context.Context, emailData config.EmailData) {
+	s.log.Info("triggered SendHourlyNotifications")
+	s.sendDueNotifications(ctx, model.Frequency_Hourly, model.NotificationKind_Hourly, emailData, s.resolveCurrentWeather, s.localizeCurrentWeather, s.composeCurrentWeatherText, func(subscription *model.Subscription, now time.Time) (bool, error) {
+		return subscription.HourlyDeliveryDue(now), nil
+	})
+}
+
+func (s *NotificationService) SendScheduledNotifications(ctx context.Context, emailData config.EmailData) {
+	s.log.Info("triggered SendScheduledNotifications")
+	s.sendDueNotifications(ctx, model.Frequency_Custom, model.NotificationKind_Custom, emailData, s.resolveCurrentWeather, s.localizeCurrentWeather, s.composeCurrentWeatherText, func(subscription *model.Subscription, now time.Time) (bool, error) {
+		sched, err := schedule.Parse(subscription.Schedule, subscription.DeliveryHour)
+		if err != nil {
+			return false, err
 		}
+		return subscription.ScheduleDue(sched, now)
+	})
+}
 
-		if errIn = s.sendNotificationsToAllSubscribers(ctx, tx, subscriptions, emailData); errIn != nil {
-			return errIn
+type dueFunc func(subscription *model.Subscription, now time.Time) (bool, error)
+
+// locationWeather is resolved once per location and language in a run and shared by all subscriptions of them
+type locationWeather struct {
+	location *model.Location
+	current  *model.Weather
+	today    *model.DailyForecast
+	// snapshot is taken before localizing, conditions are matched against metric values and english description
+	snapshot model.WeatherSnapshot
+}
+
+// weatherKey identifies weather of a run, subscriptions of one location differ only in language of the description
+type weatherKey struct {
+	locationId int32
+	language   string
+}
+
+// resolveWeatherFunc resolves weather of a location in default language
+type resolveWeatherFunc func(ctx context.Context, location *model.Location) (*locationWeather, error)
+
+// localizeWeatherFunc returns a copy of resolved weather with description in given language
+type localizeWeatherFunc func(ctx context.Context, weather *locationWeather, language string) (*locationWeather, error)
+
+type notificationJob struct {
+	subscription     *model.Subscription
+	weather          *locationWeather
+	subscriber       *model.Subscriber
+	unsubToken       string
+	text             string
+	matched          bool
+	conditionChanged bool
+}
+
+type notificationRun struct {
+	kind            model.NotificationKind
+	now             time.Time
+	emailData       config.EmailData
+	resolveWeather  resolveWeatherFunc
+	localizeWeather localizeWeatherFunc
+	composeText     composeTextFunc
+	// weather and weatherErrors are written only while pages are resolved, jobs hold weather pointers
+	weather       map[weatherKey]*locationWeather
+	weatherErrors map[weatherKey]error
+	sent          atomic.Int64
+	skipped       atomic.Int64
+	failed        atomic.Int64
+}
+
+// sendDueNotifications streams subscriptions page by page, resolves weather of every new location and language of a page up front and then
+// pipelines composing and sending through worker pools of configured size.
+// Every subscription is sent in its own transaction, so one failing subscription does not affect the others, failed one keeps
+// its last delivery time and is due again on the next run. Once ctx is cancelled no new work is started, emails already being sent are finished
+func (s *NotificationService) sendDueNotifications(ctx context.Context, frequency model.Frequency, kind model.NotificationKind, emailData config.EmailData, resolveWeather resolveWeatherFunc, localizeWeather localizeWeatherFunc, composeText composeTextFunc, isDue dueFunc) {
+	run := &notificationRun{
+		kind: kind,
+		// database keeps microseconds, so the claim is released by the same value it has stored
+		now:             time.Now().Truncate(time.Microsecond),
+		emailData:       emailData,
+		resolveWeather:  resolveWeather,
+		localizeWeather: localizeWeather,
+		composeText:     composeText,
+		weather:         make(map[weatherKey]*locationWeather),
+		weatherErrors:   make(map[weatherKey]error),
+	}
+
+	jobs := make(chan *notificationJob, max(s.cfg.PageSize, 1))
+	go func() {
+		defer close(jobs)
+		s.streamDueJobs(ctx, run, frequency, isDue, jobs)
+	}()
+
+	composed := s.startStage(ctx, run, s.cfg.WeatherWorkers, jobs, s.composeNotification)
+	// sending is not interrupted halfway, so that email and its delivery record are either both done or both not
+	done := s.startStage(ctx, run, s.cfg.SendWorkers, composed, func(_ context.Context, run *notificationRun, job *notificationJob) error {
+		return s.sendNotification(context.WithoutCancel(ctx), run, job)
+	})
+	for range done {
+	}
+
+	if ctx.Err() != nil {
+		s.log.Warn("notification run cancelled", "kind", kind, "sent", run.sent.Load(), "skipped", run.skipped.Load(), "failed", run.failed.Load())
+		return
+	}
+	s.log.Info("notification run finished", "kind", kind, "weathers", len(run.weather), "sent", run.sent.Load(), "skipped", run.skipped.Load(), "failed", run.failed.Load())
+}
+
+// streamDueJobs reads subscriptions with keyset pagination, the next page is read once the pipeline has taken in the previous one,
+// so round-trips grow with number of pages and memory stays bounded by page size
+func (s *NotificationService) streamDueJobs(ctx context.Context, run *notificationRun, frequency model.Frequency, isDue dueFunc, jobs chan<- *notificationJob) {
+	pageSize := max(s.cfg.PageSize, 1)
+	var afterId int32
+	for ctx.Err() == nil {
+		targets, err := s.subscriptionRepository.FindNotificationTargetsPage(ctx, s.db, frequency, afterId, pageSize)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				s.log.Error("unable to find subscriptions", "frequency", frequency, "afterId", afterId, "error", err)
+			}
+			return
+		}
+		if len(targets) == 0 {
+			return
+		}
+		afterId = targets[len(targets)-1].Subscription.Id
+
+		var dueTargets []*model.NotificationTarget
+		for _, target := range targets {
+			due, err := isDue(&target.Subscription, run.now)
+			if err != nil {
+				s.log.Error("unable to check delivery time", "subscriptionId", target.Subscription.Id, "error", err)
+				continue
+			}
+			if due {
+				dueTargets = append(dueTargets, target)
+			}
 		}
 
+		s.resolveLocations(ctx, run, dueTargets)
+
+		for _, target := range dueTargets {
+			key := weatherKey{locationId: target.Location.Id, language: target.Subscriber.Language}
+			weather, ok := run.weather[key]
+			if !ok {
+				if err, failed := run.weatherErrors[key]; failed {
+					s.failNotification(ctx, run, target.Subscription.Id, err)
+				}
+				continue
+			}
+			job := &notificationJob{
+				subscription: &target.Subscription,
+				weather:      weather,
+				subscriber:   &target.Subscriber,
+				unsubToken:   signToken(s.tokenSigner, &target.Token),
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if len(targets) < pageSize {
+			return
+		}
+	}
+}
+
+// resolveLocations resolves weather of locations not seen earlier in the run in parallel, each location is resolved once in
+// default language and then localized once per other language of its subscribers. Failed weather is remembered so that
+// subscriptions on later pages are failed without another attempt
+func (s *NotificationService) resolveLocations(ctx context.Context, run *notificationRun, targets []*model.NotificationTarget) {
+	locations := make(map[int32]*model.Location)
+	languages := make(map[int32][]string)
+	for _, target := range targets {
+		id := target.Location.Id
+		key := weatherKey{locationId: id, language: target.Subscriber.Language}
+		if _, ok := run.weather[key]; ok {
+			continue
+		}
+		if _, ok := run.weatherErrors[key]; ok {
+			continue
+		}
+		if err, failed := run.weatherErrors[weatherKey{locationId: id, language: model.DefaultLanguage}]; failed {
+			run.weatherErrors[key] = err
+			continue
+		}
+		if !slices.Contains(languages[id], key.language) {
+			languages[id] = append(languages[id], key.language)
+		}
+		locations[id] = &target.Location
+	}
+
+	var mu sync.Mutex
+	store := func(key weatherKey, weather *locationWeather, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				s.log.Error("unable to resolve weather", "locationId", key.locationId, "language", key.language, "error", err)
+				run.weatherErrors[key] = err
+			}
+			return
+		}
+		run.weather[key] = weather
+	}
+
+	var g errgroup.Group
+	g.SetLimit(max(s.cfg.WeatherWorkers, 1))
+	for id, location := range locations {
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			defaultKey := weatherKey{locationId: id, language: model.DefaultLanguage}
+			mu.Lock()
+			base, ok := run.weather[defaultKey]
+			mu.Unlock()
+			if !ok {
+				var err error
+				base, err = run.resolveWeather(ctx, location)
+				store(defaultKey, base, err)
+				if err != nil {
+					// weather in other languages is localized from the default one and fails with it
+					for _, language := range languages[id] {
+						store(weatherKey{locationId: id, language: language}, nil, err)
+					}
+					return nil
+				}
+			}
+
+			for _, language := range languages[id] {
+				if language == model.DefaultLanguage || ctx.Err() != nil {
+					continue
+				}
+				localized, err := run.localizeWeather(ctx, base, language)
+				store(weatherKey{locationId: id, language: language}, localized, err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+type stageFunc func(ctx context.Context, run *notificationRun, job *notificationJob) error
+
+// startStage runs process for every job of in on workers goroutines, out is closed once all of them are done.
+// Failed jobs are recorded and dropped, after ctx is cancelled remaining jobs are drained without processing
+func (s *NotificationService) startStage(ctx context.Context, run *notificationRun, workers int, in <-chan *notificationJob, process stageFunc) <-chan *notificationJob {
+	workers = max(workers, 1)
+	out := make(chan *notificationJob, workers)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range in {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := process(ctx, run, job); err != nil {
+					if !errors.Is(err, context.Canceled) {
+						s.failNotification(ctx, run, job.subscription.Id, err)
+					}
+					continue
+				}
+				out <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+func (s *NotificationService) composeNotification(ctx context.Context, run *notificationRun, job *notificationJob) error {
+	job.text = run.composeText(job.weather, job.subscriber, run.emailData.Text, job.unsubToken)
+
+	var err error
+	job.matched, err = s.matchConditions(ctx, s.db, job.subscription, job.weather.snapshot)
+	if err != nil {
+		return err
+	}
+	job.conditionChanged = job.matched != job.subscription.ConditionActive
+
+	return nil
+}
+
+// sendNotification claims delivery, sends the email and records the result, each database step in its own short transaction
+// so that no connection or row lock is held during the network call. Claim is the conditional update of last delivery time
+// read by this run, it is committed before sending, so once email is out it is not sent again even when recording fails.
+// Failed send releases the claim so that the subscription is due again on the next run.
+// Subscription with unmatched conditions is skipped but counts as delivered
+func (s *NotificationService) sendNotification(ctx context.Context, run *notificationRun, job *notificationJob) error {
+	subscription := job.subscription
+	claimed, err := s.claimDelivery(ctx, run, job)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		run.skipped.Add(1)
+		s.log.Info("subscription already delivered by another run, skip weather email", "subscriptionId", subscription.Id)
+		return nil
+	}
+	if !job.matched {
+		run.skipped.Add(1)
+		s.log.Info("conditions not matched, skip weather email", "subscriptionId", subscription.Id)
+		return nil
+	}
+
+	email := dto.SimpleEmail{
+		From:    run.emailData.From,
+		To:      job.subscriber.Email,
+		Subject: run.emailData.Subject,
+		Text:    withManageLink(job.text, s.manageLinker.Link(job.subscriber.Id)),
+		Headers: unsubscribeHeaders(s.unsubscribeURL, job.unsubToken),
+	}
+	messageId, err := s.send(ctx, email)
+	if err != nil {
+		s.releaseDelivery(ctx, run, job, err)
 		return nil
+	}
+
+	err = sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		return s.recordDelivery(ctx, tx, subscription.Id, run.kind, "", messageId)
 	})
 	if err != nil {
-		s.log.Error("rolled back transaction because of ", "error", err)
-		return
+		// claim is committed, so the email is not sent again, only its delivery record is missing
+		s.log.Error("unable to record delivery", "subscriptionId", subscription.Id, "messageId", messageId, "error", err)
 	}
-	s.log.Info("transaction commited successfully")
-}
+	run.sent.Add(1)
+	s.log.Info("weather email is send", "subscriptionId", subscription.Id)
 
-func (s *NotificationService) SendHourlyNotifications(emailData config.EmailData) {
-	s.log.Info("triggered SendHourlyNotifications")
-	ctx := context.Background()
+	return nil
+}
 
+// claimDelivery stores changed condition state together with the claim, false means another run has delivered the subscription
+func (s *NotificationService) claimDelivery(ctx context.Context, run *notificationRun, job *notificationJob) (bool, error) {
+	subscription := job.subscription
+	var claimed bool
 	err := sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
-		subscriptions, errIn := s.subscriptionRepository.FindAllByFrequencyAndConfirmedStatus(ctx, tx, model.Frequency_Hourly)
-		if errIn != nil {
+		var errIn error
+		claimed, errIn = s.subscriptionRepository.UpdateLastDeliveredAt(ctx, tx, subscription.Id, subscription.LastDeliveredAt, run.now)
+		if errIn != nil || !claimed {
 			return errIn
 		}
+		if job.conditionChanged {
+			return s.subscriptionRepository.UpdateConditionActive(ctx, tx, subscription.Id, job.matched)
+		}
+		return nil
+	})
+	return claimed, err
+}
 
-		if errIn = s.sendNotificationsToAllSubscribers(ctx, tx, subscriptions, emailData); errIn != nil {
+// releaseDelivery restores last delivery time of a failed send and records the failure in one transaction
+func (s *NotificationService) releaseDelivery(ctx context.Context, run *notificationRun, job *notificationJob, sendErr error) {
+	subscription := job.subscription
+	run.failed.Add(1)
+	s.log.Error("unable to notify subscription", "subscriptionId", subscription.Id, "kind", run.kind, "error", sendErr)
+
+	err := sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		if _, errIn := s.subscriptionRepository.UpdateLastDeliveredAt(ctx, tx, subscription.Id, run.now, subscription.LastDeliveredAt); errIn != nil {
 			return errIn
 		}
-
-		return nil
+		_, errIn := s.notificationDeliveryRepository.Save(ctx, tx, failedDelivery(subscription.Id, run.kind, "", sendErr))
+		return errIn
 	})
 	if err != nil {
-		s.log.Error("rolled back transaction because of ", "error", err)
-		return
+		s.log.Error("unable to release failed delivery", "subscriptionId", subscription.Id, "error", err)
 	}
-	s.log.Info("transaction commited successfully")
 }
 
-func (s *NotificationService) sendNotificationsToAllSubscribers(ctx context.Context, tx *sql.Tx, subscriptions []*model.Subscription, emailData config.EmailData) error {
-	for i := 0; i < len(subscriptions); i++ {
-		subscriber, err := s.subscriberRepository.FindById(ctx, tx, subscriptions[i].SubscriberId)
-		if err != nil {
-			return err
+func (s *NotificationService) failNotification(ctx context.Context, run *notificationRun, subscriptionId int32, err error) {
+	run.failed.Add(1)
+	s.log.Error("unable to notify subscription", "subscriptionId", subscriptionId, "kind", run.kind, "error", err)
+	s.recordFailure(context.WithoutCancel(ctx), subscriptionId, run.kind, "", err)
+}
+
+// send bounds single email by configured timeout, provider rate limit is applied by email sender
+func (s *NotificationService) send(ctx context.Context, email dto.SimpleEmail) (string, error) {
+	sendCtx, cancel := context.WithTimeout(ctx, s.cfg.SendTimeout)
+	defer cancel()
+
+	return s.emailSender.Send(sendCtx, email)
+}
+
+// SendAirQualityAlerts checks every subscription in its own transaction, alert state of failed one stays unchanged so it is retried on the next run
+func (s *NotificationService) SendAirQualityAlerts(ctx context.Context, emailData config.EmailData) {
+	s.log.Info("triggered SendAirQualityAlerts")
+
+	subscriptions, err := s.subscriptionRepository.FindAllWithAqiThresholdAndConfirmedStatus(ctx, s.db)
+	if err != nil {
+		s.log.Error("unable to find subscriptions", "error", err)
+		return
+	}
+
+	var sent, failed int
+	for _, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			s.log.Warn("notification run cancelled", "kind", model.NotificationKind_AirQuality, "sent", sent, "failed", failed)
+			return
 		}
-		location, err := s.locationRepository.FindById(ctx, tx, subscriptions[i].LocationId)
+		// started subscription is finished so that alert state matches sent email
+		txCtx := context.WithoutCancel(ctx)
+		var alerted bool
+		err = sqlutil.WithTx(txCtx, s.db, nil, func(tx *sql.Tx) error {
+			var errIn error
+			alerted, errIn = s.checkAirQuality(txCtx, tx, subscription, emailData)
+			return errIn
+		})
 		if err != nil {
-			return err
+			failed++
+			s.log.Error("unable to check air quality", "subscriptionId", subscription.Id, "error", err)
+			s.recordFailure(txCtx, subscription.Id, model.NotificationKind_AirQuality, "", err)
+			continue
 		}
-		token, err := s.tokenRepository.FindBySubscriptionIdAndType(ctx, tx, subscriptions[i].Id, model.TokenType_Unsubscribe)
-		if err != nil {
-			return err
+		if alerted {
+			sent++
 		}
-		lastWeather, err := s.weatherRepository.FindLastUpdatedByLocation(ctx, tx, location.Name)
+	}
+	s.log.Info("notification run finished", "kind", model.NotificationKind_AirQuality, "sent", sent, "failed", failed)
+}
+
+func (s *NotificationService) checkAirQuality(ctx context.Context, tx *sql.Tx, subscription *model.Subscription, emailData config.EmailData) (bool, error) {
+	location, err := s.locationRepository.FindById(ctx, tx, subscription.LocationId)
+	if err != nil {
+		return false, err
+	}
+
+	airQuality, err := s.airQualityRepository.FindLastByLocationId(ctx, tx, location.Id)
+	if err != nil {
+		return false, err
+	}
+
+	if airQuality == nil || airQuality.FetchedAt.Add(15*time.Minute).Before(time.Now()) {
+		fetched, err := s.weatherProvider.GetAirQuality(ctx, location.Query())
 		if err != nil {
-			return err
+			return false, err
 		}
 
-		if lastWeather == nil || lastWeather.LastUpdated.Add(15*time.Minute).Before(time.Now()) {
-			weather, err := s.weatherProvider.GetCurrentWeather(location.Name)
-			if err != nil {
-				return err
-			}
+		fetched.AirQuality.LocationId = location.Id
+		fetched.AirQuality.FetchedAt = time.Now().UTC()
 
-			weather.Weather.LocationId = location.Id
-			weather.Weather.FetchedAt = time.Now().UTC()
+		err = s.airQualityRepository.Save(ctx, tx, &fetched.AirQuality)
+		if err != nil {
+			return false, err
+		}
 
-			err = s.weatherRepository.Save(ctx, tx, &weather.Weather)
-			if err != nil {
-				return err
-			}
+		airQuality = &fetched.AirQuality
+	}
 
-			lastWeather = &weather.Weather
+	exceeded := airQuality.USEPAIndex >= subscription.AqiThreshold
+	if exceeded == subscription.AqiAlertActive {
+		return false, nil
+	}
+
+	if exceeded {
+		subscriber, err := s.subscriberRepository.FindById(ctx, tx, subscription.SubscriberId)
+		if err != nil {
+			return false, err
+		}
+		token, err := s.tokenRepository.FindBySubscriptionIdAndType(ctx, tx, subscription.Id, model.TokenType_Unsubscribe)
+		if err != nil {
+			return false, err
 		}
+		unsubToken := signToken(s.tokenSigner, token)
 
 		email := dto.SimpleEmail{
 			From:    emailData.From,
 			To:      subscriber.Email,
 			Subject: emailData.Subject,
-			Text: fmt.Sprintf(
+			Text: withManageLink(fmt.Sprintf(
 				emailData.Text,
 				location.Name,
-				lastWeather.Temperature,
-				lastWeather.Humidity,
-				lastWeather.Description,
-				token.Token,
-			),
+				airQuality.USEPAIndex,
+				subscription.AqiThreshold,
+				airQuality.PM25,
+				airQuality.PM10,
+				airQuality.O3,
+				airQuality.NO2,
+				unsubToken,
+			), s.manageLinker.Link(subscriber.Id)),
+			Headers: unsubscribeHeaders(s.unsubscribeURL, unsubToken),
 		}
 
-		err = s.emailSender.Send(ctx, email)
+		messageId, err := s.send(ctx, email)
 		if err != nil {
-			return err
+			return false, err
 		}
-		s.log.Info("weather email is send")
+		if err = s.recordDelivery(ctx, tx, subscription.Id, model.NotificationKind_AirQuality, "", messageId); err != nil {
+			return false, err
+		}
+		s.log.Info("air quality alert email is send")
 	}
 
-	return nil
+	return exceeded, s.subscriptionRepository.UpdateAqiAlertActive(ctx, tx, subscription.Id, exceeded)
+}
+
+func (s *NotificationService) SendWeatherAlerts(ctx context.Context, emailData config.EmailData) {
+	s.log.Info("triggered SendWeatherAlerts")
+
+	subscriptions, err := s.subscriptionRepository.FindAllByFrequencyAndConfirmedStatus(ctx, s.db, model.Frequency_Alerts)
+	if err != nil {
+		s.log.Error("unable to find subscriptions", "error", err)
+		return
+	}
+
+	var locationIds []int32
+	subscriptionsByLocation := make(map[int32][]*model.Subscription)
+	for _, subscription := range subscriptions {
+		if _, ok := subscriptionsByLocation[subscription.LocationId]; !ok {
+			locationIds = append(locationIds, subscription.LocationId)
+		}
+		subscriptionsByLocation[subscription.LocationId] = append(subscriptionsByLocation[subscription.LocationId], subscription)
+	}
+
+	var sent, failed int
+	for _, locationId := range locationIds {
+		if ctx.Err() != nil {
+			s.log.Warn("notification run cancelled", "kind", model.NotificationKind_WeatherAlert, "sent", sent, "failed", failed)
+			return
+		}
+		locationSent, locationFailed := s.sendAlerts(ctx, locationId, subscriptionsByLocation[locationId], emailData)
+		sent += locationSent
+		failed += locationFailed
+	}
+	s.log.Info("notification run finished", "kind", model.NotificationKind_WeatherAlert, "sent", sent, "failed", failed)
+}
+
+// sendAlerts sends every active alert to subscriptions that have no successful delivery of it yet, so failed recipients get it on the next run
+func (s *NotificationService) sendAlerts(ctx context.Context, locationId int32, subscriptions []*model.Subscription, emailData config.EmailData) (sent int, failed int) {
+	location, err := s.locationRepository.FindById(ctx, s.db, locationId)
+	if err != nil || location == nil {
+		s.log.Error("unable to find location", "locationId", locationId, "error", err)
+		return 0, len(subscriptions)
+	}
+
+	alerts, err := s.weatherProvider.GetAlerts(ctx, location.Query())
+	if err != nil {
+		// one location must not hold back alerts for the others, it is polled again on the next run
+		s.log.Warn("unable to get weather alerts", "location", location.Name, "error", err)
+		return 0, 0
+	}
+
+	now := time.Now()
+	for i := range alerts {
+		alert := &alerts[i]
+		if !alert.Expires.IsZero() && alert.Expires.Before(now) {
+			continue
+		}
+		alert.LocationId = location.Id
+		alert.CreatedAt = now.UTC()
+
+		if _, err = s.weatherAlertRepository.SaveIfAbsent(ctx, s.db, alert); err != nil {
+			s.log.Error("unable to save weather alert", "location", location.Name, "error", err)
+			return sent, failed + len(subscriptions)
+		}
+
+		for _, subscription := range subscriptions {
+			if ctx.Err() != nil {
+				return sent, failed
+			}
+			txCtx := context.WithoutCancel(ctx)
+			var alerted bool
+			err = sqlutil.WithTx(txCtx, s.db, nil, func(tx *sql.Tx) error {
+				var errIn error
+				alerted, errIn = s.sendAlert(txCtx, tx, location, alert, subscription, emailData)
+				return errIn
+			})
+			if err != nil {
+				failed++
+				s.log.Error("unable to send weather alert", "subscriptionId", subscription.Id, "error", err)
+				s.recordFailure(txCtx, subscription.Id, model.NotificationKind_WeatherAlert, alert.ExternalId, err)
+				continue
+			}
+			if alerted {
+				sent++
+			}
+		}
+	}
+
+	return sent, failed
+}
+
+func (s *NotificationService) sendAlert(ctx context.Context, tx *sql.Tx, location *model.Location, alert *model.WeatherAlert, subscription *model.Subscription, emailData config.EmailData) (bool, error) {
+	delivered, err := s.notificationDeliveryRepository.ExistsSent(ctx, tx, subscription.Id, model.NotificationKind_WeatherAlert, alert.ExternalId)
+	if err != nil || delivered {
+		return false, err
+	}
+
+	subscriber, err := s.subscriberRepository.FindById(ctx, tx, subscription.SubscriberId)
+	if err != nil {
+		return false, err
+	}
+	token, err := s.tokenRepository.FindBySubscriptionIdAndType(ctx, tx, subscription.Id, model.TokenType_Unsubscribe)
+	if err != nil {
+		return false, err
+	}
+	unsubToken := signToken(s.tokenSigner, token)
+
+	email := dto.SimpleEmail{
+		From:    emailData.From,
+		To:      subscriber.Email,
+		Subject: emailData.Subject,
+		Text: withManageLink(fmt.Sprintf(
+			emailData.Text,
+			location.Name,
+			alert.Headline,
+			alert.Event,
+			alert.Severity,
+			alert.Areas,
+			formatAlertTime(alert.Effective),
+			formatAlertTime(alert.Expires),
+			alert.Description,
+			unsubToken,
+		), s.manageLinker.Link(subscriber.Id)),
+		Headers: unsubscribeHeaders(s.unsubscribeURL, unsubToken),
+	}
+
+	messageId, err := s.send(ctx, email)
+	if err != nil {
+		return false, err
+	}
+	if err = s.recordDelivery(ctx, tx, subscription.Id, model.NotificationKind_WeatherAlert, alert.ExternalId, messageId); err != nil {
+		return false, err
+	}
+	s.log.Info("weather alert email is send")
+
+	return true, nil
+}
+
+func formatAlertTime(t time.Time) string {
+	if t.IsZero() {
+		return "n/a"
+	}
+	return t.Format("2006-01-02 15:04 MST")
+}
+
+// composeTextFunc formats weather already localized for the subscriber, it makes no calls of its own
+type composeTextFunc func(weather *locationWeather, subscriber *model.Subscriber, emailText string, unsubToken string) string
+
+// recordDelivery stores successful attempt in the transaction that sent the email
+func (s *NotificationService) recordDelivery(ctx context.Context, tx *sql.Tx, subscriptionId int32, kind model.NotificationKind, reference string, messageId string) error {
+	_, err := s.notificationDeliveryRepository.Save(ctx, tx, &model.NotificationDelivery{
+		SubscriptionId:    subscriptionId,
+		Kind:              kind,
+		Reference:         reference,
+		Status:            model.DeliveryStatus_Sent,
+		ProviderMessageId: messageId,
+		AttemptedAt:       time.Now().UTC(),
+	})
+	return err
+}
+
+// recordFailure runs after the transaction of the attempt is rolled back, so it is stored on its own
+func (s *NotificationService) recordFailure(ctx context.Context, subscriptionId int32, kind model.NotificationKind, reference string, deliveryErr error) {
+	_, err := s.notificationDeliveryRepository.Save(ctx, s.db, failedDelivery(subscriptionId, kind, reference, deliveryErr))
+	if err != nil {
+		s.log.Error("unable to record failed delivery", "subscriptionId", subscriptionId, "error", err)
+	}
+}
+
+func failedDelivery(subscriptionId int32, kind model.NotificationKind, reference string, deliveryErr error) *model.NotificationDelivery {
+	return &model.NotificationDelivery{
+		SubscriptionId: subscriptionId,
+		Kind:           kind,
+		Reference:      reference,
+		Status:         model.DeliveryStatus_Failed,
+		Error:          deliveryErr.Error(),
+		AttemptedAt:    time.Now().UTC(),
+	}
+}
+
+// matchConditions only reads conditions, changed condition state is stored together with the delivery
+func (s *NotificationService) matchConditions(ctx context.Context, ex sqlutil.SQLExecutor, subscription *model.Subscription, snapshot model.WeatherSnapshot) (bool, error) {
+	conditions, err := s.conditionRepository.FindAllBySubscriptionId(ctx, ex, subscription.Id)
+	if err != nil {
+		return false, err
+	}
+	if len(conditions) == 0 {
+		return true, nil
+	}
+
+	return model.EvaluateConditions(conditions, subscription.ConditionLogic, snapshot, subscription.ConditionActive), nil
+}
+
+func (s *NotificationService) resolveCurrentWeather(ctx context.Context, location *model.Location) (*locationWeather, error) {
+	lastWeather, err := s.weatherRepository.FindLastUpdatedByLocationId(ctx, s.db, location.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastWeather == nil || lastWeather.LastUpdated.Add(15*time.Minute).Before(time.Now()) {
+		weather, err := s.weatherProvider.GetCurrentWeather(ctx, location.Query(), model.DefaultLanguage)
+		if err != nil {
+			return nil, err
+		}
+
+		weather.Weather.LocationId = location.Id
+		weather.Weather.FetchedAt = time.Now().UTC()
+
+		err = s.weatherRepository.Save(ctx, s.db, &weather.Weather)
+		if err != nil {
+			return nil, err
+		}
+
+		lastWeather = &weather.Weather
+	}
+
+	return &locationWeather{location: location, current: lastWeather, snapshot: model.SnapshotFromWeather(*lastWeather)}, nil
+}
+
+// localizeCurrentWeather takes only description from the provider, measurements stay the ones of stored weather
+func (s *NotificationService) localizeCurrentWeather(ctx context.Context, weather *locationWeather, language string) (*locationWeather, error) {
+	localized, err := s.weatherProvider.GetCurrentWeather(ctx, weather.location.Query(), language)
+	if err != nil {
+		return nil, err
+	}
+
+	current := *weather.current
+	current.Description = localized.Description
+	return &locationWeather{location: weather.location, current: &current, snapshot: weather.snapshot}, nil
+}
+
+func (s *NotificationService) composeCurrentWeatherText(weather *locationWeather, subscriber *model.Subscriber, emailText string, unsubToken string) string {
+	location, lastWeather := weather.location, weather.current
+
+	tempUnit, windUnit := subscriber.TemperatureUnit, subscriber.WindUnit
+	return fmt.Sprintf(
+		emailText,
+		location.Name,
+		tempUnit.FromCelsius(lastWeather.Temperature),
+		tempUnit.Symbol(),
+		tempUnit.FromCelsius(lastWeather.FeelsLike),
+		tempUnit.Symbol(),
+		lastWeather.Humidity,
+		windUnit.FromKph(lastWeather.WindSpeed),
+		windUnit.Symbol(),
+		lastWeather.WindDirection,
+		lastWeather.Pressure,
+		lastWeather.UVIndex,
+		lastWeather.Precipitation,
+		lastWeather.CloudCover,
+		lastWeather.Visibility,
+		lastWeather.Description,
+		unsubToken,
+	)
+}
+
+func (s *NotificationService) resolveDailyForecast(ctx context.Context, location *model.Location) (*locationWeather, error) {
+	forecast, err := s.weatherProvider.GetForecast(ctx, location.Query(), 1, model.DefaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if len(forecast.Days) == 0 {
+		return nil, fmt.Errorf("empty forecast for location %s", location.Name)
+	}
+
+	return &locationWeather{location: location, today: &forecast.Days[0], snapshot: model.SnapshotFromDailyForecast(forecast.Days[0])}, nil
+}
+
+func (s *NotificationService) localizeDailyForecast(ctx context.Context, weather *locationWeather, language string) (*locationWeather, error) {
+	localized, err := s.weatherProvider.GetForecast(ctx, weather.location.Query(), 1, language)
+	if err != nil {
+		return nil, err
+	}
+	if len(localized.Days) == 0 {
+		return nil, fmt.Errorf("empty forecast for location %s", weather.location.Name)
+	}
+
+	today := *weather.today
+	today.Description = localized.Days[0].Description
+	return &locationWeather{location: weather.location, today: &today, snapshot: weather.snapshot}, nil
+}
+
+func (s *NotificationService) composeDailyForecastText(weather *locationWeather, subscriber *model.Subscriber, emailText string, unsubToken string) string {
+	location, today := weather.location, *weather.today
+
+	tempUnit := subscriber.TemperatureUnit
+	return fmt.Sprintf(
+		emailText,
+		location.Name,
+		tempUnit.FromCelsius(today.MaxTemperature),
+		tempUnit.Symbol(),
+		tempUnit.FromCelsius(today.MinTemperature),
+		tempUnit.Symbol(),
+		today.ChanceOfRain,
+		today.Description,
+		unsubToken,
+	)
 }
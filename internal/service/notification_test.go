@@ -0,0 +1,550 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/managelink"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type notificationTestEnv struct {
+	driver          *txDriver
+	weatherProvider *MockWeatherProvider
+	locations       *MockLocationRepository
+	weather         *MockWeatherRepository
+	weatherAlerts   *MockWeatherAlertRepository
+	deliveries      *MockNotificationDeliveryRepository
+	subscribers     *MockSubscriberRepository
+	subscriptions   *MockSubscriptionRepository
+	conditions      *MockSubscriptionConditionRepository
+	tokens          *MockTokenRepository
+	sender          *MockEmailSender
+	signer          *signedtoken.Signer
+	service         *NotificationService
+
+	mu sync.Mutex
+	// recorded collects deliveries stored by the service, pipeline stores them from several goroutines
+	recorded []model.NotificationDelivery
+	// deliveredAt is last delivery time of subscriptions updated by conditional update of the service
+	deliveredAt map[int32]time.Time
+}
+
+var notificationEmailData = config.EmailData{From: "from@example.com", Subject: "weather", Text: "weather %s"}
+
+func newNotificationTestEnv(t *testing.T, cfg config.Notifications) *notificationTestEnv {
+	db, driver := newTxDB()
+	signer, err := signedtoken.New([]string{"k1:test-secret-test-secret-test-secret"})
+	require.NoError(t, err)
+	linker, err := managelink.New([]string{"m1:manage-secret-manage-secret-manage"}, "http://localhost/manage/", time.Hour)
+	require.NoError(t, err)
+
+	env := &notificationTestEnv{
+		driver:          driver,
+		weatherProvider: NewMockWeatherProvider(t),
+		locations:       NewMockLocationRepository(t),
+		weather:         NewMockWeatherRepository(t),
+		weatherAlerts:   NewMockWeatherAlertRepository(t),
+		deliveries:      NewMockNotificationDeliveryRepository(t),
+		subscribers:     NewMockSubscriberRepository(t),
+		subscriptions:   NewMockSubscriptionRepository(t),
+		conditions:      NewMockSubscriptionConditionRepository(t),
+		tokens:          NewMockTokenRepository(t),
+		sender:          NewMockEmailSender(t),
+		signer:          signer,
+	}
+	env.service = NewNotificationService(db, env.weatherProvider, env.locations, env.weather, NewMockAirQualityRepository(t),
+		env.weatherAlerts, env.deliveries, env.subscribers, env.subscriptions, env.conditions, env.tokens, env.sender,
+		linker, signer, "http://localhost/unsubscribe", cfg, discardLogger())
+	return env
+}
+
+func (env *notificationTestEnv) expectDeliveriesRecorded() {
+	env.deliveries.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, delivery *model.NotificationDelivery) (int64, error) {
+			env.mu.Lock()
+			defer env.mu.Unlock()
+			env.recorded = append(env.recorded, *delivery)
+			return int64(len(env.recorded)), nil
+		})
+}
+
+// expectConditionalDeliveryTime updates deliveredAt only while it still holds the previous value, as the repository does
+func (env *notificationTestEnv) expectConditionalDeliveryTime() {
+	env.deliveredAt = make(map[int32]time.Time)
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, id int32, previous time.Time, deliveredAt time.Time) (bool, error) {
+			env.mu.Lock()
+			defer env.mu.Unlock()
+			current, ok := env.deliveredAt[id]
+			if !ok {
+				current = time.Unix(0, 0)
+			}
+			if !current.Equal(previous) {
+				return false, nil
+			}
+			env.deliveredAt[id] = deliveredAt
+			return true, nil
+		})
+}
+
+// deliveryStatuses maps subscription id to statuses of its recorded deliveries
+func (env *notificationTestEnv) deliveryStatuses() map[int32][]model.DeliveryStatus {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	statuses := make(map[int32][]model.DeliveryStatus)
+	for _, delivery := range env.recorded {
+		statuses[delivery.SubscriptionId] = append(statuses[delivery.SubscriptionId], delivery.Status)
+	}
+	return statuses
+}
+
+// failSendTo makes sending to given addresses fail and records every address email was sent to
+func (env *notificationTestEnv) failSendTo(failing ...string) *[]string {
+	var sentTo []string
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, email dto.SimpleEmail) (string, error) {
+			if slices.Contains(failing, email.To) {
+				return "", errors.New("mailbox unavailable")
+			}
+			env.mu.Lock()
+			defer env.mu.Unlock()
+			sentTo = append(sentTo, email.To)
+			return "msg-" + email.To, nil
+		})
+	return &sentTo
+}
+
+func notificationTarget(id int32, location model.Location) *model.NotificationTarget {
+	return &model.NotificationTarget{
+		Subscription: model.Subscription{Id: id, SubscriberId: id, LocationId: location.Id, Status: model.SubscriptionStatus_Confirmed, ConditionActive: true, LastDeliveredAt: time.Unix(0, 0)},
+		Subscriber:   model.Subscriber{Id: id, Email: "user" + strconv.Itoa(int(id)) + "@example.com", Preferences: model.DefaultPreferences()},
+		Location:     location,
+		Token:        model.Token{Token: "token-" + strconv.Itoa(int(id)), SubscriptionId: id, Type: model.TokenType_Unsubscribe, ExpiresAt: model.TokenNeverExpires},
+	}
+}
+
+func stubWeather(_ context.Context, location *model.Location) (*locationWeather, error) {
+	current := &model.Weather{LocationId: location.Id}
+	return &locationWeather{location: location, current: current, snapshot: model.SnapshotFromWeather(*current)}, nil
+}
+
+func stubLocalize(_ context.Context, weather *locationWeather, _ string) (*locationWeather, error) {
+	return weather, nil
+}
+
+func stubText(_ *locationWeather, _ *model.Subscriber, emailText string, _ string) string {
+	return emailText
+}
+
+func alwaysDue(*model.Subscription, time.Time) (bool, error) {
+	return true, nil
+}
+
+func TestNotificationRunIsolatesFailingSubscription(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 2, SendWorkers: 2, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(0), 10).
+		Return([]*model.NotificationTarget{notificationTarget(1, kyiv), notificationTarget(2, kyiv), notificationTarget(3, kyiv)}, nil)
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	env.expectConditionalDeliveryTime()
+	sentTo := env.failSendTo("user2@example.com")
+	env.expectDeliveriesRecorded()
+
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, notificationEmailData, stubWeather, stubLocalize, stubText, alwaysDue)
+
+	require.ElementsMatch(t, []string{"user1@example.com", "user3@example.com"}, *sentTo)
+	require.Equal(t, map[int32][]model.DeliveryStatus{
+		1: {model.DeliveryStatus_Sent},
+		2: {model.DeliveryStatus_Failed},
+		3: {model.DeliveryStatus_Sent},
+	}, env.deliveryStatuses())
+	// failed send releases its claim, so the subscription is due again on the next run
+	require.True(t, env.deliveredAt[2].Equal(time.Unix(0, 0)))
+	require.False(t, env.deliveredAt[1].Equal(time.Unix(0, 0)))
+	// claim and result of every subscription are committed separately
+	require.Equal(t, int32(6), env.driver.commits.Load())
+	require.Zero(t, env.driver.rollbacks.Load())
+}
+
+func TestNotificationRunIsolatesFailingLocation(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 2, SendWorkers: 2, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	lviv := model.Location{Id: 4, Name: "Lviv"}
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(0), 10).
+		Return([]*model.NotificationTarget{notificationTarget(1, kyiv), notificationTarget(2, lviv), notificationTarget(3, lviv)}, nil)
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, int32(1)).Return(nil, nil)
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, int32(1), mock.Anything, mock.Anything).Return(true, nil)
+	sentTo := env.failSendTo()
+	env.expectDeliveriesRecorded()
+
+	resolve := func(ctx context.Context, location *model.Location) (*locationWeather, error) {
+		if location.Id == lviv.Id {
+			return nil, errors.New("provider unavailable")
+		}
+		return stubWeather(ctx, location)
+	}
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, notificationEmailData, resolve, stubLocalize, stubText, alwaysDue)
+
+	require.Equal(t, []string{"user1@example.com"}, *sentTo)
+	require.Equal(t, map[int32][]model.DeliveryStatus{
+		1: {model.DeliveryStatus_Sent},
+		2: {model.DeliveryStatus_Failed},
+		3: {model.DeliveryStatus_Failed},
+	}, env.deliveryStatuses())
+}
+
+func TestWeatherAlertsIsolateFailingSubscription(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{SendTimeout: time.Second})
+	kyiv := &model.Location{Id: 3, Name: "Kyiv", Latitude: 50.45, Longitude: 30.52}
+	env.subscriptions.EXPECT().FindAllByFrequencyAndConfirmedStatus(mock.Anything, mock.Anything, model.Frequency_Alerts).
+		Return([]*model.Subscription{{Id: 1, SubscriberId: 1, LocationId: 3}, {Id: 2, SubscriberId: 2, LocationId: 3}}, nil)
+	env.locations.EXPECT().FindById(mock.Anything, mock.Anything, int32(3)).Return(kyiv, nil)
+	env.weatherProvider.EXPECT().GetAlerts(mock.Anything, kyiv.Query()).
+		Return([]model.WeatherAlert{{ExternalId: "storm-1", Headline: "Storm", Expires: time.Now().Add(time.Hour)}}, nil)
+	env.weatherAlerts.EXPECT().SaveIfAbsent(mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	env.deliveries.EXPECT().ExistsSent(mock.Anything, mock.Anything, mock.Anything, model.NotificationKind_WeatherAlert, "storm-1").Return(false, nil)
+	for _, id := range []int32{1, 2} {
+		env.subscribers.EXPECT().FindById(mock.Anything, mock.Anything, id).
+			Return(&model.Subscriber{Id: id, Email: "user" + strconv.Itoa(int(id)) + "@example.com"}, nil)
+		env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, id, model.TokenType_Unsubscribe).
+			Return(&model.Token{Token: "token", SubscriptionId: id, Type: model.TokenType_Unsubscribe, ExpiresAt: model.TokenNeverExpires}, nil)
+	}
+	sentTo := env.failSendTo("user1@example.com")
+	env.expectDeliveriesRecorded()
+
+	env.service.SendWeatherAlerts(context.Background(), notificationEmailData)
+
+	require.Equal(t, []string{"user2@example.com"}, *sentTo)
+	require.Equal(t, map[int32][]model.DeliveryStatus{
+		1: {model.DeliveryStatus_Failed},
+		2: {model.DeliveryStatus_Sent},
+	}, env.deliveryStatuses())
+	for _, delivery := range env.recorded {
+		require.Equal(t, "storm-1", delivery.Reference)
+	}
+	require.Equal(t, int32(1), env.driver.commits.Load())
+	require.Equal(t, int32(1), env.driver.rollbacks.Load())
+}
+
+func TestNotificationRunSkipsSubscriptionDeliveredByOverlappingRun(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	target := notificationTarget(1, kyiv)
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(0), 10).
+		Return([]*model.NotificationTarget{target}, nil)
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, int32(1)).Return(nil, nil)
+	// delivery time read by this run has been moved on by the other run, so the conditional update matches no row
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, int32(1), target.Subscription.LastDeliveredAt, mock.Anything).
+		Return(false, nil)
+
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, notificationEmailData, stubWeather, stubLocalize, stubText, alwaysDue)
+
+	env.sender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	env.deliveries.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	require.Equal(t, int32(1), env.driver.commits.Load())
+}
+
+func TestDailyRunCallsProviderOncePerLocationAndLanguage(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 2, WeatherWorkers: 2, SendWorkers: 2, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv", Latitude: 50.45, Longitude: 30.52}
+	lviv := model.Location{Id: 4, Name: "Lviv", Latitude: 49.84, Longitude: 24.03}
+	targets := []*model.NotificationTarget{
+		notificationTarget(1, kyiv), notificationTarget(2, kyiv), notificationTarget(3, kyiv), notificationTarget(4, kyiv), notificationTarget(5, lviv),
+	}
+	for i, language := range []string{"en", "uk", "uk", "de", "uk"} {
+		targets[i].Subscriber.Language = language
+	}
+	// page size of 2 spreads subscriptions of one location and language over several pages
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Daily, int32(0), 2).Return(targets[0:2], nil)
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Daily, int32(2), 2).Return(targets[2:4], nil)
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Daily, int32(4), 2).Return(targets[4:], nil)
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	env.expectDeliveriesRecorded()
+
+	descriptions := map[string]string{"en": "Rain", "uk": "Дощ", "de": "Regen"}
+	for _, location := range []model.Location{kyiv, lviv} {
+		for _, language := range []string{"en", "uk", "de"} {
+			if location.Id == lviv.Id && language == "de" {
+				continue
+			}
+			env.weatherProvider.EXPECT().GetForecast(mock.Anything, location.Query(), 1, language).
+				Return(&model.Forecast{Location: location, Days: []model.DailyForecast{{MaxTemperature: 20, MinTemperature: 10, Description: descriptions[language]}}}, nil).
+				Once()
+		}
+	}
+	texts := make(map[string]string)
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, email dto.SimpleEmail) (string, error) {
+			env.mu.Lock()
+			defer env.mu.Unlock()
+			texts[email.To] = email.Text
+			return "msg-" + email.To, nil
+		})
+
+	emailData := config.EmailData{From: "from@example.com", Subject: "forecast", Text: "%v %v%v %v%v %v %v %v"}
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Daily, model.NotificationKind_Daily, emailData,
+		env.service.resolveDailyForecast, env.service.localizeDailyForecast, env.service.composeDailyForecastText, alwaysDue)
+
+	require.Len(t, texts, 5)
+	for i, target := range targets {
+		require.Contains(t, texts[target.Subscriber.Email], descriptions[target.Subscriber.Language], "subscription %d", i+1)
+	}
+}
+
+func TestDailyRunFailsLanguagesOfLocationWithFailedForecast(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv", Latitude: 50.45, Longitude: 30.52}
+	targets := []*model.NotificationTarget{notificationTarget(1, kyiv), notificationTarget(2, kyiv)}
+	targets[1].Subscriber.Language = "uk"
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Daily, int32(0), 10).Return(targets, nil)
+	env.weatherProvider.EXPECT().GetForecast(mock.Anything, kyiv.Query(), 1, model.DefaultLanguage).
+		Return(nil, errors.New("provider unavailable")).Once()
+	env.expectDeliveriesRecorded()
+
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Daily, model.NotificationKind_Daily, notificationEmailData,
+		env.service.resolveDailyForecast, env.service.localizeDailyForecast, env.service.composeDailyForecastText, alwaysDue)
+
+	require.Equal(t, map[int32][]model.DeliveryStatus{
+		1: {model.DeliveryStatus_Failed},
+		2: {model.DeliveryStatus_Failed},
+	}, env.deliveryStatuses())
+}
+
+func TestNotificationRunReadsPagesAfterLastId(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 2, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	// ids have gaps, next page starts after the last id of the previous one rather than after an offset
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(0), 2).
+		Return([]*model.NotificationTarget{notificationTarget(2, kyiv), notificationTarget(5, kyiv)}, nil).Once()
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(5), 2).
+		Return([]*model.NotificationTarget{notificationTarget(7, kyiv), notificationTarget(9, kyiv)}, nil).Once()
+	// full last page can not tell that nothing follows, so one more empty page is read
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(9), 2).
+		Return(nil, nil).Once()
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	sentTo := env.failSendTo()
+	env.expectDeliveriesRecorded()
+
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, notificationEmailData, stubWeather, stubLocalize, stubText, alwaysDue)
+
+	require.ElementsMatch(t, []string{"user2@example.com", "user5@example.com", "user7@example.com", "user9@example.com"}, *sentTo)
+}
+
+func TestNotificationRunStopsAfterPartialPage(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 2, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(0), 2).
+		Return([]*model.NotificationTarget{notificationTarget(1, kyiv), notificationTarget(2, kyiv)}, nil).Once()
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(2), 2).
+		Return([]*model.NotificationTarget{notificationTarget(3, kyiv)}, nil).Once()
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	sentTo := env.failSendTo()
+	env.expectDeliveriesRecorded()
+
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, notificationEmailData, stubWeather, stubLocalize, stubText, alwaysDue)
+
+	require.Len(t, *sentTo, 3)
+}
+
+func TestNotificationRunSendsOnlyDueSubscriptions(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	lviv := model.Location{Id: 4, Name: "Lviv"}
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Custom, int32(0), 10).
+		Return([]*model.NotificationTarget{notificationTarget(1, kyiv), notificationTarget(2, kyiv), notificationTarget(3, kyiv), notificationTarget(4, lviv)}, nil)
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, int32(1)).Return(nil, nil)
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, int32(1), mock.Anything, mock.Anything).Return(true, nil)
+	sentTo := env.failSendTo()
+	env.expectDeliveriesRecorded()
+
+	var resolved []int32
+	resolve := func(ctx context.Context, location *model.Location) (*locationWeather, error) {
+		resolved = append(resolved, location.Id)
+		return stubWeather(ctx, location)
+	}
+	isDue := func(subscription *model.Subscription, _ time.Time) (bool, error) {
+		switch subscription.Id {
+		case 1:
+			return true, nil
+		case 2:
+			return false, errors.New("invalid schedule")
+		default:
+			return false, nil
+		}
+	}
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Custom, model.NotificationKind_Custom, notificationEmailData, resolve, stubLocalize, stubText, isDue)
+
+	require.Equal(t, []string{"user1@example.com"}, *sentTo)
+	// weather is resolved only for locations of due subscriptions and nothing is recorded for the ones not due
+	require.Equal(t, []int32{kyiv.Id}, resolved)
+	require.Equal(t, map[int32][]model.DeliveryStatus{1: {model.DeliveryStatus_Sent}}, env.deliveryStatuses())
+}
+
+func TestNotificationEmailCarriesSignedUnsubscribeToken(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	target := notificationTarget(7, kyiv)
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(0), 10).
+		Return([]*model.NotificationTarget{target}, nil)
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, int32(7)).Return(nil, nil)
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, int32(7), mock.Anything, mock.Anything).Return(true, nil)
+	env.expectDeliveriesRecorded()
+	var sent dto.SimpleEmail
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, email dto.SimpleEmail) (string, error) {
+			sent = email
+			return "msg-1", nil
+		})
+
+	emailData := config.EmailData{From: "from@example.com", Subject: "weather", Text: "unsubscribe: %s"}
+	text := func(_ *locationWeather, _ *model.Subscriber, emailText string, unsubToken string) string {
+		return fmt.Sprintf(emailText, unsubToken)
+	}
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, emailData, stubWeather, stubLocalize, text, alwaysDue)
+
+	// the joined unsubscribe token is sent signed, in the text as well as in one-click header
+	header := sent.Headers["List-Unsubscribe"]
+	require.True(t, strings.HasPrefix(header, "<http://localhost/unsubscribe/") && strings.HasSuffix(header, ">"))
+	signed := strings.TrimSuffix(strings.TrimPrefix(header, "<http://localhost/unsubscribe/"), ">")
+	require.NotEqual(t, target.Token.Token, signed)
+	require.True(t, strings.HasPrefix(sent.Text, "unsubscribe: "+signed))
+	claims, err := env.signer.Verify(signed, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, target.Token.Token, claims.Id)
+	require.Equal(t, target.Subscription.Id, claims.Subject)
+	require.Equal(t, string(model.TokenType_Unsubscribe), claims.Purpose)
+}
+
+func TestNotificationNotResentWhenRecordingFailsAfterSend(t *testing.T) {
+	env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+	kyiv := model.Location{Id: 3, Name: "Kyiv"}
+	// last delivery time is kept as the database would, an update is visible only once its transaction is committed
+	deliveredAt := time.Unix(0, 0)
+	var pending *time.Time
+	env.driver.onEnd = func(committed bool) {
+		if committed && pending != nil {
+			deliveredAt = *pending
+		}
+		pending = nil
+	}
+	env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, int32(1), mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, _ int32, previous time.Time, now time.Time) (bool, error) {
+			if !previous.Equal(deliveredAt) {
+				return false, nil
+			}
+			pending = &now
+			return true, nil
+		})
+	env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, model.Frequency_Hourly, int32(0), 10).
+		RunAndReturn(func(context.Context, sqlutil.SQLExecutor, model.Frequency, int32, int) ([]*model.NotificationTarget, error) {
+			target := notificationTarget(1, kyiv)
+			target.Subscription.LastDeliveredAt = deliveredAt
+			return []*model.NotificationTarget{target}, nil
+		})
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, int32(1)).Return(nil, nil)
+	env.deliveries.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+	var sentTo []string
+	env.sender.EXPECT().Send(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, email dto.SimpleEmail) (string, error) {
+			sentTo = append(sentTo, email.To)
+			// the first commit after email is out fails
+			env.driver.failCommit.Store(env.driver.commits.Load() + 1)
+			return "msg-1", nil
+		})
+
+	isDue := func(subscription *model.Subscription, now time.Time) (bool, error) {
+		return subscription.HourlyDeliveryDue(now), nil
+	}
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, notificationEmailData, stubWeather, stubLocalize, stubText, isDue)
+	require.Equal(t, []string{"user1@example.com"}, sentTo)
+
+	env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, notificationEmailData, stubWeather, stubLocalize, stubText, isDue)
+
+	require.Equal(t, []string{"user1@example.com"}, sentTo)
+	require.False(t, deliveredAt.Equal(time.Unix(0, 0)))
+}
+
+func TestDescriptionConditionMatchesEnglishForLocalizedSubscriber(t *testing.T) {
+	kyiv := model.Location{Id: 3, Name: "Kyiv", Latitude: 50.45, Longitude: 30.52}
+	rain := []model.Condition{{SubscriptionId: 1, Field: model.ConditionField_Description, Operator: model.ConditionOperator_Contains, Text: "rain"}}
+	tests := []struct {
+		name      string
+		frequency model.Frequency
+		// every verb is formatted with %v, the description is the one but last argument
+		text   string
+		expect func(env *notificationTestEnv)
+		run    func(env *notificationTestEnv, emailData config.EmailData)
+	}{
+		{
+			name:      "hourly",
+			frequency: model.Frequency_Hourly,
+			text:      strings.Repeat("%v ", 14) + "|%v|%v",
+			expect: func(env *notificationTestEnv) {
+				env.weather.EXPECT().FindLastUpdatedByLocationId(mock.Anything, mock.Anything, kyiv.Id).
+					Return(&model.Weather{LocationId: kyiv.Id, Description: "Light rain", LastUpdated: time.Now()}, nil)
+				env.weatherProvider.EXPECT().GetCurrentWeather(mock.Anything, kyiv.Query(), "uk").
+					Return(&model.WeatherWithLocation{Weather: model.Weather{Description: "Легкий дощ"}}, nil).Once()
+			},
+			run: func(env *notificationTestEnv, emailData config.EmailData) {
+				env.service.sendDueNotifications(context.Background(), model.Frequency_Hourly, model.NotificationKind_Hourly, emailData,
+					env.service.resolveCurrentWeather, env.service.localizeCurrentWeather, env.service.composeCurrentWeatherText, alwaysDue)
+			},
+		},
+		{
+			name:      "daily",
+			frequency: model.Frequency_Daily,
+			text:      strings.Repeat("%v ", 6) + "|%v|%v",
+			expect: func(env *notificationTestEnv) {
+				env.weatherProvider.EXPECT().GetForecast(mock.Anything, kyiv.Query(), 1, model.DefaultLanguage).
+					Return(&model.Forecast{Days: []model.DailyForecast{{Description: "Light rain"}}}, nil).Once()
+				env.weatherProvider.EXPECT().GetForecast(mock.Anything, kyiv.Query(), 1, "uk").
+					Return(&model.Forecast{Days: []model.DailyForecast{{Description: "Легкий дощ"}}}, nil).Once()
+			},
+			run: func(env *notificationTestEnv, emailData config.EmailData) {
+				env.service.sendDueNotifications(context.Background(), model.Frequency_Daily, model.NotificationKind_Daily, emailData,
+					env.service.resolveDailyForecast, env.service.localizeDailyForecast, env.service.composeDailyForecastText, alwaysDue)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newNotificationTestEnv(t, config.Notifications{PageSize: 10, WeatherWorkers: 1, SendWorkers: 1, SendTimeout: time.Second})
+			target := notificationTarget(1, kyiv)
+			target.Subscriber.Language = "uk"
+			env.subscriptions.EXPECT().FindNotificationTargetsPage(mock.Anything, mock.Anything, tt.frequency, int32(0), 10).
+				Return([]*model.NotificationTarget{target}, nil)
+			env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, int32(1)).Return(rain, nil)
+			env.subscriptions.EXPECT().UpdateLastDeliveredAt(mock.Anything, mock.Anything, int32(1), mock.Anything, mock.Anything).Return(true, nil)
+			env.expectDeliveriesRecorded()
+			tt.expect(env)
+			var text string
+			env.sender.EXPECT().Send(mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, email dto.SimpleEmail) (string, error) {
+					text = email.Text
+					return "msg-1", nil
+				})
+
+			tt.run(env, config.EmailData{From: "from@example.com", Subject: "weather", Text: tt.text})
+
+			require.Contains(t, text, "|Легкий дощ|")
+			require.Equal(t, map[int32][]model.DeliveryStatus{1: {model.DeliveryStatus_Sent}}, env.deliveryStatuses())
+		})
+	}
+}
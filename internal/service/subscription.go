@@ -8,10 +8,13 @@ import (
 	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
 	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/schedule"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/mapper"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
 	"github.com/google/uuid"
 	"log/slog"
+	"strings"
 	"time"
 )
 
@@ -19,6 +22,8 @@ type SubscriberRepository interface {
 	Save(context.Context, sqlutil.SQLExecutor, *model.Subscriber) (int32, error)
 	FindByEmail(context.Context, sqlutil.SQLExecutor, string) (*model.Subscriber, error)
 	FindById(context.Context, sqlutil.SQLExecutor, int32) (*model.Subscriber, error)
+	UpdatePreferences(context.Context, sqlutil.SQLExecutor, int32, model.Preferences) error
+	DeleteOrphanedBatch(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)
 }
 
 type SubscriptionRepository interface {
@@ -28,12 +33,28 @@ type SubscriptionRepository interface {
 	DeleteById(context.Context, sqlutil.SQLExecutor, int32) error
 	Update(context.Context, sqlutil.SQLExecutor, *model.Subscription) (*model.Subscription, error)
 	FindAllByFrequencyAndConfirmedStatus(context.Context, sqlutil.SQLExecutor, model.Frequency) ([]*model.Subscription, error)
+	FindNotificationTargetsPage(context.Context, sqlutil.SQLExecutor, model.Frequency, int32, int) ([]*model.NotificationTarget, error)
+	FindAllWithAqiThresholdAndConfirmedStatus(context.Context, sqlutil.SQLExecutor) ([]*model.Subscription, error)
+	UpdateAqiAlertActive(context.Context, sqlutil.SQLExecutor, int32, bool) error
+	UpdateLastDeliveredAt(context.Context, sqlutil.SQLExecutor, int32, time.Time, time.Time) (bool, error)
+	UpdateConditionActive(context.Context, sqlutil.SQLExecutor, int32, bool) error
+	FindAllBySubscriberId(context.Context, sqlutil.SQLExecutor, int32) ([]*model.Subscription, error)
+	DeleteAbandonedPendingBatch(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)
+}
+
+type SubscriptionConditionRepository interface {
+	Save(context.Context, sqlutil.SQLExecutor, *model.Condition) (int32, error)
+	FindAllBySubscriptionId(context.Context, sqlutil.SQLExecutor, int32) ([]model.Condition, error)
+	DeleteAllBySubscriptionId(context.Context, sqlutil.SQLExecutor, int32) error
 }
 
 type TokenRepository interface {
 	Save(context.Context, sqlutil.SQLExecutor, *model.Token) error
 	FindByToken(context.Context, sqlutil.SQLExecutor, string) (*model.Token, error)
 	FindBySubscriptionIdAndType(context.Context, sqlutil.SQLExecutor, int32, model.TokenType) (*model.Token, error)
+	CountBySubscriptionIdAndTypeCreatedAfter(context.Context, sqlutil.SQLExecutor, int32, model.TokenType, time.Time) (int, error)
+	DeleteExpiredBatch(context.Context, sqlutil.SQLExecutor, time.Time, int) (int64, error)
+	MarkUsed(context.Context, sqlutil.SQLExecutor, string, time.Time) (bool, error)
 }
 
 type SubscriptionService struct {
@@ -42,11 +63,17 @@ type SubscriptionService struct {
 	locationRepository      LocationRepository
 	subscriberRepository    SubscriberRepository
 	subscriptionRepository  SubscriptionRepository
+	conditionRepository     SubscriptionConditionRepository
 	tokenRepository         TokenRepository
-	emailSender             EmailSender
+	emailOutboxRepository   EmailOutboxRepository
+	manageLinker            ManageLinker
+	tokenSigner             TokenSigner
+	tokens                  config.Tokens
+	confirmation            config.Confirmation
 	confirmEmailData        config.EmailData
 	confirmSuccessEmailData config.EmailData
 	unsubEmailData          config.EmailData
+	changeEmailData         config.EmailData
 	log                     *slog.Logger
 }
 
@@ -55,11 +82,17 @@ func NewSubscriptionService(db *sql.DB,
 	locationRepository LocationRepository,
 	subscriberRepository SubscriberRepository,
 	subscriptionRepository SubscriptionRepository,
+	conditionRepository SubscriptionConditionRepository,
 	tokenRepository TokenRepository,
-	emailSender EmailSender,
+	emailOutboxRepository EmailOutboxRepository,
+	manageLinker ManageLinker,
+	tokenSigner TokenSigner,
+	tokens config.Tokens,
+	confirmation config.Confirmation,
 	confirmEmailData config.EmailData,
 	confirmSuccessEmailData config.EmailData,
 	unsubEmailData config.EmailData,
+	changeEmailData config.EmailData,
 	log *slog.Logger) *SubscriptionService {
 	return &SubscriptionService{
 		db:                      db,
@@ -67,51 +100,48 @@ func NewSubscriptionService(db *sql.DB,
 		locationRepository:      locationRepository,
 		subscriberRepository:    subscriberRepository,
 		subscriptionRepository:  subscriptionRepository,
+		conditionRepository:     conditionRepository,
 		tokenRepository:         tokenRepository,
-		emailSender:             emailSender,
+		emailOutboxRepository:   emailOutboxRepository,
+		manageLinker:            manageLinker,
+		tokenSigner:             tokenSigner,
+		tokens:                  tokens,
+		confirmation:            confirmation,
 		confirmEmailData:        confirmEmailData,
 		confirmSuccessEmailData: confirmSuccessEmailData,
 		unsubEmailData:          unsubEmailData,
+		changeEmailData:         changeEmailData,
 		log:                     log,
 	}
 }
 
 func (s *SubscriptionService) Subscribe(ctx context.Context, subReq dto.SubscriptionRequest) error {
 	err := sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
-		loc, errIn := s.locationRepository.FindByName(ctx, tx, subReq.City)
+		locId, errIn := s.resolveLocation(ctx, tx, subReq.City, subReq.LocationKey)
 		if errIn != nil {
 			return errIn
 		}
 
-		var locId int32
-		if loc != nil {
-			locId = loc.Id
-		} else {
-			weather, errIn := s.weatherProvider.GetCurrentWeather(subReq.City)
-			if errIn != nil {
-				if errors.Is(errIn, commonerrors.ErrLocationNotFound) {
-					return errIn
-				} else {
-					return fmt.Errorf("unable to validate location err:%w", errIn)
-				}
-			}
-			locId, errIn = s.locationRepository.Save(ctx, tx, &weather.Location)
-			if errIn != nil {
-				return errIn
-			}
-		}
-
 		subscriber, errIn := s.subscriberRepository.FindByEmail(ctx, tx, subReq.Email)
 		if errIn != nil {
 			return errIn
 		}
 		var subscriberId int32
+		var prefs model.Preferences
 		if subscriber != nil {
 			subscriberId = subscriber.Id
+			prefs = mapper.ApplyPreferencesRequest(subscriber.Preferences, subReq.Preferences)
+			if prefs != subscriber.Preferences {
+				if errIn = s.subscriberRepository.UpdatePreferences(ctx, tx, subscriberId, prefs); errIn != nil {
+					return errIn
+				}
+			}
 		} else {
+			prefs = mapper.PreferencesRequestToPreferences(subReq.Preferences)
 			subscriberToSave := model.Subscriber{
-				Email:     subReq.Email,
-				CreatedAt: time.Now().UTC(),
+				Email:       subReq.Email,
+				Preferences: prefs,
+				CreatedAt:   time.Now().UTC(),
 			}
 			subscriberId, errIn = s.subscriberRepository.Save(ctx, tx, &subscriberToSave)
 			if errIn != nil {
@@ -119,55 +149,141 @@ func (s *SubscriptionService) Subscribe(ctx context.Context, subReq dto.Subscrip
 			}
 		}
 
-		subscription, errIn := s.subscriptionRepository.FindBySubscriberIdAndLocationId(ctx, tx, subscriberId, locId)
+		existing, errIn := s.subscriptionRepository.FindBySubscriberIdAndLocationId(ctx, tx, subscriberId, locId)
 		if errIn != nil {
 			return errIn
 		}
-		if subscription != nil {
+		if existing != nil && existing.Status != model.SubscriptionStatus_Pending {
 			return commonerrors.ErrSubscriptionAlreadyExists
 		}
 
-		subscription = &model.Subscription{
-			Id:           0,
-			SubscriberId: subscriberId,
-			LocationId:   locId,
-			Frequency:    model.Frequency(subReq.Frequency),
-			Status:       model.SubscriptionStatus_Pending,
-			CreatedAt:    time.Now().UTC(),
-			UpdatedAt:    time.Now().UTC(),
+		subscription, conditions, errIn := s.buildSubscription(ctx, tx, subReq, subscriberId, locId, prefs)
+		if errIn != nil {
+			return errIn
+		}
+		if existing != nil {
+			// posting again replaces settings of the pending subscription, so the confirmation covers what was submitted last
+			subscription.Id = existing.Id
+			subscription.LastDeliveredAt = existing.LastDeliveredAt
+			subscription.CreatedAt = existing.CreatedAt
+			if _, errIn = s.subscriptionRepository.Update(ctx, tx, subscription); errIn != nil {
+				return errIn
+			}
+			if errIn = s.conditionRepository.DeleteAllBySubscriptionId(ctx, tx, existing.Id); errIn != nil {
+				return errIn
+			}
+			if errIn = s.saveConditions(ctx, tx, existing.Id, conditions); errIn != nil {
+				return errIn
+			}
+			return s.sendConfirmation(ctx, tx, existing.Id, subscriberId, subReq.Email)
 		}
+
 		subscriptionId, errIn := s.subscriptionRepository.Save(ctx, tx, subscription)
 		if errIn != nil {
 			return errIn
 		}
+		if errIn = s.saveConditions(ctx, tx, subscriptionId, conditions); errIn != nil {
+			return errIn
+		}
 
-		token := model.Token{
-			Token:          uuid.NewString(),
-			SubscriptionId: subscriptionId,
-			Type:           model.TokenType_Confirmation,
-			CreatedAt:      time.Now().UTC(),
-			ExpiresAt:      time.Now().UTC().Add(15 * time.Minute),
-			UsedAt:         time.Unix(0, 0),
+		return s.sendConfirmation(ctx, tx, subscriptionId, subscriberId, subReq.Email)
+	})
+	if err != nil {
+		s.log.Info("rollback transaction")
+		return err
+	}
+	s.log.Info("transaction commited successfully")
+
+	return nil
+}
+
+// buildSubscription validates requested settings into a pending subscription, conditions are converted to metric units of stored weather
+func (s *SubscriptionService) buildSubscription(ctx context.Context, tx *sql.Tx, subReq dto.SubscriptionRequest, subscriberId int32, locId int32, prefs model.Preferences) (*model.Subscription, []model.Condition, error) {
+	timezone, err := s.resolveTimezone(ctx, tx, subReq.Timezone, locId)
+	if err != nil {
+		return nil, nil, err
+	}
+	deliveryHour := int32(model.DefaultDeliveryHour)
+	if subReq.DeliveryHour != nil {
+		deliveryHour = *subReq.DeliveryHour
+	}
+	if subReq.Schedule != "" {
+		if _, err = schedule.Parse(subReq.Schedule, deliveryHour); err != nil {
+			return nil, nil, err
 		}
-		if errIn = s.tokenRepository.Save(ctx, tx, &token); errIn != nil {
-			return errIn
+	}
+	conditions := make([]model.Condition, 0, len(subReq.Conditions))
+	for _, expr := range subReq.Conditions {
+		condition, ok := model.ParseCondition(expr)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s", commonerrors.ErrInvalidCondition, expr)
+		}
+		if !condition.SupportedBy(model.Frequency(subReq.Frequency)) {
+			return nil, nil, fmt.Errorf("%w: %s is not available for %s notifications", commonerrors.ErrInvalidCondition, condition.Field, subReq.Frequency)
 		}
+		conditions = append(conditions, condition.ToMetric(prefs))
+	}
+	conditionLogic := model.ConditionLogic_And
+	if subReq.ConditionLogic != "" {
+		conditionLogic = model.ConditionLogic(subReq.ConditionLogic)
+	}
 
-		email := dto.SimpleEmail{
-			From:    s.confirmEmailData.From,
-			To:      subReq.Email,
-			Subject: s.confirmEmailData.Subject,
-			Text: fmt.Sprintf(
-				s.confirmEmailData.Text,
-				token.Token,
-			),
+	now := time.Now().UTC()
+	return &model.Subscription{
+		SubscriberId:    subscriberId,
+		LocationId:      locId,
+		Frequency:       model.Frequency(subReq.Frequency),
+		Status:          model.SubscriptionStatus_Pending,
+		AqiThreshold:    subReq.AqiThreshold,
+		DeliveryHour:    deliveryHour,
+		Timezone:        timezone,
+		Schedule:        subReq.Schedule,
+		ConditionLogic:  conditionLogic,
+		LastDeliveredAt: time.Unix(0, 0),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}, conditions, nil
+}
+
+func (s *SubscriptionService) saveConditions(ctx context.Context, tx *sql.Tx, subscriptionId int32, conditions []model.Condition) error {
+	for i := range conditions {
+		conditions[i].SubscriptionId = subscriptionId
+		if _, err := s.conditionRepository.Save(ctx, tx, &conditions[i]); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		errIn = s.emailSender.Send(ctx, email)
+// ResendConfirmation answers the same way for unknown emails and rate limited subscriptions, so caller cannot tell whether email is subscribed
+func (s *SubscriptionService) ResendConfirmation(ctx context.Context, email string) error {
+	err := sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		subscriber, errIn := s.subscriberRepository.FindByEmail(ctx, tx, email)
 		if errIn != nil {
 			return errIn
 		}
-		s.log.Info("confirmation email is send")
+		if subscriber == nil {
+			s.log.Info("confirmation resend requested for unknown email")
+			return nil
+		}
+
+		subscriptions, errIn := s.subscriptionRepository.FindAllBySubscriberId(ctx, tx, subscriber.Id)
+		if errIn != nil {
+			return errIn
+		}
+		for _, subscription := range subscriptions {
+			if subscription.Status != model.SubscriptionStatus_Pending {
+				continue
+			}
+			errIn = s.sendConfirmation(ctx, tx, subscription.Id, subscriber.Id, subscriber.Email)
+			if errors.Is(errIn, commonerrors.ErrTooManyRequests) {
+				s.log.Info("confirmation resend is rate limited", "subscriptionId", subscription.Id)
+				continue
+			}
+			if errIn != nil {
+				return errIn
+			}
+		}
 
 		return nil
 	})
@@ -180,9 +296,133 @@ func (s *SubscriptionService) Subscribe(ctx context.Context, subReq dto.Subscrip
 	return nil
 }
 
+// sendConfirmation issues fresh confirmation token, earlier tokens stay valid until they expire
+func (s *SubscriptionService) sendConfirmation(ctx context.Context, tx *sql.Tx, subscriptionId int32, subscriberId int32, emailAddress string) error {
+	now := time.Now().UTC()
+	last, err := s.tokenRepository.FindBySubscriptionIdAndType(ctx, tx, subscriptionId, model.TokenType_Confirmation)
+	if err != nil {
+		return err
+	}
+	if last != nil {
+		if now.Sub(last.CreatedAt) < s.confirmation.ResendInterval {
+			return fmt.Errorf("%w: confirmation was sent less than %s ago", commonerrors.ErrTooManyRequests, s.confirmation.ResendInterval)
+		}
+		sent, err := s.tokenRepository.CountBySubscriptionIdAndTypeCreatedAfter(ctx, tx, subscriptionId, model.TokenType_Confirmation, now.Add(-s.confirmation.ResendWindow))
+		if err != nil {
+			return err
+		}
+		if sent >= s.confirmation.ResendLimit {
+			return fmt.Errorf("%w: %d confirmations sent within %s", commonerrors.ErrTooManyRequests, sent, s.confirmation.ResendWindow)
+		}
+	}
+
+	token := model.Token{
+		Token:          uuid.NewString(),
+		SubscriptionId: subscriptionId,
+		Type:           model.TokenType_Confirmation,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.confirmation.TokenTTL),
+	}
+	if err = s.tokenRepository.Save(ctx, tx, &token); err != nil {
+		return err
+	}
+
+	email := dto.SimpleEmail{
+		From:    s.confirmEmailData.From,
+		To:      emailAddress,
+		Subject: s.confirmEmailData.Subject,
+		Text: withManageLink(fmt.Sprintf(
+			s.confirmEmailData.Text,
+			signToken(s.tokenSigner, &token),
+		), s.manageLinker.Link(subscriberId)),
+	}
+
+	if err = enqueueEmail(ctx, tx, s.emailOutboxRepository, email); err != nil {
+		return err
+	}
+	s.log.Info("confirmation email is queued")
+
+	return nil
+}
+
+// resolveLocation returns id of the location picked from search results or validates the free-text city
+// with the weather provider, either way the location is stored only now and ends up with its timezone known
+func (s *SubscriptionService) resolveLocation(ctx context.Context, tx *sql.Tx, city string, locationKey string) (int32, error) {
+	query := city
+	var loc *model.Location
+	if locationKey != "" {
+		var err error
+		loc, err = s.locationRepository.FindByKey(ctx, tx, locationKey)
+		if err != nil {
+			return 0, err
+		}
+		if loc != nil && loc.TzId != "" {
+			return loc.Id, nil
+		}
+		if loc == nil {
+			if loc, err = s.findSearchResult(ctx, locationKey); err != nil {
+				return 0, err
+			}
+		}
+		query = loc.Query()
+	}
+
+	weather, err := s.weatherProvider.GetCurrentWeather(ctx, query, model.DefaultLanguage)
+	if err != nil {
+		if errors.Is(err, commonerrors.ErrLocationNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("unable to validate location err:%w", err)
+	}
+
+	if loc != nil {
+		// provider queried by coordinates may not know their timezone, the one of the search result is kept then
+		if weather.Location.TzId != "" {
+			loc.TzId = weather.Location.TzId
+		}
+		return upsertLocation(ctx, tx, s.locationRepository, loc)
+	}
+	return upsertLocation(ctx, tx, s.locationRepository, &weather.Location)
+}
+
+// findSearchResult repeats the search the key came from, search results are cached so it normally does not reach the provider
+func (s *SubscriptionService) findSearchResult(ctx context.Context, locationKey string) (*model.Location, error) {
+	name, _, _ := strings.Cut(locationKey, "|")
+	locations, err := s.weatherProvider.SearchLocations(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, location := range locations {
+		if location.Key == locationKey {
+			return &location, nil
+		}
+	}
+	return nil, commonerrors.ErrLocationNotFound
+}
+
+// resolveTimezone falls back to the timezone of the location when subscriber did not choose one
+func (s *SubscriptionService) resolveTimezone(ctx context.Context, tx *sql.Tx, timezone string, locId int32) (string, error) {
+	if timezone != "" {
+		return timezone, nil
+	}
+
+	loc, err := s.locationRepository.FindById(ctx, tx, locId)
+	if err != nil {
+		return "", err
+	}
+	if loc == nil || loc.TzId == "" {
+		return model.DefaultTimezone, nil
+	}
+	if _, err = time.LoadLocation(loc.TzId); err != nil {
+		s.log.Warn("unknown location timezone, using default", "tzId", loc.TzId)
+		return model.DefaultTimezone, nil
+	}
+	return loc.TzId, nil
+}
+
 func (s *SubscriptionService) Confirm(ctx context.Context, tokenStr string) error {
 	err := sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
-		token, errIn := s.tokenRepository.FindByToken(ctx, tx, tokenStr)
+		token, errIn := s.findToken(ctx, tx, tokenStr)
 		if errIn != nil {
 			return errIn
 		}
@@ -192,13 +432,22 @@ func (s *SubscriptionService) Confirm(ctx context.Context, tokenStr string) erro
 		if time.Now().UTC().After(token.ExpiresAt) || token.Type != model.TokenType_Confirmation {
 			return commonerrors.ErrInvalidToken
 		}
+		if errIn = s.consumeToken(ctx, tx, token); errIn != nil {
+			return errIn
+		}
 
 		subscription, errIn := s.subscriptionRepository.FindById(ctx, tx, token.SubscriptionId)
 		if errIn != nil {
 			return errIn
 		}
+		// token is kept after its subscription is deleted
 		if subscription == nil {
-			return commonerrors.ErrUnexpectedState
+			return commonerrors.ErrTokenNotFound
+		}
+		// another resent confirmation token was used already
+		if subscription.Status == model.SubscriptionStatus_Confirmed {
+			s.log.Info("subscription is already confirmed", "subscriptionId", subscription.Id)
+			return nil
 		}
 
 		subscription.Status = model.SubscriptionStatus_Confirmed
@@ -219,8 +468,7 @@ func (s *SubscriptionService) Confirm(ctx context.Context, tokenStr string) erro
 			SubscriptionId: token.SubscriptionId,
 			Type:           model.TokenType_Unsubscribe,
 			CreatedAt:      time.Now().UTC(),
-			ExpiresAt:      time.Now().UTC().AddDate(0, 0, 1),
-			UsedAt:         time.Unix(0, 0),
+			ExpiresAt:      model.TokenNeverExpires,
 		}
 		if errIn = s.tokenRepository.Save(ctx, tx, &unsubToken); errIn != nil {
 			return errIn
@@ -230,17 +478,17 @@ func (s *SubscriptionService) Confirm(ctx context.Context, tokenStr string) erro
 			From:    s.confirmSuccessEmailData.From,
 			To:      subscriber.Email,
 			Subject: s.confirmSuccessEmailData.Subject,
-			Text: fmt.Sprintf(
+			Text: withManageLink(fmt.Sprintf(
 				s.confirmSuccessEmailData.Text,
-				unsubToken.Token,
-			),
+				signToken(s.tokenSigner, &unsubToken),
+			), s.manageLinker.Link(subscriber.Id)),
 		}
 
-		errIn = s.emailSender.Send(ctx, email)
+		errIn = enqueueEmail(ctx, tx, s.emailOutboxRepository, email)
 		if errIn != nil {
 			return errIn
 		}
-		s.log.Info("confirmation success email is send")
+		s.log.Info("confirmation success email is queued")
 
 		return nil
 	})
@@ -255,7 +503,7 @@ func (s *SubscriptionService) Confirm(ctx context.Context, tokenStr string) erro
 
 func (s *SubscriptionService) Unsubscribe(ctx context.Context, tokenStr string) error {
 	err := sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
-		token, errIn := s.tokenRepository.FindByToken(ctx, tx, tokenStr)
+		token, errIn := s.findToken(ctx, tx, tokenStr)
 		if errIn != nil {
 			return errIn
 		}
@@ -264,17 +512,25 @@ func (s *SubscriptionService) Unsubscribe(ctx context.Context, tokenStr string)
 		} else if time.Now().UTC().After(token.ExpiresAt) || token.Type != model.TokenType_Unsubscribe {
 			return commonerrors.ErrInvalidToken
 		}
+		if errIn = s.consumeToken(ctx, tx, token); errIn != nil {
+			return errIn
+		}
 
 		subscription, errIn := s.subscriptionRepository.FindById(ctx, tx, token.SubscriptionId)
 		if errIn != nil {
 			return errIn
 		}
+		// subscription was deleted some other way, its unused token is kept until janitor removes it
+		if subscription == nil {
+			return commonerrors.ErrTokenNotFound
+		}
 
 		subscriber, errIn := s.subscriberRepository.FindById(ctx, tx, subscription.SubscriberId)
 		if errIn != nil {
 			return errIn
 		}
 
+		// used token stays as tombstone, so replaying it is answered as already used
 		errIn = s.subscriptionRepository.DeleteById(ctx, tx, token.SubscriptionId)
 		if errIn != nil {
 			return errIn
@@ -284,14 +540,14 @@ func (s *SubscriptionService) Unsubscribe(ctx context.Context, tokenStr string)
 			From:    s.unsubEmailData.From,
 			To:      subscriber.Email,
 			Subject: s.unsubEmailData.Subject,
-			Text:    s.unsubEmailData.Text,
+			Text:    withManageLink(s.unsubEmailData.Text, s.manageLinker.Link(subscriber.Id)),
 		}
 
-		errIn = s.emailSender.Send(ctx, email)
+		errIn = enqueueEmail(ctx, tx, s.emailOutboxRepository, email)
 		if errIn != nil {
 			return errIn
 		}
-		s.log.Info("unsubscribe success email is send")
+		s.log.Info("unsubscribe success email is queued")
 
 		return nil
 	})
@@ -303,3 +559,51 @@ func (s *SubscriptionService) Unsubscribe(ctx context.Context, tokenStr string)
 
 	return nil
 }
+
+// consumeToken relies on conditional update, so of concurrent requests with the same token only one proceeds
+func (s *SubscriptionService) consumeToken(ctx context.Context, tx *sql.Tx, token *model.Token) error {
+	if token.IsUsed() {
+		return commonerrors.ErrTokenAlreadyUsed
+	}
+	used, err := s.tokenRepository.MarkUsed(ctx, tx, token.Token, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return commonerrors.ErrTokenAlreadyUsed
+	}
+	return nil
+}
+
+func (s *SubscriptionService) acceptsLegacyTokens(now time.Time) bool {
+	return s.tokens.AcceptLegacy && now.Before(s.tokens.LegacyUntil)
+}
+
+// findToken accepts signed tokens and, while legacy tokens are accepted, raw uuids issued before signing
+func (s *SubscriptionService) findToken(ctx context.Context, tx *sql.Tx, tokenStr string) (*model.Token, error) {
+	now := time.Now()
+	claims, err := s.tokenSigner.Verify(tokenStr, now)
+	if err != nil {
+		if _, errParse := uuid.Parse(tokenStr); !s.acceptsLegacyTokens(now) || errParse != nil {
+			return nil, err
+		}
+		token, err := s.tokenRepository.FindByToken(ctx, tx, tokenStr)
+		if err != nil || token == nil {
+			return nil, err
+		}
+		if !token.Legacy {
+			return nil, commonerrors.ErrInvalidToken
+		}
+		s.log.Warn("legacy token used", "subscriptionId", token.SubscriptionId, "type", token.Type, "legacyUntil", s.tokens.LegacyUntil)
+		return token, nil
+	}
+
+	token, err := s.tokenRepository.FindByToken(ctx, tx, claims.Id)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	if token.SubscriptionId != claims.Subject || string(token.Type) != claims.Purpose {
+		return nil, commonerrors.ErrInvalidToken
+	}
+	return token, nil
+}
@@ -0,0 +1,587 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/managelink"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/sqlutil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type subscriptionTestEnv struct {
+	db              *sql.DB
+	driver          *txDriver
+	weatherProvider *MockWeatherProvider
+	locations       *MockLocationRepository
+	subscribers     *MockSubscriberRepository
+	subscriptions   *MockSubscriptionRepository
+	conditions      *MockSubscriptionConditionRepository
+	tokens          *MockTokenRepository
+	outbox          *MockEmailOutboxRepository
+	signer          *signedtoken.Signer
+	linker          *managelink.Linker
+	service         *SubscriptionService
+}
+
+func newSubscriptionTestEnv(t *testing.T, acceptLegacyTokens bool) *subscriptionTestEnv {
+	db, driver := newTxDB()
+	signer, err := signedtoken.New([]string{"k1:test-secret-test-secret-test-secret"})
+	require.NoError(t, err)
+	linker, err := managelink.New([]string{"m1:manage-secret-manage-secret-manage"}, "http://localhost/manage/", time.Hour)
+	require.NoError(t, err)
+
+	env := &subscriptionTestEnv{
+		db:              db,
+		driver:          driver,
+		weatherProvider: NewMockWeatherProvider(t),
+		locations:       NewMockLocationRepository(t),
+		subscribers:     NewMockSubscriberRepository(t),
+		subscriptions:   NewMockSubscriptionRepository(t),
+		conditions:      NewMockSubscriptionConditionRepository(t),
+		tokens:          NewMockTokenRepository(t),
+		outbox:          NewMockEmailOutboxRepository(t),
+		signer:          signer,
+		linker:          linker,
+	}
+	emailData := config.EmailData{From: "from@example.com", Subject: "subject", Text: "text %s"}
+	env.service = NewSubscriptionService(db, env.weatherProvider, env.locations, env.subscribers, env.subscriptions, env.conditions,
+		env.tokens, env.outbox, env.linker, signer, config.Tokens{AcceptLegacy: acceptLegacyTokens, LegacyUntil: time.Now().Add(time.Hour)},
+		config.Confirmation{TokenTTL: 15 * time.Minute, ResendInterval: time.Minute, ResendLimit: 3, ResendWindow: 24 * time.Hour},
+		emailData, emailData, emailData, emailData, discardLogger())
+	return env
+}
+
+func (env *subscriptionTestEnv) inTx(t *testing.T, f func(tx *sql.Tx)) {
+	tx, err := env.db.Begin()
+	require.NoError(t, err)
+	f(tx)
+	require.NoError(t, tx.Rollback())
+}
+
+func TestResolveLocationStoresSearchResultOnlyOnSubscribe(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	paris := model.Location{Name: "Paris", Region: "Texas", Country: "United States of America", Latitude: 33.66, Longitude: -95.56}
+	paris.Key = model.LocationKey(paris.Name, paris.Latitude, paris.Longitude)
+	other := model.Location{Name: "Paris", Country: "France", Latitude: 48.87, Longitude: 2.33}
+	other.Key = model.LocationKey(other.Name, other.Latitude, other.Longitude)
+
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, paris.Key).Return(nil, nil)
+	env.weatherProvider.EXPECT().SearchLocations(mock.Anything, "paris").Return([]model.Location{other, paris}, nil)
+	env.weatherProvider.EXPECT().GetCurrentWeather(mock.Anything, "33.66,-95.56", model.DefaultLanguage).
+		Return(&model.WeatherWithLocation{Location: model.Location{TzId: "America/Chicago"}}, nil)
+	env.locations.EXPECT().FindSamePlace(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	env.locations.EXPECT().Upsert(mock.Anything, mock.Anything, mock.MatchedBy(func(l *model.Location) bool {
+		return l.Key == paris.Key && l.TzId == "America/Chicago"
+	})).Return(7, nil)
+
+	env.inTx(t, func(tx *sql.Tx) {
+		id, err := env.service.resolveLocation(context.Background(), tx, "", paris.Key)
+		require.NoError(t, err)
+		require.Equal(t, int32(7), id)
+	})
+}
+
+func TestResolveLocationKeepsTimezoneOfSearchResult(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	kyiv := model.Location{Name: "Kyiv", Country: "Ukraine", Latitude: 50.45466, Longitude: 30.5238, TzId: "Europe/Kyiv"}
+	kyiv.Key = model.LocationKey(kyiv.Name, kyiv.Latitude, kyiv.Longitude)
+
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, kyiv.Key).Return(nil, nil)
+	env.weatherProvider.EXPECT().SearchLocations(mock.Anything, "kyiv").Return([]model.Location{kyiv}, nil)
+	env.weatherProvider.EXPECT().GetCurrentWeather(mock.Anything, "50.45466,30.5238", model.DefaultLanguage).
+		Return(&model.WeatherWithLocation{Location: model.Location{Name: "50.45466,30.5238", Latitude: 50.45466, Longitude: 30.5238}}, nil)
+	env.locations.EXPECT().FindSamePlace(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	env.locations.EXPECT().Upsert(mock.Anything, mock.Anything, mock.MatchedBy(func(l *model.Location) bool {
+		return l.Name == "Kyiv" && l.Key == kyiv.Key && l.TzId == "Europe/Kyiv"
+	})).Return(3, nil)
+
+	env.inTx(t, func(tx *sql.Tx) {
+		id, err := env.service.resolveLocation(context.Background(), tx, "", kyiv.Key)
+		require.NoError(t, err)
+		require.Equal(t, int32(3), id)
+	})
+}
+
+func TestResolveLocationReusesSamePlaceOfOtherProvider(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	fromOpenMeteo := model.Location{Name: "Kyiv", Country: "Ukraine", Latitude: 50.45466, Longitude: 30.5238}
+	fromOpenMeteo.Key = model.LocationKey(fromOpenMeteo.Name, fromOpenMeteo.Latitude, fromOpenMeteo.Longitude)
+	stored := &model.Location{Id: 3, Name: "Kyiv", Latitude: 50.4333, Longitude: 30.5167, Key: model.LocationKey("Kyiv", 50.4333, 30.5167)}
+	require.NotEqual(t, stored.Key, fromOpenMeteo.Key)
+
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, fromOpenMeteo.Key).Return(nil, nil)
+	env.weatherProvider.EXPECT().SearchLocations(mock.Anything, "kyiv").Return([]model.Location{fromOpenMeteo}, nil)
+	env.weatherProvider.EXPECT().GetCurrentWeather(mock.Anything, "50.45466,30.5238", model.DefaultLanguage).
+		Return(&model.WeatherWithLocation{Location: model.Location{TzId: "Europe/Kyiv"}}, nil)
+	env.locations.EXPECT().FindSamePlace(mock.Anything, mock.Anything, mock.MatchedBy(func(l *model.Location) bool {
+		return l.Key == fromOpenMeteo.Key
+	})).Return(stored, nil)
+	env.locations.EXPECT().Upsert(mock.Anything, mock.Anything, mock.MatchedBy(func(l *model.Location) bool {
+		return l.Key == stored.Key && l.TzId == "Europe/Kyiv"
+	})).Return(3, nil)
+
+	env.inTx(t, func(tx *sql.Tx) {
+		id, err := env.service.resolveLocation(context.Background(), tx, "", fromOpenMeteo.Key)
+		require.NoError(t, err)
+		require.Equal(t, int32(3), id)
+	})
+}
+
+func TestResolveLocationUsesStoredLocation(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "kyiv|50.43|30.52").
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+
+	env.inTx(t, func(tx *sql.Tx) {
+		id, err := env.service.resolveLocation(context.Background(), tx, "", "kyiv|50.43|30.52")
+		require.NoError(t, err)
+		require.Equal(t, int32(3), id)
+	})
+}
+
+func TestResolveLocationUnknownKey(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "atlantis|0.00|0.00").Return(nil, nil)
+	env.weatherProvider.EXPECT().SearchLocations(mock.Anything, "atlantis").Return(nil, nil)
+
+	env.inTx(t, func(tx *sql.Tx) {
+		_, err := env.service.resolveLocation(context.Background(), tx, "", "atlantis|0.00|0.00")
+		require.ErrorIs(t, err, commonerrors.ErrLocationNotFound)
+	})
+}
+
+func TestSubscribeUpdatesPreferencesOfExistingSubscriber(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	existing := &model.Subscriber{Id: 5, Email: "user@example.com", Preferences: model.DefaultPreferences()}
+	createdAt := time.Now().UTC().Add(-time.Hour)
+	pending := &model.Subscription{
+		Id: 11, SubscriberId: 5, LocationId: 3, Frequency: model.Frequency_Daily, Status: model.SubscriptionStatus_Pending,
+		DeliveryHour: model.DefaultDeliveryHour, Timezone: "UTC", ConditionLogic: model.ConditionLogic_And,
+		LastDeliveredAt: time.Unix(0, 0), CreatedAt: createdAt,
+	}
+	threshold := int32(4)
+	deliveryHour := int32(6)
+
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "kyiv|50.43|30.52").
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+	env.subscribers.EXPECT().FindByEmail(mock.Anything, mock.Anything, existing.Email).Return(existing, nil)
+	env.subscribers.EXPECT().UpdatePreferences(mock.Anything, mock.Anything, int32(5), model.Preferences{
+		TemperatureUnit: model.TemperatureUnit_Fahrenheit,
+		WindUnit:        existing.WindUnit,
+		Language:        "uk",
+	}).Return(nil)
+	env.subscriptions.EXPECT().FindBySubscriberIdAndLocationId(mock.Anything, mock.Anything, int32(5), int32(3)).Return(pending, nil)
+	var updated *model.Subscription
+	env.subscriptions.EXPECT().Update(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, s *model.Subscription) (*model.Subscription, error) {
+			updated = s
+			return s, nil
+		})
+	// conditions submitted earlier are replaced by the new ones, converted to metric units
+	env.conditions.EXPECT().DeleteAllBySubscriptionId(mock.Anything, mock.Anything, int32(11)).Return(nil)
+	env.conditions.EXPECT().Save(mock.Anything, mock.Anything, mock.MatchedBy(func(c *model.Condition) bool {
+		return c.SubscriptionId == 11 && c.Field == model.ConditionField_Temperature && c.Operator == model.ConditionOperator_Above && c.Threshold == 20
+	})).Return(1, nil)
+	env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation).Return(nil, nil)
+	env.tokens.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.outbox.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+
+	err := env.service.Subscribe(context.Background(), dto.SubscriptionRequest{
+		Email:          existing.Email,
+		LocationKey:    "kyiv|50.43|30.52",
+		Frequency:      string(model.Frequency_Custom),
+		Schedule:       "weekdays",
+		DeliveryHour:   &deliveryHour,
+		Timezone:       "Europe/Kyiv",
+		AqiThreshold:   threshold,
+		Conditions:     []string{"temperature>68"},
+		ConditionLogic: string(model.ConditionLogic_Or),
+		Preferences:    dto.PreferencesRequest{TemperatureUnit: string(model.TemperatureUnit_Fahrenheit), Language: "uk"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), env.driver.commits.Load())
+
+	require.NotNil(t, updated)
+	require.Equal(t, int32(11), updated.Id)
+	require.Equal(t, model.SubscriptionStatus_Pending, updated.Status)
+	require.Equal(t, model.Frequency_Custom, updated.Frequency)
+	require.Equal(t, "weekdays", updated.Schedule)
+	require.Equal(t, deliveryHour, updated.DeliveryHour)
+	require.Equal(t, "Europe/Kyiv", updated.Timezone)
+	require.Equal(t, threshold, updated.AqiThreshold)
+	require.Equal(t, model.ConditionLogic_Or, updated.ConditionLogic)
+	require.Equal(t, createdAt, updated.CreatedAt)
+}
+
+func TestSubscribeKeepsUnchangedPreferences(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	existing := &model.Subscriber{Id: 5, Email: "user@example.com", Preferences: model.DefaultPreferences()}
+
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "kyiv|50.43|30.52").
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+	env.subscribers.EXPECT().FindByEmail(mock.Anything, mock.Anything, existing.Email).Return(existing, nil)
+	env.subscriptions.EXPECT().FindBySubscriberIdAndLocationId(mock.Anything, mock.Anything, int32(5), int32(3)).
+		Return(&model.Subscription{Id: 11, Status: model.SubscriptionStatus_Confirmed}, nil)
+
+	err := env.service.Subscribe(context.Background(), dto.SubscriptionRequest{
+		Email:       existing.Email,
+		LocationKey: "kyiv|50.43|30.52",
+		Frequency:   string(model.Frequency_Daily),
+	})
+	require.ErrorIs(t, err, commonerrors.ErrSubscriptionAlreadyExists)
+}
+
+func TestSubscribeRejectsConditionFrequencyCannotEvaluate(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "kyiv|50.43|30.52").
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+	env.subscribers.EXPECT().FindByEmail(mock.Anything, mock.Anything, "user@example.com").Return(nil, nil)
+	env.subscribers.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(5, nil)
+	env.subscriptions.EXPECT().FindBySubscriberIdAndLocationId(mock.Anything, mock.Anything, int32(5), int32(3)).Return(nil, nil)
+
+	err := env.service.Subscribe(context.Background(), dto.SubscriptionRequest{
+		Email:       "user@example.com",
+		LocationKey: "kyiv|50.43|30.52",
+		Frequency:   string(model.Frequency_Daily),
+		Timezone:    "Europe/Kyiv",
+		Conditions:  []string{"wind_speed>30"},
+	})
+	require.ErrorIs(t, err, commonerrors.ErrInvalidCondition)
+	require.Equal(t, int32(1), env.driver.rollbacks.Load())
+}
+
+func TestSubscribeStoresThresholdsInMetric(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "kyiv|50.43|30.52").
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+	env.subscribers.EXPECT().FindByEmail(mock.Anything, mock.Anything, "user@example.com").Return(nil, nil)
+	env.subscribers.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(5, nil)
+	env.subscriptions.EXPECT().FindBySubscriberIdAndLocationId(mock.Anything, mock.Anything, int32(5), int32(3)).Return(nil, nil)
+	env.subscriptions.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(11, nil)
+	var stored []model.Condition
+	env.conditions.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, c *model.Condition) (int32, error) {
+			stored = append(stored, *c)
+			return int32(len(stored)), nil
+		})
+	env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation).Return(nil, nil)
+	env.tokens.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.outbox.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+
+	err := env.service.Subscribe(context.Background(), dto.SubscriptionRequest{
+		Email:       "user@example.com",
+		LocationKey: "kyiv|50.43|30.52",
+		Frequency:   string(model.Frequency_Hourly),
+		Timezone:    "Europe/Kyiv",
+		Conditions:  []string{"temperature>86", "wind_speed>10"},
+		Preferences: dto.PreferencesRequest{TemperatureUnit: string(model.TemperatureUnit_Fahrenheit), WindUnit: string(model.WindUnit_Mph)},
+	})
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	require.InDelta(t, 30, stored[0].Threshold, 0.001)
+	require.InDelta(t, 16.09344, stored[1].Threshold, 0.001)
+}
+
+func TestUpdateManagedSubscriptionRejectsFrequencyConditionsCannotUse(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	token := env.linker.Token(5, time.Now())
+	env.subscriptions.EXPECT().FindById(mock.Anything, mock.Anything, int32(11)).
+		Return(&model.Subscription{Id: 11, SubscriberId: 5, Frequency: model.Frequency_Hourly}, nil)
+	env.conditions.EXPECT().FindAllBySubscriptionId(mock.Anything, mock.Anything, int32(11)).
+		Return([]model.Condition{{Field: model.ConditionField_UVIndex, Operator: model.ConditionOperator_Above, Threshold: 6}}, nil)
+
+	_, err := env.service.UpdateManagedSubscription(context.Background(), token, 11, dto.UpdateSubscriptionRequest{Frequency: string(model.Frequency_Daily)})
+	require.ErrorIs(t, err, commonerrors.ErrInvalidCondition)
+}
+
+func (env *subscriptionTestEnv) expectPendingSubscription(email string) {
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "kyiv|50.43|30.52").
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+	env.subscribers.EXPECT().FindByEmail(mock.Anything, mock.Anything, email).
+		Return(&model.Subscriber{Id: 5, Email: email, Preferences: model.DefaultPreferences()}, nil)
+	env.subscriptions.EXPECT().FindBySubscriberIdAndLocationId(mock.Anything, mock.Anything, int32(5), int32(3)).
+		Return(&model.Subscription{Id: 11, SubscriberId: 5, LocationId: 3, Status: model.SubscriptionStatus_Pending}, nil)
+	env.locations.EXPECT().FindById(mock.Anything, mock.Anything, int32(3)).
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+	env.subscriptions.EXPECT().Update(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, s *model.Subscription) (*model.Subscription, error) {
+			return s, nil
+		})
+	env.conditions.EXPECT().DeleteAllBySubscriptionId(mock.Anything, mock.Anything, int32(11)).Return(nil)
+}
+
+func TestSubscribeAgainWithinResendInterval(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.expectPendingSubscription("user@example.com")
+	env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation).
+		Return(&model.Token{Token: "t1", SubscriptionId: 11, CreatedAt: time.Now().UTC().Add(-10 * time.Second)}, nil)
+
+	err := env.service.Subscribe(context.Background(), dto.SubscriptionRequest{Email: "user@example.com", LocationKey: "kyiv|50.43|30.52", Frequency: "daily"})
+	require.ErrorIs(t, err, commonerrors.ErrTooManyRequests)
+	require.Equal(t, int32(1), env.driver.rollbacks.Load())
+}
+
+func TestSubscribeAgainOverResendLimit(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.expectPendingSubscription("user@example.com")
+	env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation).
+		Return(&model.Token{Token: "t3", SubscriptionId: 11, CreatedAt: time.Now().UTC().Add(-time.Hour)}, nil)
+	env.tokens.EXPECT().CountBySubscriptionIdAndTypeCreatedAfter(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation,
+		mock.MatchedBy(func(after time.Time) bool {
+			return time.Since(after) > 23*time.Hour && time.Since(after) < 24*time.Hour+time.Minute
+		})).Return(3, nil)
+
+	err := env.service.Subscribe(context.Background(), dto.SubscriptionRequest{Email: "user@example.com", LocationKey: "kyiv|50.43|30.52", Frequency: "daily"})
+	require.ErrorIs(t, err, commonerrors.ErrTooManyRequests)
+}
+
+func TestSubscribeAgainResendsConfirmation(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.expectPendingSubscription("user@example.com")
+	env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation).
+		Return(&model.Token{Token: "t1", SubscriptionId: 11, CreatedAt: time.Now().UTC().Add(-time.Hour)}, nil)
+	env.tokens.EXPECT().CountBySubscriptionIdAndTypeCreatedAfter(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation, mock.Anything).
+		Return(2, nil)
+	env.tokens.EXPECT().Save(mock.Anything, mock.Anything, mock.MatchedBy(func(token *model.Token) bool {
+		return token.SubscriptionId == 11 && token.Type == model.TokenType_Confirmation && token.ExpiresAt.Sub(token.CreatedAt) == 15*time.Minute
+	})).Return(nil)
+	env.outbox.EXPECT().Save(mock.Anything, mock.Anything, mock.MatchedBy(func(email *model.OutboxEmail) bool {
+		return email.To == "user@example.com"
+	})).Return(1, nil)
+
+	err := env.service.Subscribe(context.Background(), dto.SubscriptionRequest{Email: "user@example.com", LocationKey: "kyiv|50.43|30.52", Frequency: "daily"})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), env.driver.commits.Load())
+}
+
+func TestResendConfirmationSkipsRateLimitedSubscriptions(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.subscribers.EXPECT().FindByEmail(mock.Anything, mock.Anything, "user@example.com").
+		Return(&model.Subscriber{Id: 5, Email: "user@example.com"}, nil)
+	env.subscriptions.EXPECT().FindAllBySubscriberId(mock.Anything, mock.Anything, int32(5)).Return([]*model.Subscription{
+		{Id: 11, SubscriberId: 5, Status: model.SubscriptionStatus_Pending},
+		{Id: 12, SubscriberId: 5, Status: model.SubscriptionStatus_Confirmed},
+		{Id: 13, SubscriberId: 5, Status: model.SubscriptionStatus_Pending},
+	}, nil)
+	env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, int32(11), model.TokenType_Confirmation).
+		Return(&model.Token{Token: "t1", SubscriptionId: 11, CreatedAt: time.Now().UTC()}, nil)
+	env.tokens.EXPECT().FindBySubscriptionIdAndType(mock.Anything, mock.Anything, int32(13), model.TokenType_Confirmation).
+		Return(nil, nil)
+	env.tokens.EXPECT().Save(mock.Anything, mock.Anything, mock.MatchedBy(func(token *model.Token) bool {
+		return token.SubscriptionId == 13
+	})).Return(nil).Once()
+	env.outbox.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(1, nil).Once()
+
+	require.NoError(t, env.service.ResendConfirmation(context.Background(), "user@example.com"))
+	require.Equal(t, int32(1), env.driver.commits.Load())
+}
+
+func TestResendConfirmationUnknownEmail(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	env.subscribers.EXPECT().FindByEmail(mock.Anything, mock.Anything, "nobody@example.com").Return(nil, nil)
+
+	require.NoError(t, env.service.ResendConfirmation(context.Background(), "nobody@example.com"))
+}
+
+func (env *subscriptionTestEnv) expectUnsubscribe(token string) {
+	env.tokens.EXPECT().MarkUsed(mock.Anything, mock.Anything, token, mock.Anything).Return(true, nil)
+	env.subscriptions.EXPECT().FindById(mock.Anything, mock.Anything, int32(11)).
+		Return(&model.Subscription{Id: 11, SubscriberId: 5, Status: model.SubscriptionStatus_Confirmed}, nil)
+	env.subscribers.EXPECT().FindById(mock.Anything, mock.Anything, int32(5)).Return(&model.Subscriber{Id: 5, Email: "user@example.com"}, nil)
+	env.subscriptions.EXPECT().DeleteById(mock.Anything, mock.Anything, int32(11)).Return(nil)
+	env.outbox.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+}
+
+func TestUnsubscribeWithLegacyToken(t *testing.T) {
+	env := newSubscriptionTestEnv(t, true)
+	legacy := "6f1c9a52-3f0e-4a53-9d59-0c3b3c1f4a7e"
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, legacy).Return(&model.Token{
+		Token: legacy, SubscriptionId: 11, Type: model.TokenType_Unsubscribe, ExpiresAt: model.TokenNeverExpires, Legacy: true,
+	}, nil)
+	env.expectUnsubscribe(legacy)
+
+	require.NoError(t, env.service.Unsubscribe(context.Background(), legacy))
+	require.Equal(t, int32(1), env.driver.commits.Load())
+}
+
+func TestUnsubscribeWithLegacyTokenWhenNotAccepted(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+
+	err := env.service.Unsubscribe(context.Background(), "6f1c9a52-3f0e-4a53-9d59-0c3b3c1f4a7e")
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+}
+
+func TestUnsubscribeWithLegacyTokenAfterLegacyUntil(t *testing.T) {
+	env := newSubscriptionTestEnv(t, true)
+	env.service.tokens.LegacyUntil = time.Now().Add(-time.Hour)
+
+	err := env.service.Unsubscribe(context.Background(), "6f1c9a52-3f0e-4a53-9d59-0c3b3c1f4a7e")
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+}
+
+// id of a signed token is stored as is, it must not work without signature even when legacy tokens are accepted
+func TestUnsubscribeWithBareIdOfSignedToken(t *testing.T) {
+	env := newSubscriptionTestEnv(t, true)
+	id := "0b0d7f3e-8a53-4c4e-bc58-7d0f5f9b2e11"
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, id).Return(&model.Token{
+		Token: id, SubscriptionId: 11, Type: model.TokenType_Unsubscribe, ExpiresAt: model.TokenNeverExpires,
+	}, nil)
+
+	err := env.service.Unsubscribe(context.Background(), id)
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+}
+
+func TestUnsubscribeWithSignedToken(t *testing.T) {
+	env := newSubscriptionTestEnv(t, true)
+	stored := &model.Token{Token: "0b0d7f3e-8a53-4c4e-bc58-7d0f5f9b2e11", SubscriptionId: 11, Type: model.TokenType_Unsubscribe, ExpiresAt: model.TokenNeverExpires}
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+	env.expectUnsubscribe(stored.Token)
+
+	require.NoError(t, env.service.Unsubscribe(context.Background(), signToken(env.signer, stored)))
+}
+
+func TestUnsubscribeWithConfirmationToken(t *testing.T) {
+	env := newSubscriptionTestEnv(t, true)
+	stored := &model.Token{Token: "0b0d7f3e-8a53-4c4e-bc58-7d0f5f9b2e11", SubscriptionId: 11, Type: model.TokenType_Confirmation, ExpiresAt: time.Now().Add(time.Hour)}
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+
+	err := env.service.Unsubscribe(context.Background(), signToken(env.signer, stored))
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+}
+
+func confirmationToken() *model.Token {
+	return &model.Token{
+		Token:          "2c8e3f4a-6b1d-4c0e-9f3a-5d7b8e9c1a2b",
+		SubscriptionId: 11,
+		Type:           model.TokenType_Confirmation,
+		CreatedAt:      time.Now().UTC(),
+		ExpiresAt:      time.Now().UTC().Add(15 * time.Minute),
+	}
+}
+
+func TestConfirmMarksTokenUsed(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	stored := confirmationToken()
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+	env.tokens.EXPECT().MarkUsed(mock.Anything, mock.Anything, stored.Token, mock.Anything).Return(true, nil).Once()
+	env.subscriptions.EXPECT().FindById(mock.Anything, mock.Anything, int32(11)).
+		Return(&model.Subscription{Id: 11, SubscriberId: 5, Status: model.SubscriptionStatus_Pending}, nil)
+	env.subscriptions.EXPECT().Update(mock.Anything, mock.Anything, mock.MatchedBy(func(s *model.Subscription) bool {
+		return s.Status == model.SubscriptionStatus_Confirmed
+	})).RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, s *model.Subscription) (*model.Subscription, error) {
+		return s, nil
+	})
+	env.subscribers.EXPECT().FindById(mock.Anything, mock.Anything, int32(5)).Return(&model.Subscriber{Id: 5, Email: "user@example.com"}, nil)
+	env.tokens.EXPECT().Save(mock.Anything, mock.Anything, mock.MatchedBy(func(token *model.Token) bool {
+		return token.Type == model.TokenType_Unsubscribe && token.ExpiresAt.Equal(model.TokenNeverExpires)
+	})).Return(nil).Once()
+	env.outbox.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).Return(1, nil).Once()
+
+	require.NoError(t, env.service.Confirm(context.Background(), signToken(env.signer, stored)))
+	require.Equal(t, int32(1), env.driver.commits.Load())
+}
+
+func TestConfirmReplayOfUsedToken(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	stored := confirmationToken()
+	stored.UsedAt = time.Now().UTC().Add(-time.Minute)
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+
+	err := env.service.Confirm(context.Background(), signToken(env.signer, stored))
+	require.ErrorIs(t, err, commonerrors.ErrTokenAlreadyUsed)
+	require.Equal(t, int32(1), env.driver.rollbacks.Load())
+}
+
+// concurrent confirmation read the token before it was used, conditional update lets only one of them through
+func TestConfirmLosesRaceToMarkTokenUsed(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	stored := confirmationToken()
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+	env.tokens.EXPECT().MarkUsed(mock.Anything, mock.Anything, stored.Token, mock.Anything).Return(false, nil)
+
+	err := env.service.Confirm(context.Background(), signToken(env.signer, stored))
+	require.ErrorIs(t, err, commonerrors.ErrTokenAlreadyUsed)
+	require.Equal(t, int32(1), env.driver.rollbacks.Load())
+}
+
+func TestConfirmExpiredToken(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	stored := confirmationToken()
+	stored.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+
+	err := env.service.Confirm(context.Background(), env.signer.Sign(signedtoken.Claims{
+		Id: stored.Token, Subject: 11, Purpose: string(model.TokenType_Confirmation), ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+}
+
+func TestUnsubscribeTokenOfDeletedSubscription(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	stored := &model.Token{Token: "0b0d7f3e-8a53-4c4e-bc58-7d0f5f9b2e11", SubscriptionId: 11, Type: model.TokenType_Unsubscribe, ExpiresAt: model.TokenNeverExpires}
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+	env.tokens.EXPECT().MarkUsed(mock.Anything, mock.Anything, stored.Token, mock.Anything).Return(true, nil)
+	env.subscriptions.EXPECT().FindById(mock.Anything, mock.Anything, int32(11)).Return(nil, nil)
+
+	err := env.service.Unsubscribe(context.Background(), signToken(env.signer, stored))
+	require.ErrorIs(t, err, commonerrors.ErrTokenNotFound)
+	require.Equal(t, int32(1), env.driver.rollbacks.Load())
+}
+
+func TestUnsubscribeReplayOfUsedToken(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	stored := &model.Token{Token: "0b0d7f3e-8a53-4c4e-bc58-7d0f5f9b2e11", SubscriptionId: 11, Type: model.TokenType_Unsubscribe,
+		ExpiresAt: model.TokenNeverExpires, UsedAt: time.Now().UTC()}
+	env.tokens.EXPECT().FindByToken(mock.Anything, mock.Anything, stored.Token).Return(stored, nil)
+
+	err := env.service.Unsubscribe(context.Background(), signToken(env.signer, stored))
+	require.ErrorIs(t, err, commonerrors.ErrTokenAlreadyUsed)
+}
+
+func TestAddManagedSubscriptionSavesThresholdAndConditions(t *testing.T) {
+	env := newSubscriptionTestEnv(t, false)
+	prefs := model.DefaultPreferences()
+	prefs.TemperatureUnit = model.TemperatureUnit_Fahrenheit
+	env.subscribers.EXPECT().FindById(mock.Anything, mock.Anything, int32(5)).
+		Return(&model.Subscriber{Id: 5, Email: "user@example.com", Preferences: prefs}, nil)
+	env.locations.EXPECT().FindByKey(mock.Anything, mock.Anything, "kyiv|50.43|30.52").
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv", Key: "kyiv|50.43|30.52"}, nil)
+	env.subscriptions.EXPECT().FindBySubscriberIdAndLocationId(mock.Anything, mock.Anything, int32(5), int32(3)).Return(nil, nil)
+	env.locations.EXPECT().FindById(mock.Anything, mock.Anything, int32(3)).
+		Return(&model.Location{Id: 3, TzId: "Europe/Kyiv"}, nil)
+	env.subscriptions.EXPECT().Save(mock.Anything, mock.Anything, mock.MatchedBy(func(s *model.Subscription) bool {
+		return s.Status == model.SubscriptionStatus_Confirmed && s.AqiThreshold == 4 && s.ConditionLogic == model.ConditionLogic_Or &&
+			s.Timezone == "Europe/Kyiv"
+	})).Return(12, nil)
+	var saved []model.Condition
+	env.conditions.EXPECT().Save(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ sqlutil.SQLExecutor, c *model.Condition) (int32, error) {
+			saved = append(saved, *c)
+			return int32(len(saved)), nil
+		})
+	env.tokens.EXPECT().Save(mock.Anything, mock.Anything, mock.MatchedBy(func(t *model.Token) bool {
+		return t.SubscriptionId == 12 && t.Type == model.TokenType_Unsubscribe
+	})).Return(nil)
+
+	err := env.service.AddManagedSubscription(context.Background(), env.linker.Token(5, time.Now()), dto.ManagedSubscriptionRequest{
+		LocationKey:    "kyiv|50.43|30.52",
+		Frequency:      "hourly",
+		AqiThreshold:   4,
+		Conditions:     []string{"temperature<32", "description~rain"},
+		ConditionLogic: "or",
+	})
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	require.Equal(t, int32(12), saved[0].SubscriptionId)
+	require.Equal(t, model.ConditionField_Temperature, saved[0].Field)
+	require.InDelta(t, 0, saved[0].Threshold, 0.01)
+	require.Equal(t, model.ConditionField_Description, saved[1].Field)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+// txDriver is a database driver that only begins, commits and rolls back transactions, repositories are mocked in service tests
+type txDriver struct {
+	commits   atomic.Int32
+	rollbacks atomic.Int32
+	// failCommit is the number of commit that fails, counted from 1, zero means all commits succeed
+	failCommit atomic.Int32
+	// onEnd lets a test keep repository fakes transactional, it is called once transaction is committed or rolled back
+	onEnd func(committed bool)
+}
+
+type txConn struct {
+	driver *txDriver
+}
+
+type noopTx struct {
+	driver *txDriver
+}
+
+func (d *txDriver) Open(string) (driver.Conn, error) {
+	return &txConn{driver: d}, nil
+}
+
+func (d *txDriver) Connect(context.Context) (driver.Conn, error) {
+	return &txConn{driver: d}, nil
+}
+
+func (d *txDriver) Driver() driver.Driver {
+	return d
+}
+
+func (c *txConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("statements are not supported, mock the repository")
+}
+
+func (c *txConn) Close() error {
+	return nil
+}
+
+func (c *txConn) Begin() (driver.Tx, error) {
+	return &noopTx{driver: c.driver}, nil
+}
+
+func (t *noopTx) Commit() error {
+	failed := t.driver.commits.Add(1) == t.driver.failCommit.Load()
+	t.end(!failed)
+	if failed {
+		return errors.New("commit failed")
+	}
+	return nil
+}
+
+func (t *noopTx) Rollback() error {
+	t.driver.rollbacks.Add(1)
+	t.end(false)
+	return nil
+}
+
+func (t *noopTx) end(committed bool) {
+	if t.driver.onEnd != nil {
+		t.driver.onEnd(committed)
+	}
+}
+
+func newTxDB() (*sql.DB, *txDriver) {
+	d := &txDriver{}
+	return sql.OpenDB(d), d
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -13,54 +13,75 @@ import (
 
 type WeatherRepository interface {
 	Save(context.Context, sqlutil.SQLExecutor, *model.Weather) error
-	FindLastUpdatedByLocation(context.Context, sqlutil.SQLExecutor, string) (*model.Weather, error)
+	FindLastUpdatedByLocationId(context.Context, sqlutil.SQLExecutor, int32) (*model.Weather, error)
+}
+
+type AirQualityRepository interface {
+	Save(context.Context, sqlutil.SQLExecutor, *model.AirQuality) error
+	FindLastByLocationId(context.Context, sqlutil.SQLExecutor, int32) (*model.AirQuality, error)
 }
 
 type WeatherService struct {
-	db                 *sql.DB
-	weatherProvider    WeatherProvider
-	locationRepository LocationRepository
-	weatherRepository  WeatherRepository
-	log                *slog.Logger
+	db                   *sql.DB
+	weatherProvider      WeatherProvider
+	locationRepository   LocationRepository
+	weatherRepository    WeatherRepository
+	airQualityRepository AirQualityRepository
+	log                  *slog.Logger
 }
 
-func NewWeatherService(db *sql.DB, weatherProvider WeatherProvider, locationRepository LocationRepository, weatherRepository WeatherRepository, log *slog.Logger) *WeatherService {
+func NewWeatherService(db *sql.DB, weatherProvider WeatherProvider, locationRepository LocationRepository, weatherRepository WeatherRepository, airQualityRepository AirQualityRepository, log *slog.Logger) *WeatherService {
 	return &WeatherService{
-		db:                 db,
-		weatherProvider:    weatherProvider,
-		locationRepository: locationRepository,
-		weatherRepository:  weatherRepository,
-		log:                log,
+		db:                   db,
+		weatherProvider:      weatherProvider,
+		locationRepository:   locationRepository,
+		weatherRepository:    weatherRepository,
+		airQualityRepository: airQualityRepository,
+		log:                  log,
 	}
 }
 
-func (s *WeatherService) GetCurrentWeatherForLocation(ctx context.Context, location string) (*dto.WeatherDTO, error) {
-	weather, err := s.weatherProvider.GetCurrentWeather(location)
+func (s *WeatherService) GetCurrentWeatherForLocation(ctx context.Context, location string, withAirQuality bool, prefsReq dto.PreferencesRequest) (*dto.WeatherDTO, error) {
+	prefs := mapper.PreferencesRequestToPreferences(prefsReq)
+	weather, err := s.weatherProvider.GetCurrentWeather(ctx, location, prefs.Language)
 	if err != nil {
 		return nil, err
 	}
-	weatherDto := mapper.WeatherToWeatherDTO(weather.Weather)
+	weatherDto := mapper.WeatherToWeatherDTO(weather.Weather, prefs)
+
+	var airQuality *model.AirQualityWithLocation
+	if withAirQuality {
+		airQuality, err = s.weatherProvider.GetAirQuality(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		airQualityDto := mapper.AirQualityToAirQualityDTO(airQuality.AirQuality)
+		weatherDto.AirQuality = &airQualityDto
+	}
 
 	err = sqlutil.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
-		loc, errIn := s.locationRepository.FindByName(ctx, tx, weather.Location.Name)
+		locId, errIn := upsertLocation(ctx, tx, s.locationRepository, &weather.Location)
 		if errIn != nil {
 			return errIn
 		}
 
-		var locId int32
-		if loc != nil {
-			locId = loc.Id
-		} else {
-			locId, errIn = s.locationRepository.Save(ctx, tx, &weather.Location)
-			if errIn != nil {
+		weather.Weather.LocationId = locId
+		weather.Weather.FetchedAt = time.Now().UTC()
+
+		if airQuality != nil {
+			airQuality.AirQuality.LocationId = locId
+			airQuality.AirQuality.FetchedAt = time.Now().UTC()
+			if errIn = s.airQualityRepository.Save(ctx, tx, &airQuality.AirQuality); errIn != nil {
 				return errIn
 			}
 		}
 
-		weather.Weather.LocationId = locId
-		weather.Weather.FetchedAt = time.Now().UTC()
+		if prefs.Language != model.DefaultLanguage {
+			s.log.Info("skip saving weather with localized description", "lang", prefs.Language)
+			return nil
+		}
 
-		lastWeather, errIn := s.weatherRepository.FindLastUpdatedByLocation(ctx, tx, weather.Location.Name)
+		lastWeather, errIn := s.weatherRepository.FindLastUpdatedByLocationId(ctx, tx, locId)
 		if errIn != nil {
 			return errIn
 		}
@@ -84,3 +105,13 @@ func (s *WeatherService) GetCurrentWeatherForLocation(ctx context.Context, locat
 
 	return &weatherDto, nil
 }
+
+func (s *WeatherService) GetForecastForLocation(ctx context.Context, location string, days int) (*dto.ForecastDTO, error) {
+	forecast, err := s.weatherProvider.GetForecast(ctx, location, days, model.DefaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+	forecastDto := mapper.ForecastToForecastDTO(*forecast)
+
+	return &forecastDto, nil
+}
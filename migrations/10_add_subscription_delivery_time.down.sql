@@ -0,0 +1,4 @@
+ALTER TABLE subscription
+    DROP COLUMN IF EXISTS delivery_hour,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS last_delivered_at;
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE subscription
+    ADD COLUMN delivery_hour     SMALLINT    NOT NULL DEFAULT 9 CHECK (delivery_hour BETWEEN 0 AND 23),
+    ADD COLUMN timezone          VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN last_delivered_at TIMESTAMP   NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+UPDATE subscription s
+SET timezone = l.tz_id
+FROM location l
+WHERE l.id = s.location_id
+  AND l.tz_id <> '';
\ No newline at end of file
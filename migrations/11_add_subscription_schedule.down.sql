@@ -0,0 +1,9 @@
+DELETE FROM subscription WHERE frequency = 'custom';
+
+ALTER TABLE subscription
+    DROP COLUMN IF EXISTS schedule;
+
+ALTER TYPE frequency RENAME TO frequency_old;
+CREATE TYPE frequency AS ENUM ('hourly', 'daily', 'alerts');
+ALTER TABLE subscription ALTER COLUMN frequency TYPE frequency USING frequency::text::frequency;
+DROP TYPE frequency_old;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TYPE frequency ADD VALUE 'custom';
+
+ALTER TABLE subscription
+    ADD COLUMN schedule VARCHAR(100) NOT NULL DEFAULT '';
\ No newline at end of file
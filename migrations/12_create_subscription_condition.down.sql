@@ -0,0 +1,5 @@
+ALTER TABLE subscription
+    DROP COLUMN IF EXISTS condition_logic,
+    DROP COLUMN IF EXISTS condition_active;
+
+DROP TABLE IF EXISTS subscription_condition;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE subscription_condition
+(
+    id              SERIAL PRIMARY KEY,
+    subscription_id INT           NOT NULL REFERENCES subscription (id) ON DELETE CASCADE,
+    field           VARCHAR(30)   NOT NULL,
+    operator        VARCHAR(1)    NOT NULL CHECK (operator IN ('<', '>', '~')),
+    threshold       NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    text            VARCHAR(60)   NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_subscription_condition_subscription_id ON subscription_condition (subscription_id);
+
+ALTER TABLE subscription
+    ADD COLUMN condition_logic  VARCHAR(3) NOT NULL DEFAULT 'and' CHECK (condition_logic IN ('and', 'or')),
+    ADD COLUMN condition_active BOOLEAN    NOT NULL DEFAULT FALSE;
\ No newline at end of file
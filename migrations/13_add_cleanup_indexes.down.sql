@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscription_pending_created_at;
+
+DROP INDEX IF EXISTS idx_token_expires_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+CREATE INDEX idx_token_expires_at ON token (expires_at);
+
+CREATE INDEX idx_subscription_pending_created_at ON subscription (created_at) WHERE status = 'pending';
\ No newline at end of file
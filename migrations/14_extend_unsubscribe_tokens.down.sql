@@ -0,0 +1,3 @@
+UPDATE token
+SET expires_at = created_at + INTERVAL '1 day'
+WHERE type = 'unsubscribe';
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE token
+SET expires_at = '9999-12-31'
+WHERE type = 'unsubscribe';
\ No newline at end of file
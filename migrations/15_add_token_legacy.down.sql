@@ -0,0 +1,2 @@
+ALTER TABLE token
+    DROP COLUMN IF EXISTS legacy;
\ No newline at end of file
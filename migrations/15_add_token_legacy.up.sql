@@ -0,0 +1,5 @@
+ALTER TABLE token
+    ADD COLUMN legacy BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE token
+SET legacy = TRUE;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_email_outbox_sent_at;
+DROP INDEX IF EXISTS idx_email_outbox_pending_next_attempt_at;
+DROP TABLE IF EXISTS email_outbox;
+DROP TYPE IF EXISTS email_outbox_status;
\ No newline at end of file
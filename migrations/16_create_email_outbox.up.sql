@@ -0,0 +1,20 @@
+CREATE TYPE email_outbox_status AS ENUM ('pending', 'sent', 'dead');
+
+CREATE TABLE email_outbox
+(
+    id              BIGSERIAL PRIMARY KEY,
+    sender          VARCHAR(255)        NOT NULL,
+    recipient       VARCHAR(255)        NOT NULL,
+    subject         VARCHAR(255)        NOT NULL,
+    body            TEXT                NOT NULL,
+    headers         JSONB               NOT NULL DEFAULT '{}',
+    status          email_outbox_status NOT NULL DEFAULT 'pending',
+    attempts        INT                 NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP           NOT NULL,
+    last_error      TEXT                NOT NULL DEFAULT '',
+    created_at      TIMESTAMP           NOT NULL,
+    sent_at         TIMESTAMP
+);
+
+CREATE INDEX idx_email_outbox_pending_next_attempt_at ON email_outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_email_outbox_sent_at ON email_outbox (sent_at) WHERE status = 'sent';
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notification_delivery_attempted_at;
+DROP INDEX IF EXISTS idx_notification_delivery_subscription_id_kind_reference;
+DROP TABLE IF EXISTS notification_delivery;
+DROP TYPE IF EXISTS delivery_status;
\ No newline at end of file
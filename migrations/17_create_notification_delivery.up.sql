@@ -0,0 +1,24 @@
+CREATE TYPE delivery_status AS ENUM ('sent', 'failed');
+
+CREATE TABLE notification_delivery
+(
+    id                  BIGSERIAL PRIMARY KEY,
+    subscription_id     INT             NOT NULL
+        REFERENCES subscription (id) ON DELETE CASCADE,
+    kind                VARCHAR(20)     NOT NULL,
+    reference           VARCHAR(64)     NOT NULL DEFAULT '',
+    status              delivery_status NOT NULL,
+    error               TEXT            NOT NULL DEFAULT '',
+    provider_message_id VARCHAR(255)    NOT NULL DEFAULT '',
+    attempted_at        TIMESTAMP       NOT NULL
+);
+
+CREATE INDEX idx_notification_delivery_subscription_id_kind_reference ON notification_delivery (subscription_id, kind, reference) WHERE status = 'sent';
+CREATE INDEX idx_notification_delivery_attempted_at ON notification_delivery (attempted_at);
+
+INSERT INTO notification_delivery (subscription_id, kind, reference, status, attempted_at)
+SELECT s.id, 'weather_alert', wa.external_id, 'sent', wa.created_at
+FROM weather_alert wa
+         JOIN subscription s ON s.location_id = wa.location_id
+WHERE s.frequency = 'alerts'
+  AND s.status = 'confirmed';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_subscription_confirmed_frequency_id;
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX idx_subscription_confirmed_frequency_id ON subscription (frequency, id) WHERE status = 'confirmed';
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- key format before migration 19: name, region and country in lowercase with whitespace collapsed, which is
+-- lower(name) || '||' for locations stored before migration 5 added region and country.
+-- Locations told apart only by coordinates keep their id in the key to stay unique
+UPDATE location l
+SET canonical_key = k.key || CASE WHEN k.id = k.first_id THEN '' ELSE '|' || k.id END
+FROM (SELECT id, key, min(id) OVER (PARTITION BY key) AS first_id
+      FROM (SELECT id,
+                   lower(trim(regexp_replace(name, '\s+', ' ', 'g'))) || '|' ||
+                   lower(trim(regexp_replace(region, '\s+', ' ', 'g'))) || '|' ||
+                   lower(trim(regexp_replace(country, '\s+', ' ', 'g'))) AS key
+            FROM location) n) k
+WHERE l.id = k.id;
\ No newline at end of file
@@ -0,0 +1,45 @@
+-- same normalisation as model.LocationKey: name reduced to lowercase letters and digits separated by single spaces,
+-- coordinates rounded to 2 decimals
+CREATE TEMP TABLE location_key AS
+SELECT id, key, min(id) OVER (PARTITION BY key) AS first_id, min(id) OVER (PARTITION BY key) AS keep_id
+FROM (SELECT id,
+             trim(regexp_replace(lower(name), '[^[:alnum:]]+', ' ', 'g')) || '|' ||
+             round(latitude::numeric, 2) || '|' || round(longitude::numeric, 2) AS key
+      FROM location) k;
+
+-- a subscriber subscribed to two locations of the same key would end up with two subscriptions to one location,
+-- such locations are not merged and keep their id in the key instead
+UPDATE location_key k
+SET keep_id = k.id
+WHERE k.id <> k.keep_id
+  AND EXISTS (SELECT 1
+              FROM subscription s
+                       JOIN subscription o ON o.subscriber_id = s.subscriber_id AND o.location_id <> s.location_id
+                       JOIN location_key ok ON ok.id = o.location_id AND ok.first_id = k.first_id
+              WHERE s.location_id = k.id);
+
+UPDATE subscription s
+SET location_id = k.keep_id
+FROM location_key k
+WHERE s.location_id = k.id
+  AND k.id <> k.keep_id;
+
+DELETE
+FROM weather w
+    USING location_key k
+WHERE w.location_id = k.id
+  AND k.id <> k.keep_id;
+
+DELETE
+FROM location l
+    USING location_key k
+WHERE l.id = k.id
+  AND k.id <> k.keep_id;
+
+UPDATE location l
+SET canonical_key = k.key || CASE WHEN k.id = k.first_id THEN '' ELSE '|' || k.id END
+FROM location_key k
+WHERE l.id = k.id
+  AND k.id = k.keep_id;
+
+DROP TABLE location_key;
\ No newline at end of file
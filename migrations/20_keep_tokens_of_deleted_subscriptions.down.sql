@@ -0,0 +1,6 @@
+DELETE
+FROM token t
+WHERE NOT EXISTS (SELECT 1 FROM subscription s WHERE s.id = t.subscription_id);
+
+ALTER TABLE token
+    ADD CONSTRAINT token_subscription_id_fkey FOREIGN KEY (subscription_id) REFERENCES subscription (id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE token
+    DROP CONSTRAINT IF EXISTS token_subscription_id_fkey;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE weather
+    DROP COLUMN IF EXISTS provider;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE weather
+    ADD COLUMN provider VARCHAR(30) NOT NULL DEFAULT 'weatherapi';
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE location
+    DROP CONSTRAINT IF EXISTS location_canonical_key_key,
+    DROP COLUMN IF EXISTS canonical_key,
+    DROP COLUMN IF EXISTS tz_id,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS region;
+
+ALTER TABLE location
+    ADD CONSTRAINT location_name_key UNIQUE (name);
\ No newline at end of file
@@ -0,0 +1,17 @@
+ALTER TABLE location
+    DROP CONSTRAINT IF EXISTS location_name_key;
+
+ALTER TABLE location
+    ADD COLUMN region        VARCHAR(100)     NOT NULL DEFAULT '',
+    ADD COLUMN country       VARCHAR(100)     NOT NULL DEFAULT '',
+    ADD COLUMN latitude      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN longitude     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN tz_id         VARCHAR(60)      NOT NULL DEFAULT '',
+    ADD COLUMN canonical_key VARCHAR(300);
+
+UPDATE location
+SET canonical_key = lower(name) || '||';
+
+ALTER TABLE location
+    ALTER COLUMN canonical_key SET NOT NULL,
+    ADD CONSTRAINT location_canonical_key_key UNIQUE (canonical_key);
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE weather
+    DROP COLUMN IF EXISTS visibility,
+    DROP COLUMN IF EXISTS cloud_cover,
+    DROP COLUMN IF EXISTS precipitation,
+    DROP COLUMN IF EXISTS uv_index,
+    DROP COLUMN IF EXISTS pressure,
+    DROP COLUMN IF EXISTS wind_direction,
+    DROP COLUMN IF EXISTS wind_degree,
+    DROP COLUMN IF EXISTS wind_speed,
+    DROP COLUMN IF EXISTS feels_like;
\ No newline at end of file
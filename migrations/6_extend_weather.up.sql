@@ -0,0 +1,10 @@
+ALTER TABLE weather
+    ADD COLUMN feels_like     NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN wind_speed     NUMERIC(5, 2) NOT NULL DEFAULT 0 CHECK (wind_speed >= 0),
+    ADD COLUMN wind_degree    SMALLINT      NOT NULL DEFAULT 0 CHECK (wind_degree BETWEEN 0 AND 360),
+    ADD COLUMN wind_direction VARCHAR(3)    NOT NULL DEFAULT '',
+    ADD COLUMN pressure       NUMERIC(6, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN uv_index       NUMERIC(4, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN precipitation  NUMERIC(6, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN cloud_cover    NUMERIC(5, 2) NOT NULL DEFAULT 0 CHECK (cloud_cover BETWEEN 0 AND 100),
+    ADD COLUMN visibility     NUMERIC(6, 2) NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE subscription
+    DROP COLUMN IF EXISTS aqi_alert_active,
+    DROP COLUMN IF EXISTS aqi_threshold;
+
+DROP INDEX IF EXISTS idx_air_quality_location_id_measured_at;
+DROP TABLE IF EXISTS air_quality;
\ No newline at end of file
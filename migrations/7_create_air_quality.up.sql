@@ -0,0 +1,19 @@
+CREATE TABLE air_quality
+(
+    location_id  INT           NOT NULL REFERENCES location (id) ON DELETE CASCADE,
+    measured_at  TIMESTAMP     NOT NULL,
+    fetched_at   TIMESTAMP     NOT NULL,
+    pm2_5        NUMERIC(7, 2) NOT NULL,
+    pm10         NUMERIC(7, 2) NOT NULL,
+    o3           NUMERIC(7, 2) NOT NULL,
+    no2          NUMERIC(7, 2) NOT NULL,
+    us_epa_index SMALLINT      NOT NULL CHECK (us_epa_index BETWEEN 1 AND 6),
+    provider     VARCHAR(30)   NOT NULL,
+    PRIMARY KEY (location_id, measured_at)
+);
+
+CREATE INDEX idx_air_quality_location_id_measured_at ON air_quality (location_id, measured_at DESC);
+
+ALTER TABLE subscription
+    ADD COLUMN aqi_threshold    SMALLINT NOT NULL DEFAULT 0 CHECK (aqi_threshold BETWEEN 0 AND 6),
+    ADD COLUMN aqi_alert_active BOOLEAN  NOT NULL DEFAULT FALSE;
\ No newline at end of file
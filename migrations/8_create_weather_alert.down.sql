@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS weather_alert;
+
+DELETE FROM subscription WHERE frequency = 'alerts';
+
+ALTER TYPE frequency RENAME TO frequency_old;
+CREATE TYPE frequency AS ENUM ('hourly', 'daily');
+ALTER TABLE subscription ALTER COLUMN frequency TYPE frequency USING frequency::text::frequency;
+DROP TYPE frequency_old;
\ No newline at end of file
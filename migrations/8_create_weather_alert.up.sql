@@ -0,0 +1,18 @@
+ALTER TYPE frequency ADD VALUE 'alerts';
+
+CREATE TABLE weather_alert
+(
+    id          SERIAL PRIMARY KEY,
+    location_id INT          NOT NULL REFERENCES location (id) ON DELETE CASCADE,
+    external_id VARCHAR(64)  NOT NULL,
+    event       VARCHAR(200) NOT NULL,
+    headline    TEXT         NOT NULL,
+    severity    VARCHAR(60)  NOT NULL,
+    areas       TEXT         NOT NULL,
+    description TEXT         NOT NULL,
+    instruction TEXT         NOT NULL,
+    effective   TIMESTAMP,
+    expires     TIMESTAMP,
+    created_at  TIMESTAMP    NOT NULL,
+    UNIQUE (location_id, external_id)
+);
\ No newline at end of file
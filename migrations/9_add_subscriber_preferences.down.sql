@@ -0,0 +1,4 @@
+ALTER TABLE subscriber
+    DROP COLUMN IF EXISTS temperature_unit,
+    DROP COLUMN IF EXISTS wind_unit,
+    DROP COLUMN IF EXISTS language;
\ No newline at end of file
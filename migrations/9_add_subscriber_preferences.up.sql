@@ -0,0 +1,4 @@
+ALTER TABLE subscriber
+    ADD COLUMN temperature_unit VARCHAR(1)  NOT NULL DEFAULT 'C' CHECK (temperature_unit IN ('C', 'F')),
+    ADD COLUMN wind_unit        VARCHAR(3)  NOT NULL DEFAULT 'kph' CHECK (wind_unit IN ('kph', 'mph', 'mps')),
+    ADD COLUMN language         VARCHAR(10) NOT NULL DEFAULT 'en';
\ No newline at end of file
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func mustParseConditions(t *testing.T, exprs ...string) []model.Condition {
+	conditions := make([]model.Condition, 0, len(exprs))
+	for _, expr := range exprs {
+		c, ok := model.ParseCondition(expr)
+		require.True(t, ok, expr)
+		conditions = append(conditions, c)
+	}
+	return conditions
+}
+
+func TestParseCondition(t *testing.T) {
+	c, ok := model.ParseCondition("temperature < -2.5")
+	require.True(t, ok)
+	require.Equal(t, model.ConditionField_Temperature, c.Field)
+	require.Equal(t, model.ConditionOperator_Below, c.Operator)
+	require.Equal(t, float32(-2.5), c.Threshold)
+
+	c, ok = model.ParseCondition("description~Rain")
+	require.True(t, ok)
+	require.Equal(t, "rain", c.Text)
+
+	for _, expr := range []string{"", "temperature", "temperature<", "<0", "pressure>1000", "humidity~wet", "description<3", "humidity>lots"} {
+		_, ok = model.ParseCondition(expr)
+		require.False(t, ok, expr)
+	}
+}
+
+func TestEvaluateConditionsLogic(t *testing.T) {
+	snapshot := model.SnapshotFromWeather(model.Weather{Temperature: 3, Humidity: 85, Description: "Light rain"})
+
+	require.True(t, model.EvaluateConditions(nil, model.ConditionLogic_And, snapshot, false))
+	require.True(t, model.EvaluateConditions(mustParseConditions(t, "humidity>80", "description~rain"), model.ConditionLogic_And, snapshot, false))
+	require.False(t, model.EvaluateConditions(mustParseConditions(t, "temperature<0", "description~rain"), model.ConditionLogic_And, snapshot, false))
+	require.True(t, model.EvaluateConditions(mustParseConditions(t, "temperature<0", "description~rain"), model.ConditionLogic_Or, snapshot, false))
+	require.False(t, model.EvaluateConditions(mustParseConditions(t, "temperature<0", "description~snow"), model.ConditionLogic_Or, snapshot, false))
+	// field not present in current weather
+	require.False(t, model.EvaluateConditions(mustParseConditions(t, "chance_of_rain>50"), model.ConditionLogic_And, snapshot, false))
+}
+
+func TestEvaluateConditionsHysteresis(t *testing.T) {
+	conditions := mustParseConditions(t, "temperature<0")
+
+	at := func(temp float32) model.WeatherSnapshot {
+		return model.SnapshotFromWeather(model.Weather{Temperature: temp})
+	}
+
+	require.False(t, model.EvaluateConditions(conditions, model.ConditionLogic_And, at(0.5), false))
+	require.True(t, model.EvaluateConditions(conditions, model.ConditionLogic_And, at(-0.2), false))
+	// hovering just above the threshold keeps the active condition matched
+	require.True(t, model.EvaluateConditions(conditions, model.ConditionLogic_And, at(0.5), true))
+	require.False(t, model.EvaluateConditions(conditions, model.ConditionLogic_And, at(1.5), true))
+}
+
+func TestEvaluateConditionsOnDailyForecast(t *testing.T) {
+	day := model.DailyForecast{
+		MaxTemperature: 6,
+		MinTemperature: -1,
+		ChanceOfRain:   20,
+		Description:    "Partly cloudy",
+		Hours: []model.HourlyForecast{
+			{Humidity: 60, ChanceOfRain: 10, Description: "Clear"},
+			{Humidity: 90, ChanceOfRain: 70, Description: "Patchy light snow"},
+		},
+	}
+	snapshot := model.SnapshotFromDailyForecast(day)
+
+	require.True(t, model.EvaluateConditions(mustParseConditions(t, "temperature<0"), model.ConditionLogic_And, snapshot, false))
+	require.True(t, model.EvaluateConditions(mustParseConditions(t, "temperature>5"), model.ConditionLogic_And, snapshot, false))
+	require.True(t, model.EvaluateConditions(mustParseConditions(t, "chance_of_rain>50", "description~snow", "humidity>85"), model.ConditionLogic_And, snapshot, false))
+	require.False(t, model.EvaluateConditions(mustParseConditions(t, "humidity<50"), model.ConditionLogic_And, snapshot, false))
+}
+
+func TestConditionSupportedByFrequency(t *testing.T) {
+	feelsLike := mustParseConditions(t, "feels_like<0")[0]
+	chanceOfRain := mustParseConditions(t, "chance_of_rain>50")[0]
+	description := mustParseConditions(t, "description~rain")[0]
+
+	require.True(t, feelsLike.SupportedBy(model.Frequency_Hourly))
+	require.True(t, feelsLike.SupportedBy(model.Frequency_Custom))
+	require.False(t, feelsLike.SupportedBy(model.Frequency_Daily))
+	require.True(t, chanceOfRain.SupportedBy(model.Frequency_Daily))
+	require.False(t, chanceOfRain.SupportedBy(model.Frequency_Hourly))
+	require.True(t, description.SupportedBy(model.Frequency_Daily))
+	require.False(t, description.SupportedBy(model.Frequency_Alerts))
+}
+
+func TestConditionToMetric(t *testing.T) {
+	imperial := model.Preferences{TemperatureUnit: model.TemperatureUnit_Fahrenheit, WindUnit: model.WindUnit_Mph}
+
+	require.InDelta(t, 0, mustParseConditions(t, "temperature<32")[0].ToMetric(imperial).Threshold, 0.001)
+	require.InDelta(t, 16.09344, mustParseConditions(t, "wind_speed>10")[0].ToMetric(imperial).Threshold, 0.001)
+	require.InDelta(t, 80, mustParseConditions(t, "humidity>80")[0].ToMetric(imperial).Threshold, 0.001)
+	require.InDelta(t, 36, mustParseConditions(t, "wind_speed>10")[0].ToMetric(model.Preferences{WindUnit: model.WindUnit_Mps}).Threshold, 0.001)
+	require.InDelta(t, 5, mustParseConditions(t, "temperature>5")[0].ToMetric(model.DefaultPreferences()).Threshold, 0.001)
+}
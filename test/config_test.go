@@ -0,0 +1,33 @@
+package test
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEmailOutboxClaimTimeoutCoversBatch(t *testing.T) {
+	outbox := config.EmailOutbox{BatchSize: 50, SendTimeout: 10 * time.Second, ClaimTimeout: 5 * time.Minute}
+	require.Error(t, outbox.Validate())
+
+	outbox.ClaimTimeout = 500 * time.Second
+	require.Error(t, outbox.Validate())
+
+	outbox.ClaimTimeout = 15 * time.Minute
+	require.NoError(t, outbox.Validate())
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	cfg := config.ReadConfig("../config/config.yaml")
+
+	require.NoError(t, cfg.EmailOutbox.Validate())
+	require.NoError(t, cfg.Tokens.Validate())
+	require.False(t, cfg.Tokens.AcceptLegacy)
+}
+
+func TestTokensLegacyRequiresLegacyUntil(t *testing.T) {
+	require.NoError(t, config.Tokens{}.Validate())
+	require.Error(t, config.Tokens{AcceptLegacy: true}.Validate())
+	require.NoError(t, config.Tokens{AcceptLegacy: true, LegacyUntil: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)}.Validate())
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDailyDeliveryDueAtLocalHour(t *testing.T) {
+	sub := &model.Subscription{
+		DeliveryHour:    9,
+		Timezone:        "Asia/Tokyo",
+		LastDeliveredAt: time.Unix(0, 0),
+		CreatedAt:       time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// 08:45 and 09:00 in Tokyo
+	due, err := sub.DailyDeliveryDue(time.Date(2025, 5, 15, 23, 45, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, due)
+	due, err = sub.DailyDeliveryDue(time.Date(2025, 5, 16, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, due)
+
+	sub.LastDeliveredAt = time.Date(2025, 5, 16, 0, 0, 0, 0, time.UTC)
+	due, err = sub.DailyDeliveryDue(time.Date(2025, 5, 16, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, due)
+}
+
+func TestDailyDeliveryDueAcrossDSTChange(t *testing.T) {
+	sub := &model.Subscription{
+		DeliveryHour:    9,
+		Timezone:        "America/New_York",
+		LastDeliveredAt: time.Date(2025, 3, 8, 14, 0, 0, 0, time.UTC), // 09:00 EST
+		CreatedAt:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// clocks moved forward on March 9, 09:00 EDT is 13:00 UTC
+	due, err := sub.DailyDeliveryDue(time.Date(2025, 3, 9, 12, 45, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, due)
+	due, err = sub.DailyDeliveryDue(time.Date(2025, 3, 9, 13, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, due)
+}
+
+func TestDailyDeliveryNotDueOnSubscriptionDayAfterHour(t *testing.T) {
+	sub := &model.Subscription{
+		DeliveryHour:    9,
+		Timezone:        "UTC",
+		LastDeliveredAt: time.Unix(0, 0),
+		CreatedAt:       time.Date(2025, 5, 16, 15, 0, 0, 0, time.UTC),
+	}
+
+	due, err := sub.DailyDeliveryDue(time.Date(2025, 5, 16, 15, 15, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, due)
+	due, err = sub.DailyDeliveryDue(time.Date(2025, 5, 17, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, due)
+}
+
+func TestHourlyDeliveryDueOncePerHour(t *testing.T) {
+	sub := &model.Subscription{
+		LastDeliveredAt: time.Unix(0, 0),
+		CreatedAt:       time.Date(2025, 5, 16, 8, 20, 0, 0, time.UTC),
+	}
+
+	require.False(t, sub.HourlyDeliveryDue(time.Date(2025, 5, 16, 8, 45, 0, 0, time.UTC)))
+	require.True(t, sub.HourlyDeliveryDue(time.Date(2025, 5, 16, 9, 0, 0, 0, time.UTC)))
+
+	sub.LastDeliveredAt = time.Date(2025, 5, 16, 9, 0, 5, 0, time.UTC)
+	require.False(t, sub.HourlyDeliveryDue(time.Date(2025, 5, 16, 9, 15, 0, 0, time.UTC)))
+	require.True(t, sub.HourlyDeliveryDue(time.Date(2025, 5, 16, 10, 15, 0, 0, time.UTC)))
+}
+
+func TestSubscriptionRequestDeliveryValidation(t *testing.T) {
+	validate := validator.New()
+	midnight, late := int32(0), int32(24)
+
+	req := dto.SubscriptionRequest{Email: "user@example.com", City: "Kyiv", Frequency: "daily", DeliveryHour: &midnight, Timezone: "Europe/Kyiv"}
+	require.NoError(t, validate.Struct(req))
+
+	req.DeliveryHour = &late
+	require.Error(t, validate.Struct(req))
+
+	req.DeliveryHour = nil
+	req.Timezone = "Mars/Olympus"
+	require.Error(t, validate.Struct(req))
+}
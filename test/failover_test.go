@@ -0,0 +1,202 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/client/failover"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/client/openmeteo"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/client/weatherapi"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/logger/noophandler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const openMeteoGeocodingResp = `{"results":[{"name":"Kyiv","latitude":50.45466,"longitude":30.5238,"country":"Ukraine","admin1":"Kyiv City","timezone":"Europe/Kyiv"}]}`
+
+const openMeteoForecastResp = `{
+  "current": {"time": 1747415700, "temperature_2m": 7.1, "relative_humidity_2m": 90, "weather_code": 51},
+  "hourly": {
+    "time": [1747353600, 1747357200],
+    "temperature_2m": [6.2, 5.9],
+    "relative_humidity_2m": [85, 88],
+    "precipitation_probability": [10, 45],
+    "weather_code": [3, 61]
+  },
+  "daily": {
+    "time": [1747353600],
+    "temperature_2m_max": [11.4],
+    "temperature_2m_min": [4.8],
+    "temperature_2m_mean": [8.0],
+    "relative_humidity_2m_mean": [80],
+    "precipitation_probability_max": [70],
+    "weather_code": [61]
+  }
+}`
+
+func newOpenMeteoStandIn(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "Kyiv" {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(openMeteoGeocodingResp))
+	})
+	mux.HandleFunc("GET /forecast", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(openMeteoForecastResp))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newFailoverProvider(weatherApiURL, openMeteoURL string, log *slog.Logger) *failover.Provider {
+	registry := failover.NewRegistry()
+	registry.Register(failover.Entry{
+		Name:     openmeteo.ProviderName,
+		Priority: 2,
+		Timeout:  time.Second,
+		Provider: openmeteo.NewClient(openMeteoURL, openMeteoURL, openMeteoURL, &http.Client{}, log),
+	})
+	registry.Register(failover.Entry{
+		Name:     weatherapi.ProviderName,
+		Priority: 1,
+		Timeout:  100 * time.Millisecond,
+		Provider: weatherapi.NewClient(weatherApiURL, "key", &http.Client{}, weatherapi.Options{}, log),
+	})
+	return failover.NewProvider(registry, log)
+}
+
+func TestFailoverProviderFallsBackOnError(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+
+	weatherApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer weatherApi.Close()
+	openMeteo := newOpenMeteoStandIn(t)
+
+	provider := newFailoverProvider(weatherApi.URL, openMeteo.URL, log)
+
+	weather, err := provider.GetCurrentWeather(context.Background(), "Kyiv", "")
+	require.NoError(t, err)
+	require.Equal(t, openmeteo.ProviderName, weather.Weather.Provider)
+	require.Equal(t, "Kyiv", weather.Location.Name)
+	require.Equal(t, float32(7.1), weather.Weather.Temperature)
+	require.Equal(t, "Light drizzle", weather.Weather.Description)
+
+	forecast, err := provider.GetForecast(context.Background(), "Kyiv", 1, "")
+	require.NoError(t, err)
+	require.Len(t, forecast.Days, 1)
+	require.Equal(t, float32(11.4), forecast.Days[0].MaxTemperature)
+	require.Equal(t, 70, forecast.Days[0].ChanceOfRain)
+	require.Len(t, forecast.Days[0].Hours, 2)
+}
+
+func TestFailoverProviderFallsBackOnTimeout(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+
+	weatherApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer weatherApi.Close()
+	openMeteo := newOpenMeteoStandIn(t)
+
+	provider := newFailoverProvider(weatherApi.URL, openMeteo.URL, log)
+
+	forecast, err := provider.GetForecast(context.Background(), "Kyiv", 1, "")
+	require.NoError(t, err)
+	require.Equal(t, "Kyiv", forecast.Location.Name)
+}
+
+func TestFailoverProviderDoesNotFailOverOnUnknownLocation(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+
+	weatherApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"code":1006,"message":"No matching location found."}}`, http.StatusBadRequest)
+	}))
+	defer weatherApi.Close()
+
+	openMeteoCalled := false
+	openMeteo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openMeteoCalled = true
+	}))
+	defer openMeteo.Close()
+
+	provider := newFailoverProvider(weatherApi.URL, openMeteo.URL, log)
+
+	_, err := provider.GetCurrentWeather(context.Background(), "Atlantis", "")
+	require.True(t, errors.Is(err, commonerrors.ErrLocationNotFound))
+	require.False(t, openMeteoCalled)
+}
+
+func TestLocationKeyMatchesAcrossProviders(t *testing.T) {
+	fromWeatherApi := weatherapi.LocationToLocation(weatherapi.Location{Name: "Kyiv", Region: "Kyyivs'ka Oblast'", Country: "Ukraine", Lat: 50.4333, Lon: 30.5167})
+	fromOpenMeteo := openmeteo.GeocodingResultToLocation(openmeteo.GeocodingResult{Name: "Kyiv", Admin1: "Kyiv City", Country: "Ukraine", Latitude: 50.4334, Longitude: 30.5166})
+	require.Equal(t, fromWeatherApi.Key, fromOpenMeteo.Key)
+	require.Equal(t, "kyiv|50.43|30.52", fromOpenMeteo.Key)
+	require.Equal(t, "kyiv|0.00|0.00", model.LocationKey("Kyiv", -0.001, 0.001))
+
+	// towns of the same name 30 km apart are different places
+	require.NotEqual(t, model.LocationKey("Mykolaivka", 48.61, 37.71), model.LocationKey("Mykolaivka", 48.85, 37.6))
+
+	otherParis := weatherapi.LocationToLocation(weatherapi.Location{Name: "Paris", Region: "Texas", Country: "United States of America", Lat: 33.66, Lon: -95.56})
+	paris := openmeteo.GeocodingResultToLocation(openmeteo.GeocodingResult{Name: "Paris", Admin1: "Île-de-France", Country: "France", Latitude: 48.85341, Longitude: 2.3488})
+	require.NotEqual(t, paris.Key, otherParis.Key)
+}
+
+func TestOpenMeteoCoordinatesTakeTimezoneOfForecast(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("coordinates must not be geocoded")
+	})
+	mux.HandleFunc("GET /forecast", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "auto", r.URL.Query().Get("timezone"))
+		_, _ = w.Write([]byte(`{"timezone": "Europe/Kyiv", "utc_offset_seconds": 10800, "current": {"time": 1747415700, "temperature_2m": 7.1, "relative_humidity_2m": 90, "weather_code": 51}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := openmeteo.NewClient(srv.URL, srv.URL, srv.URL, &http.Client{}, slog.New(noophandler.NewNoOpHandler()))
+	weather, err := client.GetCurrentWeather(context.Background(), "50.45466,30.5238", "")
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Kyiv", weather.Location.TzId)
+	require.Equal(t, 50.45466, weather.Location.Latitude)
+}
+
+func TestOpenMeteoForecastFollowsLocalDay(t *testing.T) {
+	// local midnight of 2025-05-16 in Kyiv, hours around it belong to the previous and to the current local day
+	const resp = `{
+	  "utc_offset_seconds": 10800,
+	  "hourly": {"time": [1747339200, 1747342800], "temperature_2m": [6.2, 5.9], "relative_humidity_2m": [85, 88], "precipitation_probability": [10, 45], "weather_code": [3, 61]},
+	  "daily": {"time": [1747342800], "temperature_2m_max": [11.4], "temperature_2m_min": [4.8], "temperature_2m_mean": [8.0], "relative_humidity_2m_mean": [80], "precipitation_probability_max": [70], "weather_code": [61]}
+	}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(openMeteoGeocodingResp))
+	})
+	mux.HandleFunc("GET /forecast", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "auto", r.URL.Query().Get("timezone"))
+		_, _ = w.Write([]byte(resp))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := openmeteo.NewClient(srv.URL, srv.URL, srv.URL, &http.Client{}, slog.New(noophandler.NewNoOpHandler()))
+	forecast, err := client.GetForecast(context.Background(), "Kyiv", 1, "")
+	require.NoError(t, err)
+	require.Len(t, forecast.Days, 1)
+	require.Equal(t, 16, forecast.Days[0].Date.Day())
+	require.Equal(t, 0, forecast.Days[0].Date.Hour())
+	require.Len(t, forecast.Days[0].Hours, 1)
+	require.Equal(t, int64(1747342800), forecast.Days[0].Hours[0].Time.Unix())
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/client/weatherapi"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/handler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/httputil"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/logger/noophandler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/repository/posgresql"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestForecastHandler(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+
+	forecastData, err := os.ReadFile("./test_data/forecast_resp.json")
+	require.NoError(t, err)
+
+	var requestedPath, requestedDays string
+	testClient := httputil.NewTestHTTPClient(func(req *http.Request) (*http.Response, error) {
+		requestedPath = req.URL.Path
+		requestedDays = req.URL.Query().Get("days")
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader(forecastData)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	weatherApiClient := weatherapi.NewClient("https://api.weatherapi.com/v1", "key", testClient, weatherapi.Options{}, log)
+	weatherService := service.NewWeatherService(nil, weatherApiClient, posgresql.NewLocationRepository(), posgresql.NewWeatherRepository(), posgresql.NewAirQualityRepository(), log)
+	weatherHandler := handler.NewWeatherHandler(weatherService, validator.New(), log)
+
+	u := &url.URL{Path: "/forecast"}
+	q := u.Query()
+	q.Set("city", "Kyiv")
+	q.Set("days", "1")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	weatherHandler.GetForecast(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "/v1/forecast.json", requestedPath)
+	require.Equal(t, "1", requestedDays)
+
+	var actualForecastDto dto.ForecastDTO
+	err = json.Unmarshal(rr.Body.Bytes(), &actualForecastDto)
+	require.NoError(t, err)
+
+	require.Equal(t, "Kyiv", actualForecastDto.City)
+	require.Len(t, actualForecastDto.Days, 1)
+
+	today := actualForecastDto.Days[0]
+	require.Equal(t, "2025-05-16", today.Date)
+	require.Equal(t, float32(11.2), today.MaxTemperature)
+	require.Equal(t, float32(5.1), today.MinTemperature)
+	require.Equal(t, 87, today.ChanceOfRain)
+	require.Equal(t, "Patchy rain nearby", today.Description)
+	require.Len(t, today.Hours, 2)
+	require.Equal(t, 64, today.Hours[1].ChanceOfRain)
+}
+
+func TestForecastHandlerInvalidDays(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+
+	testClient := httputil.NewTestHTTPClient(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("provider must not be called")
+		return nil, nil
+	})
+
+	weatherApiClient := weatherapi.NewClient("https://api.weatherapi.com/v1", "key", testClient, weatherapi.Options{}, log)
+	weatherService := service.NewWeatherService(nil, weatherApiClient, posgresql.NewLocationRepository(), posgresql.NewWeatherRepository(), posgresql.NewAirQualityRepository(), log)
+	weatherHandler := handler.NewWeatherHandler(weatherService, validator.New(), log)
+
+	req, err := http.NewRequest("GET", "/forecast?city=Kyiv&days=30", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	weatherHandler.GetForecast(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
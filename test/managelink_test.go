@@ -0,0 +1,81 @@
+package test
+
+import (
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/managelink"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManageLink(t *testing.T) {
+	linker, err := managelink.New([]string{"m1:" + oldSecret}, "http://localhost/api/manage/", 24*time.Hour)
+	require.NoError(t, err)
+	now := time.Date(2025, 5, 16, 8, 0, 0, 0, time.UTC)
+
+	token := linker.Token(42, now)
+	require.Equal(t, "http://localhost/api/manage/"+token, linker.URL(token))
+
+	id, err := linker.Verify(token, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int32(42), id)
+
+	_, err = linker.Verify(token, now.Add(25*time.Hour))
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+
+	other, err := managelink.New([]string{"m1:" + newSecret}, "http://localhost", 24*time.Hour)
+	require.NoError(t, err)
+	_, err = other.Verify(token, now)
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+
+	forged, _, _ := strings.Cut(linker.Token(43, now), ".")
+	_, signature, _ := strings.Cut(token, ".")
+	for _, tampered := range []string{"", "garbage", forged + "." + signature, token + "x"} {
+		_, err = linker.Verify(tampered, now)
+		require.ErrorIs(t, err, commonerrors.ErrInvalidToken, tampered)
+	}
+}
+
+func TestManageLinkKeyRotation(t *testing.T) {
+	now := time.Date(2025, 5, 16, 8, 0, 0, 0, time.UTC)
+	old, err := managelink.New([]string{"m1:" + oldSecret}, "http://localhost", 24*time.Hour)
+	require.NoError(t, err)
+	rotated, err := managelink.New([]string{"m2:" + newSecret, "m1:" + oldSecret}, "http://localhost", 24*time.Hour)
+	require.NoError(t, err)
+	retired, err := managelink.New([]string{"m2:" + newSecret}, "http://localhost", 24*time.Hour)
+	require.NoError(t, err)
+
+	id, err := rotated.Verify(old.Token(42, now), now)
+	require.NoError(t, err)
+	require.Equal(t, int32(42), id)
+
+	id, err = retired.Verify(rotated.Token(42, now), now)
+	require.NoError(t, err)
+	require.Equal(t, int32(42), id)
+
+	_, err = retired.Verify(old.Token(42, now), now)
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+}
+
+// unsubscribe token of subscription 42 must not open management page of subscriber 42 even if keys are shared
+func TestManageLinkRejectsOtherTokens(t *testing.T) {
+	now := time.Date(2025, 5, 16, 8, 0, 0, 0, time.UTC)
+	keys := []string{"k1:" + oldSecret}
+	linker, err := managelink.New(keys, "http://localhost", 24*time.Hour)
+	require.NoError(t, err)
+	signer, err := signedtoken.New(keys)
+	require.NoError(t, err)
+
+	unsubscribe := signer.Sign(signedtoken.Claims{Id: "0b6f1f0e-6c1a-4c57-9d0c-5d6f2a1e7a11", Subject: 42, Purpose: "unsubscribe", ExpiresAt: now.Add(time.Hour)})
+	_, err = linker.Verify(unsubscribe, now)
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+}
+
+func TestManageLinkRejectsWeakKeys(t *testing.T) {
+	for _, keys := range [][]string{nil, {}, {"m1:"}, {"m1:secret"}, {"m1:" + strings.Repeat("s", signedtoken.MinSecretLength-1)}} {
+		_, err := managelink.New(keys, "http://localhost", 24*time.Hour)
+		require.Error(t, err, keys)
+	}
+}
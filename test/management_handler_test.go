@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/handler"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type stubManagementService struct {
+	token  string
+	id     int32
+	req    dto.UpdateSubscriptionRequest
+	addReq dto.ManagedSubscriptionRequest
+	err    error
+}
+
+func (s *stubManagementService) ManageURL(token string) string {
+	return "/manage/" + token
+}
+
+func (s *stubManagementService) GetManagedSubscriptions(context.Context, string) (*dto.ManagementDTO, error) {
+	return &dto.ManagementDTO{}, s.err
+}
+
+func (s *stubManagementService) AddManagedSubscription(_ context.Context, token string, req dto.ManagedSubscriptionRequest) error {
+	s.token, s.addReq = token, req
+	return s.err
+}
+
+func (s *stubManagementService) DeleteManagedSubscription(context.Context, string, int32) error {
+	return s.err
+}
+
+func (s *stubManagementService) UpdateManagedSubscription(_ context.Context, token string, id int32, req dto.UpdateSubscriptionRequest) (*dto.ManagedSubscriptionDTO, error) {
+	s.token, s.id, s.req = token, id, req
+	if s.err != nil {
+		return nil, s.err
+	}
+	updated := &dto.ManagedSubscriptionDTO{Id: id, Frequency: req.Frequency}
+	if req.DeliveryHour != nil {
+		updated.DeliveryHour = *req.DeliveryHour
+	}
+	return updated, nil
+}
+
+func patchSubscription(t *testing.T, service *stubManagementService, id string, authorization string, form string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := http.NewServeMux()
+	managementHandler := handler.NewManagementHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router.HandleFunc("PATCH /subscriptions/{id}", managementHandler.UpdateSubscription)
+
+	req := httptest.NewRequest(http.MethodPatch, "/subscriptions/"+id, strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUpdateSubscriptionHandler(t *testing.T) {
+	service := &stubManagementService{}
+
+	rec := patchSubscription(t, service, "7", "Bearer tok", "frequency=daily&deliveryHour=7")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "tok", service.token)
+	require.Equal(t, int32(7), service.id)
+	require.Equal(t, "daily", service.req.Frequency)
+	var updated dto.ManagedSubscriptionDTO
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+	require.Equal(t, int32(7), updated.DeliveryHour)
+
+	require.Equal(t, http.StatusUnauthorized, patchSubscription(t, service, "7", "", "frequency=daily").Code)
+	require.Equal(t, http.StatusBadRequest, patchSubscription(t, service, "7", "Bearer tok", "").Code)
+	require.Equal(t, http.StatusBadRequest, patchSubscription(t, service, "x", "Bearer tok", "frequency=daily").Code)
+	require.Equal(t, http.StatusBadRequest, patchSubscription(t, service, "7", "Bearer tok", "frequency=weekly").Code)
+	require.Equal(t, http.StatusBadRequest, patchSubscription(t, service, "7", "Bearer tok", "frequency=custom").Code)
+	require.Equal(t, http.StatusBadRequest, patchSubscription(t, service, "7", "Bearer tok", "deliveryHour=24").Code)
+	require.Equal(t, http.StatusBadRequest, patchSubscription(t, service, "7", "Bearer tok", "city=Kyiv&locationKey=kyiv|50.43|30.52").Code)
+
+	service.err = commonerrors.ErrInvalidToken
+	require.Equal(t, http.StatusForbidden, patchSubscription(t, service, "7", "Bearer tok", "timezone=Europe/Kyiv").Code)
+	service.err = commonerrors.ErrSubscriptionNotFound
+	require.Equal(t, http.StatusNotFound, patchSubscription(t, service, "7", "Bearer tok", "timezone=Europe/Kyiv").Code)
+	service.err = commonerrors.ErrSubscriptionAlreadyExists
+	require.Equal(t, http.StatusConflict, patchSubscription(t, service, "7", "Bearer tok", "city=Lviv").Code)
+}
+
+func TestChangeFrequencyFormUsesSubscriptionUpdate(t *testing.T) {
+	service := &stubManagementService{}
+	router := http.NewServeMux()
+	managementHandler := handler.NewManagementHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router.HandleFunc("POST /manage/{token}/subscriptions/{id}/frequency", managementHandler.ChangeFrequency)
+
+	post := func(form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/manage/tok/subscriptions/7/frequency", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("frequency=custom&schedule=0 9 * * 1")
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "/manage/tok", rec.Header().Get("Location"))
+	require.Equal(t, "tok", service.token)
+	require.Equal(t, int32(7), service.id)
+	require.Equal(t, dto.UpdateSubscriptionRequest{Frequency: "custom", Schedule: "0 9 * * 1"}, service.req)
+
+	require.Equal(t, http.StatusBadRequest, post("").Code)
+	require.Equal(t, http.StatusBadRequest, post("frequency=custom").Code)
+	service.err = commonerrors.ErrInvalidCondition
+	require.Equal(t, http.StatusBadRequest, post("frequency=daily").Code)
+}
+
+func TestAddSubscriptionFormPassesThresholdAndConditions(t *testing.T) {
+	service := &stubManagementService{}
+	router := http.NewServeMux()
+	managementHandler := handler.NewManagementHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router.HandleFunc("POST /manage/{token}/subscriptions", managementHandler.AddSubscription)
+
+	post := func(form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/manage/tok/subscriptions", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("city=Kyiv&frequency=hourly&aqiThreshold=4&condition=temperature%3C0&condition=&conditionLogic=or")
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "tok", service.token)
+	require.Equal(t, dto.ManagedSubscriptionRequest{
+		City: "Kyiv", Frequency: "hourly", AqiThreshold: 4, Conditions: []string{"temperature<0"}, ConditionLogic: "or",
+	}, service.addReq)
+
+	require.Equal(t, http.StatusSeeOther, post("city=Kyiv&frequency=daily&aqiThreshold=&condition=&condition=").Code)
+	require.Empty(t, service.addReq.Conditions)
+	require.Zero(t, service.addReq.AqiThreshold)
+
+	require.Equal(t, http.StatusBadRequest, post("city=Kyiv&frequency=hourly&aqiThreshold=7").Code)
+	require.Equal(t, http.StatusBadRequest, post("city=Kyiv&frequency=hourly&aqiThreshold=high").Code)
+	require.Equal(t, http.StatusBadRequest, post("city=Kyiv&frequency=hourly&conditionLogic=xor").Code)
+}
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/mapper"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWeatherToWeatherDTOAppliesPreferences(t *testing.T) {
+	weather := model.Weather{Temperature: 20, FeelsLike: -10, WindSpeed: 36, Description: "Sunny"}
+
+	imperial := mapper.WeatherToWeatherDTO(weather, mapper.PreferencesRequestToPreferences(dto.PreferencesRequest{TemperatureUnit: "F", WindUnit: "mph"}))
+	require.InDelta(t, 68, imperial.Temperature, 0.01)
+	require.InDelta(t, 14, imperial.FeelsLike, 0.01)
+	require.InDelta(t, 22.37, imperial.WindSpeed, 0.01)
+	require.Equal(t, "F", imperial.TemperatureUnit)
+	require.Equal(t, "mph", imperial.WindUnit)
+
+	metric := mapper.WeatherToWeatherDTO(weather, mapper.PreferencesRequestToPreferences(dto.PreferencesRequest{WindUnit: "mps"}))
+	require.Equal(t, float32(20), metric.Temperature)
+	require.InDelta(t, 10, metric.WindSpeed, 0.01)
+	require.Equal(t, "C", metric.TemperatureUnit)
+}
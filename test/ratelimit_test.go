@@ -0,0 +1,40 @@
+package test
+
+import (
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/ratelimit"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLimiterBurstThenRate(t *testing.T) {
+	limiter := ratelimit.New(20, 3)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, limiter.Wait(ctx))
+	}
+	require.Less(t, time.Since(start), 25*time.Millisecond)
+
+	require.NoError(t, limiter.Wait(ctx))
+	require.NoError(t, limiter.Wait(ctx))
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestLimiterCancelledWait(t *testing.T) {
+	limiter := ratelimit.New(1, 1)
+	require.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	limiter := ratelimit.New(0, 0)
+	for range 1000 {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+}
@@ -0,0 +1,249 @@
+package test
+
+import (
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/config"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/handler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/managelink"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/repository/posgresql"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveConfirmedSubscriptions stores count confirmed subscriptions of distinct subscribers to the same location
+func saveConfirmedSubscriptions(t *testing.T, env *TestEnv, frequency model.Frequency, count int) (int32, []int32) {
+	ctx := context.Background()
+	locationId, err := posgresql.NewLocationRepository().Upsert(ctx, env.DB, &model.Location{
+		Name: "Kyiv", Country: "Ukraine", Latitude: 50.4333, Longitude: 30.5167, TzId: "Europe/Kyiv",
+		Key: model.LocationKey("Kyiv", 50.4333, 30.5167),
+	})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	ids := make([]int32, 0, count)
+	for i := range count {
+		subscriberId, err := posgresql.NewSubscriberRepository().Save(ctx, env.DB, &model.Subscriber{
+			Email:       "user" + strconv.Itoa(i) + "@example.com",
+			Preferences: model.DefaultPreferences(),
+			CreatedAt:   now,
+		})
+		require.NoError(t, err)
+		id, err := posgresql.NewSubscriptionRepository().Save(ctx, env.DB, &model.Subscription{
+			SubscriberId:    subscriberId,
+			LocationId:      locationId,
+			Frequency:       frequency,
+			Status:          model.SubscriptionStatus_Confirmed,
+			DeliveryHour:    model.DefaultDeliveryHour,
+			Timezone:        "Europe/Kyiv",
+			ConditionLogic:  model.ConditionLogic_And,
+			LastDeliveredAt: time.Unix(0, 0),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	return locationId, ids
+}
+
+func TestFindSamePlaceIT(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.Cleanup()
+	ctx := context.Background()
+
+	locationId, _ := saveConfirmedSubscriptions(t, env, model.Frequency_Daily, 0)
+	locations := posgresql.NewLocationRepository()
+
+	samePlace, err := locations.FindSamePlace(ctx, env.DB, &model.Location{
+		Latitude: 50.45466, Longitude: 30.5238, Key: model.LocationKey("Kyiv", 50.45466, 30.5238),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, samePlace)
+	require.Equal(t, locationId, samePlace.Id)
+
+	otherPlace, err := locations.FindSamePlace(ctx, env.DB, &model.Location{
+		Latitude: 50.9, Longitude: 30.5167, Key: model.LocationKey("Kyiv", 50.9, 30.5167),
+	})
+	require.NoError(t, err)
+	require.Nil(t, otherPlace)
+}
+
+func TestDeliveryPurgeKeepsActiveAlertsIT(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.Cleanup()
+	ctx := context.Background()
+
+	locationId, subscriptionIds := saveConfirmedSubscriptions(t, env, model.Frequency_Alerts, 1)
+	subscriptionId := subscriptionIds[0]
+	now := time.Now().UTC()
+	alerts := posgresql.NewWeatherAlertRepository()
+	for externalId, expires := range map[string]time.Time{
+		"active":    now.Add(time.Hour),
+		"expired":   now.Add(-time.Hour),
+		"no-expiry": {},
+	} {
+		_, err := alerts.SaveIfAbsent(ctx, env.DB, &model.WeatherAlert{
+			LocationId: locationId, ExternalId: externalId, Event: "Storm", Expires: expires, CreatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	deliveries := posgresql.NewNotificationDeliveryRepository()
+	old := now.Add(-60 * 24 * time.Hour)
+	for _, delivery := range []model.NotificationDelivery{
+		{Kind: model.NotificationKind_WeatherAlert, Reference: "active", Status: model.DeliveryStatus_Sent},
+		{Kind: model.NotificationKind_WeatherAlert, Reference: "no-expiry", Status: model.DeliveryStatus_Sent},
+		{Kind: model.NotificationKind_WeatherAlert, Reference: "expired", Status: model.DeliveryStatus_Sent},
+		{Kind: model.NotificationKind_WeatherAlert, Reference: "active", Status: model.DeliveryStatus_Failed, Error: "timeout"},
+		{Kind: model.NotificationKind_Daily, Status: model.DeliveryStatus_Sent},
+	} {
+		delivery.SubscriptionId = subscriptionId
+		delivery.AttemptedAt = old
+		_, err := deliveries.Save(ctx, env.DB, &delivery)
+		require.NoError(t, err)
+	}
+
+	deleted, err := deliveries.DeleteAttemptedBeforeBatch(ctx, env.DB, now.Add(-30*24*time.Hour), 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted)
+
+	for reference, kept := range map[string]bool{"active": true, "no-expiry": true, "expired": false} {
+		exists, err := deliveries.ExistsSent(ctx, env.DB, subscriptionId, model.NotificationKind_WeatherAlert, reference)
+		require.NoError(t, err)
+		require.Equal(t, kept, exists, reference)
+	}
+}
+
+func TestUpdateLastDeliveredAtOnlyOnceForReadValueIT(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.Cleanup()
+	ctx := context.Background()
+	repo := posgresql.NewSubscriptionRepository()
+
+	_, subscriptionIds := saveConfirmedSubscriptions(t, env, model.Frequency_Hourly, 1)
+	read := time.Unix(0, 0)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	claimed, err := repo.UpdateLastDeliveredAt(ctx, env.DB, subscriptionIds[0], read, now)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// overlapping run has read the same delivery time and must not deliver again
+	claimed, err = repo.UpdateLastDeliveredAt(ctx, env.DB, subscriptionIds[0], read, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.False(t, claimed)
+
+	subscription, err := repo.FindById(ctx, env.DB, subscriptionIds[0])
+	require.NoError(t, err)
+	require.True(t, now.Equal(subscription.LastDeliveredAt))
+}
+
+func TestNotificationTargetsPagesIT(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.Cleanup()
+	ctx := context.Background()
+	subscriptions := posgresql.NewSubscriptionRepository()
+	tokens := posgresql.NewTokenRepository()
+
+	locationId, subscriptionIds := saveConfirmedSubscriptions(t, env, model.Frequency_Hourly, 6)
+	now := time.Now().UTC()
+	for _, id := range subscriptionIds[:5] {
+		require.NoError(t, tokens.Save(ctx, env.DB, &model.Token{
+			Token: "unsub-" + strconv.Itoa(int(id)), SubscriptionId: id, Type: model.TokenType_Unsubscribe,
+			CreatedAt: now, ExpiresAt: model.TokenNeverExpires,
+		}))
+	}
+	// only the latest unsubscribe token is joined, other token types are ignored
+	require.NoError(t, tokens.Save(ctx, env.DB, &model.Token{
+		Token: "unsub-old", SubscriptionId: subscriptionIds[0], Type: model.TokenType_Unsubscribe,
+		CreatedAt: now.Add(-time.Hour), ExpiresAt: model.TokenNeverExpires,
+	}))
+	require.NoError(t, tokens.Save(ctx, env.DB, &model.Token{
+		Token: "confirm-new", SubscriptionId: subscriptionIds[0], Type: model.TokenType_Confirmation,
+		CreatedAt: now.Add(time.Hour), ExpiresAt: now.Add(time.Hour),
+	}))
+
+	var pages [][]int32
+	var afterId int32
+	for {
+		targets, err := subscriptions.FindNotificationTargetsPage(ctx, env.DB, model.Frequency_Hourly, afterId, 2)
+		require.NoError(t, err)
+		if len(targets) == 0 {
+			break
+		}
+		var page []int32
+		for _, target := range targets {
+			require.Equal(t, locationId, target.Location.Id)
+			require.Equal(t, target.Subscription.SubscriberId, target.Subscriber.Id)
+			require.Equal(t, "unsub-"+strconv.Itoa(int(target.Subscription.Id)), target.Token.Token)
+			page = append(page, target.Subscription.Id)
+		}
+		pages = append(pages, page)
+		afterId = targets[len(targets)-1].Subscription.Id
+	}
+
+	// subscription without unsubscribe token can not be sent to and is not returned
+	require.Equal(t, [][]int32{subscriptionIds[0:2], subscriptionIds[2:4], subscriptionIds[4:5]}, pages)
+
+	daily, err := subscriptions.FindNotificationTargetsPage(ctx, env.DB, model.Frequency_Daily, 0, 2)
+	require.NoError(t, err)
+	require.Empty(t, daily)
+}
+
+func TestUnsubscribeReplayIT(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.Cleanup()
+	ctx := context.Background()
+
+	_, subscriptionIds := saveConfirmedSubscriptions(t, env, model.Frequency_Daily, 1)
+	token := model.Token{
+		Token: uuid.NewString(), SubscriptionId: subscriptionIds[0], Type: model.TokenType_Unsubscribe,
+		CreatedAt: time.Now().UTC(), ExpiresAt: model.TokenNeverExpires,
+	}
+	tokens := posgresql.NewTokenRepository()
+	require.NoError(t, tokens.Save(ctx, env.DB, &token))
+
+	signer, err := signedtoken.New([]string{"k1:" + newSecret})
+	require.NoError(t, err)
+	linker, err := managelink.New([]string{"m1:" + oldSecret}, "https://example.com/manage", time.Hour)
+	require.NoError(t, err)
+	subscriptionService := service.NewSubscriptionService(env.DB, nil, posgresql.NewLocationRepository(), posgresql.NewSubscriberRepository(),
+		posgresql.NewSubscriptionRepository(), posgresql.NewSubscriptionConditionRepository(), tokens, posgresql.NewEmailOutboxRepository(),
+		linker, signer, config.Tokens{}, config.Confirmation{}, config.EmailData{}, config.EmailData{}, config.EmailData{Text: "unsubscribed"}, config.EmailData{}, env.Log)
+	router := http.NewServeMux()
+	router.HandleFunc("POST /unsubscribe/{token}", handler.NewSubscriptionHandler(subscriptionService, validator.New(), env.Log).Unsubscribe)
+	signed := signer.Sign(signedtoken.Claims{Id: token.Token, Subject: token.SubscriptionId, Purpose: string(token.Type), ExpiresAt: token.ExpiresAt})
+	unsubscribe := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/unsubscribe/"+signed, strings.NewReader("List-Unsubscribe=One-Click"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, unsubscribe())
+	// used token outlives the deleted subscription, so replay is answered as already used rather than unknown
+	require.Equal(t, http.StatusGone, unsubscribe())
+
+	subscription, err := posgresql.NewSubscriptionRepository().FindById(ctx, env.DB, token.SubscriptionId)
+	require.NoError(t, err)
+	require.Nil(t, subscription)
+
+	// tombstone is removed once retention counted from its use has passed
+	deleted, err := tokens.DeleteExpiredBatch(ctx, env.DB, time.Now().UTC().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	stored, err := tokens.FindByToken(ctx, env.DB, token.Token)
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/schedule"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestScheduleParse(t *testing.T) {
+	weekdays, err := schedule.Parse("weekdays", 7)
+	require.NoError(t, err)
+	// Friday 08:00 -> Monday 07:00
+	require.Equal(t, time.Date(2025, 5, 19, 7, 0, 0, 0, time.UTC), weekdays.Next(time.Date(2025, 5, 16, 8, 0, 0, 0, time.UTC)))
+
+	cron, err := schedule.Parse("30 7 * * 1-5", 9)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 5, 16, 7, 30, 0, 0, time.UTC), cron.Next(time.Date(2025, 5, 16, 7, 0, 0, 0, time.UTC)))
+
+	daytime, err := schedule.Parse("daytime-every-3-hours", 9)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 5, 17, 9, 0, 0, 0, time.UTC), daytime.Next(time.Date(2025, 5, 16, 21, 0, 0, 0, time.UTC)))
+
+	for _, expr := range []string{"", "yearly", "* * * * *", "*/5 * * * *", "0 0 9 * * *", "@every 1m", "0 25 * * *"} {
+		_, err = schedule.Parse(expr, 9)
+		require.ErrorIs(t, err, commonerrors.ErrInvalidSchedule, expr)
+	}
+}
+
+func TestScheduleDueInSubscriptionTimezone(t *testing.T) {
+	sched, err := schedule.Parse("mondays", 8)
+	require.NoError(t, err)
+
+	sub := &model.Subscription{
+		Timezone:        "Europe/Kyiv",
+		LastDeliveredAt: time.Unix(0, 0),
+		CreatedAt:       time.Date(2025, 5, 14, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Monday 08:00 in Kyiv is 05:00 UTC
+	due, err := sub.ScheduleDue(sched, time.Date(2025, 5, 19, 4, 45, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, due)
+	due, err = sub.ScheduleDue(sched, time.Date(2025, 5, 19, 5, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, due)
+
+	sub.LastDeliveredAt = time.Date(2025, 5, 19, 5, 0, 0, 0, time.UTC)
+	due, err = sub.ScheduleDue(sched, time.Date(2025, 5, 20, 5, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, due)
+}
+
+func TestSubscriptionRequestScheduleValidation(t *testing.T) {
+	validate := validator.New()
+
+	req := dto.SubscriptionRequest{Email: "user@example.com", City: "Kyiv", Frequency: "custom", Schedule: "weekdays"}
+	require.NoError(t, validate.Struct(req))
+
+	req.Schedule = ""
+	require.Error(t, validate.Struct(req))
+
+	req.Frequency = "daily"
+	req.Schedule = "weekdays"
+	require.Error(t, validate.Struct(req))
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/signedtoken"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oldSecret = strings.Repeat("o", signedtoken.MinSecretLength)
+	newSecret = strings.Repeat("n", signedtoken.MinSecretLength)
+)
+
+func TestSignedToken(t *testing.T) {
+	now := time.Date(2025, 5, 16, 8, 0, 0, 0, time.UTC)
+	claims := signedtoken.Claims{
+		Id:        "0b6f1f0e-6c1a-4c57-9d0c-5d6f2a1e7a11",
+		Subject:   42,
+		Purpose:   "confirmation",
+		ExpiresAt: now.Add(15 * time.Minute),
+	}
+
+	oldSigner, err := signedtoken.New([]string{"k1:" + oldSecret})
+	require.NoError(t, err)
+	rotated, err := signedtoken.New([]string{"k2:" + newSecret, "k1:" + oldSecret})
+	require.NoError(t, err)
+	retired, err := signedtoken.New([]string{"k2:" + newSecret})
+	require.NoError(t, err)
+
+	oldToken := oldSigner.Sign(claims)
+	require.NotContains(t, oldToken, claims.Id)
+
+	verified, err := rotated.Verify(oldToken, now)
+	require.NoError(t, err)
+	require.Equal(t, claims, verified)
+
+	_, err = retired.Verify(oldToken, now)
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+
+	newToken := rotated.Sign(claims)
+	verified, err = retired.Verify(newToken, now)
+	require.NoError(t, err)
+	require.Equal(t, claims, verified)
+
+	_, err = rotated.Verify(oldToken, now.Add(16*time.Minute))
+	require.ErrorIs(t, err, commonerrors.ErrInvalidToken)
+
+	forged, _, _ := strings.Cut(rotated.Sign(signedtoken.Claims{Id: claims.Id, Subject: 43, Purpose: "unsubscribe", ExpiresAt: claims.ExpiresAt}), ".")
+	_, signature, _ := strings.Cut(newToken, ".")
+	for _, tampered := range []string{"", "garbage", claims.Id, forged + "." + signature, newToken + "x"} {
+		_, err = rotated.Verify(tampered, now)
+		require.ErrorIs(t, err, commonerrors.ErrInvalidToken, tampered)
+	}
+}
+
+func TestSignedTokenKeys(t *testing.T) {
+	for _, keys := range [][]string{nil, {"secret"}, {":secret"}, {"k1:"}, {"k.1:" + oldSecret}, {"k1:" + oldSecret, "k1:" + newSecret}, {"k1:short"}} {
+		_, err := signedtoken.New(keys)
+		require.Error(t, err, keys)
+	}
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/dto"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/handler"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type stubSubscriptionService struct {
+	subscribeErr error
+	confirmErr   error
+	resent       []string
+	unsubscribed []string
+}
+
+func (s *stubSubscriptionService) Subscribe(context.Context, dto.SubscriptionRequest) error {
+	return s.subscribeErr
+}
+
+func (s *stubSubscriptionService) Confirm(context.Context, string) error {
+	return s.confirmErr
+}
+
+func (s *stubSubscriptionService) ResendConfirmation(_ context.Context, email string) error {
+	s.resent = append(s.resent, email)
+	return nil
+}
+
+func (s *stubSubscriptionService) Unsubscribe(_ context.Context, token string) error {
+	s.unsubscribed = append(s.unsubscribed, token)
+	return nil
+}
+
+func postForm(t *testing.T, handlerFunc http.HandlerFunc, form string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handlerFunc(rec, req)
+	return rec
+}
+
+func TestResendConfirmationHandler(t *testing.T) {
+	service := &stubSubscriptionService{}
+	subscriptionHandler := handler.NewSubscriptionHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	known := postForm(t, subscriptionHandler.ResendConfirmation, "email=known@example.com")
+	unknown := postForm(t, subscriptionHandler.ResendConfirmation, "email=unknown@example.com")
+	require.Equal(t, http.StatusAccepted, known.Code)
+	require.Equal(t, known.Code, unknown.Code)
+	require.Equal(t, known.Body.String(), unknown.Body.String())
+	require.Equal(t, []string{"known@example.com", "unknown@example.com"}, service.resent)
+
+	require.Equal(t, http.StatusBadRequest, postForm(t, subscriptionHandler.ResendConfirmation, "email=not-an-email").Code)
+	require.Equal(t, http.StatusBadRequest, postForm(t, subscriptionHandler.ResendConfirmation, "").Code)
+}
+
+func TestSubscribeRateLimited(t *testing.T) {
+	service := &stubSubscriptionService{subscribeErr: commonerrors.ErrTooManyRequests}
+	subscriptionHandler := handler.NewSubscriptionHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	rec := postForm(t, subscriptionHandler.Subscribe, "email=known@example.com&city=Kyiv&frequency=daily")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestOneClickUnsubscribe(t *testing.T) {
+	service := &stubSubscriptionService{}
+	subscriptionHandler := handler.NewSubscriptionHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := http.NewServeMux()
+	router.HandleFunc("POST /unsubscribe/{token}", subscriptionHandler.Unsubscribe)
+	post := func(form string) int {
+		req := httptest.NewRequest(http.MethodPost, "/unsubscribe/tok", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusBadRequest, post(""))
+	require.Equal(t, http.StatusBadRequest, post("List-Unsubscribe=Yes"))
+	require.Empty(t, service.unsubscribed)
+
+	require.Equal(t, http.StatusOK, post("List-Unsubscribe=One-Click"))
+	require.Equal(t, []string{"tok"}, service.unsubscribed)
+}
+
+func TestOneClickUnsubscribeMultipart(t *testing.T) {
+	service := &stubSubscriptionService{}
+	subscriptionHandler := handler.NewSubscriptionHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := http.NewServeMux()
+	router.HandleFunc("POST /unsubscribe/{token}", subscriptionHandler.Unsubscribe)
+	post := func(value string) int {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		require.NoError(t, writer.WriteField("List-Unsubscribe", value))
+		require.NoError(t, writer.Close())
+		req := httptest.NewRequest(http.MethodPost, "/unsubscribe/tok", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusBadRequest, post("Yes"))
+	require.Empty(t, service.unsubscribed)
+
+	require.Equal(t, http.StatusOK, post("One-Click"))
+	require.Equal(t, []string{"tok"}, service.unsubscribed)
+}
+
+func TestUnsubscribeLinkOnlyAsksForConfirmation(t *testing.T) {
+	service := &stubSubscriptionService{}
+	subscriptionHandler := handler.NewSubscriptionHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := http.NewServeMux()
+	router.HandleFunc("GET /unsubscribe/{token}", subscriptionHandler.UnsubscribePage)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unsubscribe/tok", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rec.Body.String(), `<form method="post" action="">`)
+	require.Contains(t, rec.Body.String(), `name="List-Unsubscribe" value="One-Click"`)
+	require.Empty(t, service.unsubscribed)
+}
+
+func TestConfirmReplay(t *testing.T) {
+	service := &stubSubscriptionService{}
+	subscriptionHandler := handler.NewSubscriptionHandler(service, validator.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := http.NewServeMux()
+	router.HandleFunc("GET /confirm/{token}", subscriptionHandler.Confirm)
+	confirm := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/confirm/tok", nil))
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, confirm())
+	service.confirmErr = commonerrors.ErrTokenAlreadyUsed
+	require.Equal(t, http.StatusGone, confirm())
+	service.confirmErr = commonerrors.ErrTokenNotFound
+	require.Equal(t, http.StatusNotFound, confirm())
+}
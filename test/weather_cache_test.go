@@ -0,0 +1,129 @@
+package test
+
+import (
+	"context"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/cache"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/logger/noophandler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingProvider struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (p *countingProvider) GetCurrentWeather(_ context.Context, _ string, _ string) (*model.WeatherWithLocation, error) {
+	p.calls.Add(1)
+	if p.release != nil {
+		<-p.release
+	}
+	return &model.WeatherWithLocation{
+		Weather:  model.Weather{Temperature: 12.3, Humidity: 50, Description: "Sunny"},
+		Location: model.Location{Name: "London"},
+	}, nil
+}
+
+func (p *countingProvider) GetForecast(_ context.Context, _ string, _ int, _ string) (*model.Forecast, error) {
+	p.calls.Add(1)
+	return &model.Forecast{Location: model.Location{Name: "London"}}, nil
+}
+
+func (p *countingProvider) GetAirQuality(_ context.Context, _ string) (*model.AirQualityWithLocation, error) {
+	p.calls.Add(1)
+	return &model.AirQualityWithLocation{
+		AirQuality: model.AirQuality{PM25: 10.5, USEPAIndex: 1},
+		Location:   model.Location{Name: "London"},
+	}, nil
+}
+
+func (p *countingProvider) GetAlerts(_ context.Context, _ string) ([]model.WeatherAlert, error) {
+	p.calls.Add(1)
+	return nil, nil
+}
+
+func (p *countingProvider) SearchLocations(_ context.Context, _ string) ([]model.Location, error) {
+	p.calls.Add(1)
+	return []model.Location{{Name: "London", Country: "United Kingdom"}}, nil
+}
+
+func TestWeatherCacheDeduplicatesConcurrentLookups(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+	provider := &countingProvider{release: make(chan struct{})}
+	weatherCache := cache.NewWeatherCache(provider, time.Minute, log)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			weather, err := weatherCache.GetCurrentWeather(context.Background(), "London", "")
+			require.NoError(t, err)
+			require.Equal(t, "London", weather.Location.Name)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return provider.calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), provider.calls.Load())
+
+	_, err := weatherCache.GetCurrentWeather(context.Background(), "  london ", "")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), provider.calls.Load())
+	require.Equal(t, int64(1), weatherCache.Stats().Hits)
+}
+
+func TestWeatherCacheReturnsCopies(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+	weatherCache := cache.NewWeatherCache(&countingProvider{}, time.Minute, log)
+
+	first, err := weatherCache.GetCurrentWeather(context.Background(), "London", "")
+	require.NoError(t, err)
+	first.Weather.LocationId = 42
+
+	second, err := weatherCache.GetCurrentWeather(context.Background(), "London", "")
+	require.NoError(t, err)
+	require.Equal(t, int32(0), second.Weather.LocationId)
+}
+
+func TestWeatherCacheExpires(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+	provider := &countingProvider{}
+	weatherCache := cache.NewWeatherCache(provider, 20*time.Millisecond, log)
+
+	_, err := weatherCache.GetForecast(context.Background(), "London", 1, "")
+	require.NoError(t, err)
+	_, err = weatherCache.GetForecast(context.Background(), "London", 1, "")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), provider.calls.Load())
+
+	time.Sleep(30 * time.Millisecond)
+
+	_, err = weatherCache.GetForecast(context.Background(), "London", 1, "")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), provider.calls.Load())
+	require.Equal(t, cache.Stats{Hits: 1, Misses: 2}, weatherCache.Stats())
+}
+
+func TestWeatherCacheSearchLocations(t *testing.T) {
+	log := slog.New(noophandler.NewNoOpHandler())
+	provider := &countingProvider{}
+	weatherCache := cache.NewWeatherCache(provider, time.Minute, log)
+
+	first, err := weatherCache.SearchLocations(context.Background(), "Lond")
+	require.NoError(t, err)
+	first[0].Id = 42
+
+	second, err := weatherCache.SearchLocations(context.Background(), "lond")
+	require.NoError(t, err)
+	require.Equal(t, int32(0), second[0].Id)
+	require.Equal(t, int32(1), provider.calls.Load())
+}
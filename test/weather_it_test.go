@@ -11,9 +11,11 @@ import (
 	"github.com/denyshuzovskyi/nimbus-notify/internal/handler"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/httputil"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/logger/noophandler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/model"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/repository/posgresql"
 	"github.com/denyshuzovskyi/nimbus-notify/internal/service"
 	"github.com/denyshuzovskyi/nimbus-notify/migrations"
+	"github.com/go-playground/validator/v10"
 	"github.com/golang-migrate/migrate/v4"
 	mpostgres "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -106,11 +108,11 @@ func TestWeatherHandlerIT(t *testing.T) {
 		}, nil
 	})
 
-	weatherApiClient := weatherapi.NewClient("https://api.weatherapi.com/v1", "key", testClient, env.Log)
+	weatherApiClient := weatherapi.NewClient("https://api.weatherapi.com/v1", "key", testClient, weatherapi.Options{}, env.Log)
 	locationRepository := posgresql.NewLocationRepository()
 	weatherRepository := posgresql.NewWeatherRepository()
-	weatherService := service.NewWeatherService(env.DB, weatherApiClient, locationRepository, weatherRepository, env.Log)
-	weatherHandler := handler.NewWeatherHandler(weatherService, env.Log)
+	weatherService := service.NewWeatherService(env.DB, weatherApiClient, locationRepository, weatherRepository, posgresql.NewAirQualityRepository(), env.Log)
+	weatherHandler := handler.NewWeatherHandler(weatherService, validator.New(), env.Log)
 
 	city := "Kyiv"
 
@@ -139,10 +141,23 @@ func TestWeatherHandlerIT(t *testing.T) {
 	require.Equal(t, expectedTemp, actualWeatherDto.Temperature)
 	require.Equal(t, expectedHum, actualWeatherDto.Humidity)
 	require.Equal(t, expectedDesc, actualWeatherDto.Description)
+	require.Equal(t, float32(6.0), actualWeatherDto.FeelsLike)
+	require.Equal(t, float32(4.7), actualWeatherDto.WindSpeed)
+	require.Equal(t, "NE", actualWeatherDto.WindDirection)
+	require.Equal(t, float32(1014), actualWeatherDto.Pressure)
+	require.Equal(t, float32(100), actualWeatherDto.CloudCover)
+	require.Equal(t, float32(2.0), actualWeatherDto.Visibility)
+
+	actualLocation, err := locationRepository.FindByKey(context.Background(), env.DB, model.LocationKey(city, 50.4333, 30.5167))
+	require.NoError(t, err)
+	require.NotNil(t, actualLocation)
+	require.Equal(t, "Europe/Kiev", actualLocation.TzId)
 
-	actualWeatherFroDB, err := weatherRepository.FindLastUpdatedByLocation(context.Background(), env.DB, city)
+	actualWeatherFroDB, err := weatherRepository.FindLastUpdatedByLocationId(context.Background(), env.DB, actualLocation.Id)
 	require.NoError(t, err)
 	require.NotNil(t, actualWeatherFroDB)
+	require.Equal(t, float32(0.35), actualWeatherFroDB.Precipitation)
+	require.Equal(t, "NE", actualWeatherFroDB.WindDirection)
 	require.Equal(t, expectedTemp, actualWeatherDto.Temperature)
 	require.Equal(t, expectedHum, actualWeatherDto.Humidity)
 	require.Equal(t, expectedDesc, actualWeatherDto.Description)
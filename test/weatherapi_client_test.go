@@ -0,0 +1,197 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/client/weatherapi"
+	commonerrors "github.com/denyshuzovskyi/nimbus-notify/internal/error"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/circuitbreaker"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/logger/noophandler"
+	"github.com/denyshuzovskyi/nimbus-notify/internal/lib/retry"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newResilientWeatherApiClient(url string, breaker *circuitbreaker.CircuitBreaker) *weatherapi.Client {
+	return weatherapi.NewClient(url, "key", &http.Client{}, weatherapi.Options{
+		RequestTimeout: 100 * time.Millisecond,
+		MaxRetries:     2,
+		Backoff:        retry.Backoff{BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+		Breaker:        breaker,
+	}, slog.New(noophandler.NewNoOpHandler()))
+}
+
+func TestWeatherApiClientRetriesTransientErrors(t *testing.T) {
+	currentWeatherData, err := os.ReadFile("./test_data/current_weather_resp.json")
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write(currentWeatherData)
+		}
+	}))
+	defer srv.Close()
+
+	client := newResilientWeatherApiClient(srv.URL, nil)
+
+	weather, err := client.GetCurrentWeather(context.Background(), "Kyiv", "")
+	require.NoError(t, err)
+	require.Equal(t, "Kyiv", weather.Location.Name)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestWeatherApiClientMapsStatusCodes(t *testing.T) {
+	cases := []struct {
+		status int
+		err    error
+		calls  int32
+	}{
+		{http.StatusUnauthorized, commonerrors.ErrProviderUnauthorized, 1},
+		{http.StatusForbidden, commonerrors.ErrProviderForbidden, 1},
+		{http.StatusTooManyRequests, commonerrors.ErrProviderRateLimited, 3},
+		{http.StatusServiceUnavailable, commonerrors.ErrProviderUnavailable, 3},
+		{http.StatusBadRequest, commonerrors.ErrLocationNotFound, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			client := newResilientWeatherApiClient(srv.URL, nil)
+
+			_, err := client.GetCurrentWeather(context.Background(), "Kyiv", "")
+			require.True(t, errors.Is(err, tc.err), "got %v", err)
+			require.Equal(t, tc.calls, calls.Load())
+		})
+	}
+}
+
+func TestWeatherApiClientCircuitBreakerFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	breaker := circuitbreaker.New(3, time.Minute)
+	client := newResilientWeatherApiClient(srv.URL, breaker)
+
+	_, err := client.GetCurrentWeather(context.Background(), "Kyiv", "")
+	require.True(t, errors.Is(err, commonerrors.ErrProviderUnavailable))
+	require.Equal(t, circuitbreaker.State_Open, breaker.State())
+
+	_, err = client.GetCurrentWeather(context.Background(), "Kyiv", "")
+	require.True(t, errors.Is(err, commonerrors.ErrCircuitOpen))
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestWeatherApiClientSearchLocations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/search.json", r.URL.Path)
+		require.Equal(t, "Paris", r.URL.Query().Get("q"))
+		_, _ = w.Write([]byte(`[
+			{"id":803267,"name":"Paris","region":"Ile-de-France","country":"France","lat":48.87,"lon":2.33,"url":"paris-ile-de-france-france"},
+			{"id":2690914,"name":"Paris","region":"Texas","country":"United States of America","lat":33.66,"lon":-95.56,"url":"paris-texas-united-states-of-america"}
+		]`))
+	}))
+	defer srv.Close()
+
+	client := newResilientWeatherApiClient(srv.URL, nil)
+
+	locations, err := client.SearchLocations(context.Background(), "Paris")
+	require.NoError(t, err)
+	require.Len(t, locations, 2)
+	require.Equal(t, "Texas", locations[1].Region)
+	require.Equal(t, "33.66,-95.56", locations[1].Query())
+	require.NotEqual(t, locations[0].Key, locations[1].Key)
+}
+
+func TestWeatherApiClientGetAirQuality(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/current.json", r.URL.Path)
+		require.Equal(t, "yes", r.URL.Query().Get("aqi"))
+		_, _ = w.Write([]byte(`{
+			"location":{"name":"Kyiv","region":"Kyyivs'ka Oblast'","country":"Ukraine","lat":50.43,"lon":30.52,"tz_id":"Europe/Kiev"},
+			"current":{"last_updated_epoch":1747415700,"air_quality":{"pm2_5":38.5,"pm10":52.1,"o3":61.0,"no2":12.4,"us-epa-index":3}}
+		}`))
+	}))
+	defer srv.Close()
+
+	client := newResilientWeatherApiClient(srv.URL, nil)
+
+	airQuality, err := client.GetAirQuality(context.Background(), "Kyiv")
+	require.NoError(t, err)
+	require.Equal(t, "Kyiv", airQuality.Location.Name)
+	require.Equal(t, float32(38.5), airQuality.AirQuality.PM25)
+	require.Equal(t, float32(12.4), airQuality.AirQuality.NO2)
+	require.Equal(t, int32(3), airQuality.AirQuality.USEPAIndex)
+	require.Equal(t, int64(1747415700), airQuality.AirQuality.MeasuredAt.Unix())
+}
+
+func TestWeatherApiClientGetAlerts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/alerts.json", r.URL.Path)
+		_, _ = w.Write([]byte(`{
+			"location":{"name":"Miami","region":"Florida","country":"USA","lat":25.77,"lon":-80.19,"tz_id":"America/New_York"},
+			"alerts":{"alert":[
+				{"headline":"Flood Warning issued","severity":"Moderate","areas":"Miami-Dade","event":"Flood Warning","effective":"2025-05-16T10:00:00-04:00","expires":"2025-05-16T22:00:00-04:00","desc":"Heavy rain","instruction":"Avoid low areas"},
+				{"headline":"Heat Advisory issued","severity":"Minor","areas":"Miami-Dade","event":"Heat Advisory","effective":"","expires":"","desc":"Hot","instruction":""}
+			]}
+		}`))
+	}))
+	defer srv.Close()
+
+	client := newResilientWeatherApiClient(srv.URL, nil)
+
+	alerts, err := client.GetAlerts(context.Background(), "Miami")
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	require.Equal(t, "Flood Warning", alerts[0].Event)
+	require.Equal(t, time.Date(2025, 5, 16, 14, 0, 0, 0, time.UTC), alerts[0].Effective)
+	require.True(t, alerts[1].Effective.IsZero())
+	require.NotEmpty(t, alerts[0].ExternalId)
+	require.NotEqual(t, alerts[0].ExternalId, alerts[1].ExternalId)
+
+	again, err := client.GetAlerts(context.Background(), "Miami")
+	require.NoError(t, err)
+	require.Equal(t, alerts[0].ExternalId, again[0].ExternalId)
+}
+
+func TestWeatherApiClientRequestsLanguage(t *testing.T) {
+	weatherData, err := os.ReadFile("./test_data/current_weather_resp.json")
+	require.NoError(t, err)
+
+	var langs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		langs = append(langs, r.URL.Query().Get("lang"))
+		_, _ = w.Write(weatherData)
+	}))
+	defer srv.Close()
+
+	client := newResilientWeatherApiClient(srv.URL, nil)
+
+	_, err = client.GetCurrentWeather(context.Background(), "Kyiv", "uk")
+	require.NoError(t, err)
+	_, err = client.GetCurrentWeather(context.Background(), "Kyiv", "en")
+	require.NoError(t, err)
+	require.Equal(t, []string{"uk", ""}, langs)
+}